@@ -19,20 +19,73 @@ package controllers
 import (
 	"context"
 	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/IBM-Cloud/power-go-client/power/models"
+
 	"github.com/pkg/errors"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/klog"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/ibm-powervs-block-csi-driver/pkg/cloud"
+	"sigs.k8s.io/ibm-powervs-block-csi-driver/pkg/util"
+)
+
+const (
+	// NodeFinalizer is kept on the Nodes backed by a PowerVS instance, so the volumes still attached to
+	// the instance are detached before the Node is removed.
+	NodeFinalizer = "powervs.csi.ibm.com/volume-detach"
+
+	// StoragePoolAffinityAnnotation sets the storage pool affinity, "true" or "false", of the PowerVS
+	// instance of a Node, overriding the cluster default.
+	StoragePoolAffinityAnnotation = "powervs.csi.ibm.com/storage-pool-affinity"
+
+	// Labels describing the PowerVS instance of a Node. The disk type of the instance image is published
+	// with the util.DiskTypeKey topology label.
+	StoragePoolLabel = "powervs.csi.ibm.com/storage-pool"
+	ProcTypeLabel    = "powervs.csi.ibm.com/proc-type"
+	SysTypeLabel     = "powervs.csi.ibm.com/sys-type"
+	ZoneLabel        = "powervs.csi.ibm.com/zone"
+
+	// Event reasons
+	reasonVolumeDetached                  = "VolumeDetached"
+	reasonVolumeDetachFailed              = "VolumeDetachFailed"
+	reasonVolumeDetachTimedOut            = "VolumeDetachTimedOut"
+	reasonStoragePoolAffinityUpdated      = "StoragePoolAffinityUpdated"
+	reasonStoragePoolAffinityUpdateFailed = "StoragePoolAffinityUpdateFailed"
+	reasonInvalidStoragePoolAffinity      = "InvalidStoragePoolAffinity"
+	reasonNodeLabelsUpdated               = "NodeLabelsUpdated"
 )
 
+// instanceLabelKeys are the Node labels kept in sync with the PowerVS instance.
+var instanceLabelKeys = []string{util.DiskTypeKey, StoragePoolLabel, ProcTypeLabel, SysTypeLabel, ZoneLabel}
+
 // NodeUpdateReconciler reconciles a NodeUpdate object
 type NodeUpdateReconciler struct {
-	Client client.Client
-	Scheme *runtime.Scheme
+	Client   client.Client
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+
+	// DefaultStoragePoolAffinity is the storage pool affinity of the instances whose Node has no
+	// StoragePoolAffinityAnnotation
+	DefaultStoragePoolAffinity bool
+	// ClientCache shares the PowerVS clients between the reconciles of the nodes of a workspace
+	ClientCache *cloud.ClientCache
+	// SyncPeriod is the time between two syncs of the labels of a Node with its PowerVS instance
+	SyncPeriod time.Duration
+	// DetachTimeout is how long the finalizer of a deleted Node is kept while its volumes cannot be detached
+	DetachTimeout time.Duration
 }
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
@@ -40,11 +93,11 @@ type NodeUpdateReconciler struct {
 //
 // For more details, check Reconcile and its Result here:
 // - https://pkg.go.dev/sigs.k8s.io/controller-runtime@v0.10.0/pkg/reconcile
-func (r *NodeUpdateReconciler) Reconcile(_ context.Context, req ctrl.Request) (ctrl.Result, error) {
+func (r *NodeUpdateReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 
 	// Fetch the Node instance
 	node := corev1.Node{}
-	err := r.Client.Get(context.Background(), req.NamespacedName, &node)
+	err := r.Client.Get(ctx, req.NamespacedName, &node)
 	if err != nil {
 		if apierrors.IsNotFound(err) {
 			// req object not found, could have been deleted after reconcile req.
@@ -57,6 +110,10 @@ func (r *NodeUpdateReconciler) Reconcile(_ context.Context, req ctrl.Request) (c
 		return ctrl.Result{}, fmt.Errorf("error getting node: %v", err)
 	}
 
+	if !node.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, r.reconcileDelete(ctx, &node)
+	}
+
 	// ProviderID format: ibmpowervs://<region>/<zone>/<service_instance_id>/<powervs_machine_id>
 	if node.Spec.ProviderID != "" {
 		klog.Infof("PROVIDER-ID: %s", node.Spec.ProviderID)
@@ -69,44 +126,214 @@ func (r *NodeUpdateReconciler) Reconcile(_ context.Context, req ctrl.Request) (c
 			ServiceInstanceId: metadata.GetCloudInstanceId(),
 			InstanceId:        metadata.GetPvmInstanceId(),
 			Zone:              metadata.GetZone(),
+			ClientCache:       r.ClientCache,
 		})
 
 		if err != nil {
 			return ctrl.Result{}, errors.Errorf("failed to create nodeUpdateScope: %+v", err)
 		}
 
-		instance, err := nodeUpdateScope.Cloud.GetPVMInstanceDetails(nodeUpdateScope.InstanceId)
+		if !controllerutil.ContainsFinalizer(&node, NodeFinalizer) {
+			patch := client.MergeFrom(node.DeepCopy())
+			controllerutil.AddFinalizer(&node, NodeFinalizer)
+			if err := r.Client.Patch(ctx, &node, patch); err != nil {
+				return ctrl.Result{}, fmt.Errorf("error adding finalizer to node: %v", err)
+			}
+		}
+
+		instance, err := nodeUpdateScope.Cloud.GetPVMInstanceDetails(ctx, nodeUpdateScope.InstanceId)
 		if err != nil {
-			klog.Infof("Unable to fetch Instance Details %v", err)
-			return ctrl.Result{}, nil
+			return ctrl.Result{}, errors.Wrapf(err, "failed to fetch the details of instance %s", nodeUpdateScope.InstanceId)
+		}
+
+		if err := r.syncInstanceLabels(ctx, &node, nodeUpdateScope, instance); err != nil {
+			return ctrl.Result{}, errors.Wrapf(err, "failed to sync the labels of node %s", node.Name)
+		}
+		// The instance can change without any Node update, e.g. when it is migrated to another storage pool.
+		synced := ctrl.Result{RequeueAfter: r.SyncPeriod}
+
+		affinity, err := r.desiredStoragePoolAffinity(&node)
+		if err != nil {
+			// The annotation has to be fixed, which triggers a new reconcile.
+			klog.Infof("%s: %v", req.NamespacedName, err)
+			r.Recorder.Event(&node, corev1.EventTypeWarning, reasonInvalidStoragePoolAffinity, err.Error())
+			return synced, nil
 		}
 
-		if instance != nil {
-			klog.Infof("StoragePoolAffinity: %v", *instance.StoragePoolAffinity)
-			if *instance.StoragePoolAffinity {
-				switch *instance.Status {
-				case cloud.PowerVSInstanceStateSHUTOFF, cloud.PowerVSInstanceStateACTIVE:
-					if *instance.StoragePoolAffinity == cloud.StoragePoolAffinity {
-						klog.Infof("PowerVS instance - %v Storage pool affinity already %t", instance.PvmInstanceID, cloud.StoragePoolAffinity)
-					} else {
-						err := r.getOrUpdate(nodeUpdateScope)
-						if err != nil {
-							klog.Infof("unable to update instance StoragePoolAffinity %v", err)
-							return ctrl.Result{}, errors.Wrapf(err, "failed to reconcile VSI for IBMPowerVSMachine %s/%s", node.Namespace, node.Name)
-						}
-					}
-				default:
-					klog.Infof("PowerVS instance - %v state not ACTIVE/SHUTOFF yet", instance.PvmInstanceID)
-				}
+		if instance.StoragePoolAffinity != nil && *instance.StoragePoolAffinity == affinity {
+			klog.Infof("PowerVS instance - %v Storage pool affinity already %t", nodeUpdateScope.InstanceId, affinity)
+			return synced, nil
+		}
+
+		switch *instance.Status {
+		case cloud.PowerVSInstanceStateSHUTOFF, cloud.PowerVSInstanceStateACTIVE:
+			err := r.getOrUpdate(ctx, nodeUpdateScope, affinity)
+			if err != nil {
+				klog.Infof("unable to update instance StoragePoolAffinity %v", err)
+				r.Recorder.Eventf(&node, corev1.EventTypeWarning, reasonStoragePoolAffinityUpdateFailed,
+					"Failed to set the storage pool affinity of instance %s to %t: %v", nodeUpdateScope.InstanceId, affinity, err)
+				return ctrl.Result{}, errors.Wrapf(err, "failed to reconcile VSI for IBMPowerVSMachine %s/%s", node.Namespace, node.Name)
 			}
+			r.Recorder.Eventf(&node, corev1.EventTypeNormal, reasonStoragePoolAffinityUpdated,
+				"Set the storage pool affinity of instance %s to %t", nodeUpdateScope.InstanceId, affinity)
+			return synced, nil
+		default:
+			// Requeue with the backoff of the rate limiter until the instance can be updated.
+			klog.Infof("PowerVS instance - %v state %s not ACTIVE/SHUTOFF yet", nodeUpdateScope.InstanceId, *instance.Status)
+			return ctrl.Result{Requeue: true}, nil
 		}
 	}
 
 	return ctrl.Result{}, nil
 }
 
-func (r *NodeUpdateReconciler) getOrUpdate(scope *cloud.NodeUpdateScope) error {
-	if err := scope.Cloud.UpdateStoragePoolAffinity(scope.InstanceId); err != nil {
+// reconcileDelete detaches the volumes still attached to the PowerVS instance of a deleted Node, then
+// removes the finalizer. The finalizer is kept while the volumes fail to detach, so the Node is
+// requeued, until DetachTimeout has passed since the Node was deleted; each failure is reported with a
+// VolumeDetachFailed event and giving up with a VolumeDetachTimedOut event.
+func (r *NodeUpdateReconciler) reconcileDelete(ctx context.Context, node *corev1.Node) error {
+	if !controllerutil.ContainsFinalizer(node, NodeFinalizer) {
+		return nil
+	}
+
+	if err := r.detachVolumes(ctx, node); err != nil {
+		if time.Since(node.DeletionTimestamp.Time) < r.DetachTimeout {
+			return err
+		}
+		klog.Errorf("Giving up detaching the volumes of deleted node %s after %v: %v", node.Name, r.DetachTimeout, err)
+		r.Recorder.Eventf(node, corev1.EventTypeWarning, reasonVolumeDetachTimedOut,
+			"Removing finalizer %s without detaching the volumes after %v: %v", NodeFinalizer, r.DetachTimeout, err)
+	}
+
+	patch := client.MergeFrom(node.DeepCopy())
+	controllerutil.RemoveFinalizer(node, NodeFinalizer)
+	if err := r.Client.Patch(ctx, node, patch); err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("error removing finalizer from node: %v", err)
+	}
+	return nil
+}
+
+// detachVolumes detaches the data volumes from the PowerVS instance of the Node, whatever the power
+// state of the instance: the Node is gone, so no pod uses them anymore. There is nothing to detach when
+// the instance is gone, and boot volumes stay with the instance.
+func (r *NodeUpdateReconciler) detachVolumes(ctx context.Context, node *corev1.Node) error {
+	if node.Spec.ProviderID == "" {
+		return nil
+	}
+	metadata, err := cloud.TokenizeProviderID(node.Spec.ProviderID)
+	if err != nil {
+		return fmt.Errorf("failed to tokenize the providerID and err: %v", err)
+	}
+	scope, err := cloud.NewNodeUpdateScope(cloud.NodeUpdateScopeParams{
+		ServiceInstanceId: metadata.GetCloudInstanceId(),
+		InstanceId:        metadata.GetPvmInstanceId(),
+		Zone:              metadata.GetZone(),
+		ClientCache:       r.ClientCache,
+	})
+	if err != nil {
+		return errors.Errorf("failed to create nodeUpdateScope: %+v", err)
+	}
+
+	disks, err := scope.Cloud.ListPVMInstanceDisks(ctx, scope.InstanceId)
+	if err != nil {
+		if errors.Is(err, cloud.ErrNotFound) {
+			klog.Infof("Instance %s of deleted node %s is gone, no volume to detach", scope.InstanceId, node.Name)
+			return nil
+		}
+		return errors.Wrapf(err, "failed to list the volumes attached to instance %s", scope.InstanceId)
+	}
+
+	var failed []string
+	for _, disk := range disks {
+		if disk.BootVolume {
+			continue
+		}
+		klog.Infof("Detaching volume %s (%s) from instance %s of deleted node %s", disk.Name, disk.VolumeID, scope.InstanceId, node.Name)
+		if err := scope.Cloud.DetachDisk(ctx, disk.VolumeID, scope.InstanceId); err != nil && !errors.Is(err, cloud.ErrNotFound) {
+			klog.Errorf("Failed to detach volume %s from instance %s: %v", disk.VolumeID, scope.InstanceId, err)
+			r.Recorder.Eventf(node, corev1.EventTypeWarning, reasonVolumeDetachFailed,
+				"Failed to detach volume %s (%s) from instance %s: %v", disk.Name, disk.VolumeID, scope.InstanceId, err)
+			failed = append(failed, disk.VolumeID)
+			continue
+		}
+		r.Recorder.Eventf(node, corev1.EventTypeNormal, reasonVolumeDetached,
+			"Detached volume %s (%s) from instance %s", disk.Name, disk.VolumeID, scope.InstanceId)
+	}
+	if len(failed) > 0 {
+		return errors.Errorf("failed to detach volumes %v from instance %s", failed, scope.InstanceId)
+	}
+	return nil
+}
+
+// syncInstanceLabels publishes the facts of the PowerVS instance as Node labels. Labels whose value is
+// unknown or not a valid label value are removed.
+func (r *NodeUpdateReconciler) syncInstanceLabels(ctx context.Context, node *corev1.Node, scope *cloud.NodeUpdateScope, instance *models.PVMInstance) error {
+	labels := map[string]string{
+		StoragePoolLabel: instance.StoragePool,
+		SysTypeLabel:     instance.SysType,
+		ZoneLabel:        scope.Zone,
+	}
+	if instance.ProcType != nil {
+		labels[ProcTypeLabel] = *instance.ProcType
+	}
+	if instance.ImageID != nil {
+		// Same disk type as the topology segment reported by NodeGetInfo.
+		image, err := scope.Cloud.GetImageByID(ctx, *instance.ImageID)
+		if err != nil {
+			return errors.Wrapf(err, "failed to get the image details for %s", *instance.ImageID)
+		}
+		labels[util.DiskTypeKey] = image.DiskType
+	}
+
+	patch := client.MergeFrom(node.DeepCopy())
+	if node.Labels == nil {
+		node.Labels = map[string]string{}
+	}
+	var changes []string
+	for _, key := range instanceLabelKeys {
+		value := labels[key]
+		if errs := validation.IsValidLabelValue(value); len(errs) > 0 {
+			klog.Infof("Not labeling node %s with %s=%q: %s", node.Name, key, value, strings.Join(errs, ", "))
+			value = ""
+		}
+		current, found := node.Labels[key]
+		switch {
+		case value == "" && found:
+			delete(node.Labels, key)
+			changes = append(changes, key+"-")
+		case value != "" && current != value:
+			node.Labels[key] = value
+			changes = append(changes, key+"="+value)
+		}
+	}
+	if len(changes) == 0 {
+		return nil
+	}
+
+	if err := r.Client.Patch(ctx, node, patch); err != nil {
+		return err
+	}
+	r.Recorder.Eventf(node, corev1.EventTypeNormal, reasonNodeLabelsUpdated,
+		"Updated labels from instance %s: %s", scope.InstanceId, strings.Join(changes, ", "))
+	return nil
+}
+
+// desiredStoragePoolAffinity returns the storage pool affinity set by the Node annotation, or the
+// cluster default when the Node has none.
+func (r *NodeUpdateReconciler) desiredStoragePoolAffinity(node *corev1.Node) (bool, error) {
+	value, ok := node.Annotations[StoragePoolAffinityAnnotation]
+	if !ok {
+		return r.DefaultStoragePoolAffinity, nil
+	}
+	affinity, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("invalid value %q for annotation %s, expected true or false", value, StoragePoolAffinityAnnotation)
+	}
+	return affinity, nil
+}
+
+func (r *NodeUpdateReconciler) getOrUpdate(ctx context.Context, scope *cloud.NodeUpdateScope, affinity bool) error {
+	if err := scope.Cloud.UpdateStoragePoolAffinity(ctx, scope.InstanceId, affinity); err != nil {
 		return err
 	}
 	return nil
@@ -115,6 +342,41 @@ func (r *NodeUpdateReconciler) getOrUpdate(scope *cloud.NodeUpdateScope) error {
 // SetupWithManager sets up the controller with the Manager.
 func (r *NodeUpdateReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
-		For(&corev1.Node{}).
+		For(&corev1.Node{}, builder.WithPredicates(nodeUpdatePredicate())).
 		Complete(r)
 }
+
+// nodeUpdatePredicate filters out the Node updates that do not change the provider ID, the storage pool
+// affinity annotation, the instance labels or the deletion state, such as the periodic status updates
+// of the kubelet.
+func nodeUpdatePredicate() predicate.Predicate {
+	return predicate.Funcs{
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			oldNode, ok := e.ObjectOld.(*corev1.Node)
+			if !ok {
+				return false
+			}
+			newNode, ok := e.ObjectNew.(*corev1.Node)
+			if !ok {
+				return false
+			}
+			return oldNode.Spec.ProviderID != newNode.Spec.ProviderID ||
+				oldNode.Annotations[StoragePoolAffinityAnnotation] != newNode.Annotations[StoragePoolAffinityAnnotation] ||
+				oldNode.DeletionTimestamp.IsZero() != newNode.DeletionTimestamp.IsZero() ||
+				instanceLabelsChanged(oldNode, newNode)
+		},
+		DeleteFunc: func(event.DeleteEvent) bool {
+			// Deleted Nodes are handled through the finalizer while they are being deleted.
+			return false
+		},
+	}
+}
+
+func instanceLabelsChanged(oldNode, newNode *corev1.Node) bool {
+	for _, key := range instanceLabelKeys {
+		if oldNode.Labels[key] != newNode.Labels[key] {
+			return true
+		}
+	}
+	return false
+}
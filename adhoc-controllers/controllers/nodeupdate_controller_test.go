@@ -0,0 +1,160 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/ibm-powervs-block-csi-driver/pkg/cloud"
+	mocks "sigs.k8s.io/ibm-powervs-block-csi-driver/pkg/cloud/mocks"
+)
+
+func TestReconcileDelete(t *testing.T) {
+	const instanceID = "instance-1"
+	attachedDisk := &cloud.Disk{VolumeID: "vol-1", Name: "pvc-1", PVMInstanceIDs: []string{instanceID}}
+	bootDisk := &cloud.Disk{VolumeID: "vol-boot", Name: "boot", PVMInstanceIDs: []string{instanceID}, BootVolume: true}
+
+	testCases := []struct {
+		name         string
+		providerID   string
+		noFinalizer  bool
+		deletedSince time.Duration
+		setup        func(mockCloud *mocks.MockCloud)
+		expErr       bool
+		expFinalizer bool
+	}{
+		{
+			name:        "node without finalizer",
+			noFinalizer: true,
+		},
+		{
+			name: "detach the data volumes of the instance",
+			setup: func(mockCloud *mocks.MockCloud) {
+				mockCloud.EXPECT().ListPVMInstanceDisks(gomock.Any(), instanceID).Return([]*cloud.Disk{attachedDisk, bootDisk}, nil)
+				mockCloud.EXPECT().DetachDisk(gomock.Any(), attachedDisk.VolumeID, instanceID).Return(nil)
+			},
+		},
+		{
+			name: "volume already detached",
+			setup: func(mockCloud *mocks.MockCloud) {
+				mockCloud.EXPECT().ListPVMInstanceDisks(gomock.Any(), instanceID).Return([]*cloud.Disk{attachedDisk}, nil)
+				mockCloud.EXPECT().DetachDisk(gomock.Any(), attachedDisk.VolumeID, instanceID).Return(cloud.ErrNotFound)
+			},
+		},
+		{
+			name: "nothing to detach from a deleted instance",
+			setup: func(mockCloud *mocks.MockCloud) {
+				mockCloud.EXPECT().ListPVMInstanceDisks(gomock.Any(), instanceID).Return(nil, fmt.Errorf("instance not found: %w", cloud.ErrNotFound))
+			},
+		},
+		{
+			name: "keep the finalizer when a detach fails",
+			setup: func(mockCloud *mocks.MockCloud) {
+				mockCloud.EXPECT().ListPVMInstanceDisks(gomock.Any(), instanceID).Return([]*cloud.Disk{attachedDisk}, nil)
+				mockCloud.EXPECT().DetachDisk(gomock.Any(), attachedDisk.VolumeID, instanceID).Return(errors.New("detach failed"))
+			},
+			expErr:       true,
+			expFinalizer: true,
+		},
+		{
+			name:         "remove the finalizer when a detach fails past the timeout",
+			deletedSince: time.Hour,
+			setup: func(mockCloud *mocks.MockCloud) {
+				mockCloud.EXPECT().ListPVMInstanceDisks(gomock.Any(), instanceID).Return([]*cloud.Disk{attachedDisk}, nil)
+				mockCloud.EXPECT().DetachDisk(gomock.Any(), attachedDisk.VolumeID, instanceID).Return(errors.New("detach failed"))
+			},
+		},
+		{
+			name: "keep the finalizer when the workspace is unreachable",
+			setup: func(mockCloud *mocks.MockCloud) {
+				mockCloud.EXPECT().ListPVMInstanceDisks(gomock.Any(), instanceID).Return(nil, cloud.ErrThrottled)
+			},
+			expErr:       true,
+			expFinalizer: true,
+		},
+		{
+			name:         "keep the finalizer with an invalid provider ID",
+			providerID:   "ibmpowervs://region/zone",
+			expErr:       true,
+			expFinalizer: true,
+		},
+		{
+			name:         "remove the finalizer with an invalid provider ID past the timeout",
+			providerID:   "ibmpowervs://region/zone",
+			deletedSince: time.Hour,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockCtl := gomock.NewController(t)
+			defer mockCtl.Finish()
+
+			mockCloud := mocks.NewMockCloud(mockCtl)
+			if tc.setup != nil {
+				tc.setup(mockCloud)
+			}
+
+			providerID := tc.providerID
+			if providerID == "" {
+				providerID = "ibmpowervs://region/zone/service-instance/" + instanceID
+			}
+			node := &corev1.Node{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:              "node-1",
+					DeletionTimestamp: &metav1.Time{Time: time.Now().Add(-tc.deletedSince)},
+				},
+				Spec: corev1.NodeSpec{ProviderID: providerID},
+			}
+			if !tc.noFinalizer {
+				controllerutil.AddFinalizer(node, NodeFinalizer)
+			}
+
+			r := &NodeUpdateReconciler{
+				Client:        fake.NewClientBuilder().WithObjects(node).Build(),
+				Recorder:      record.NewFakeRecorder(10),
+				ClientCache:   newTestClientCache(mockCloud),
+				DetachTimeout: 15 * time.Minute,
+			}
+			err := r.reconcileDelete(context.Background(), node)
+			if tc.expErr != (err != nil) {
+				t.Fatalf("Expected error %t, got %v", tc.expErr, err)
+			}
+
+			// the deleted node is gone once its last finalizer is removed
+			current := &corev1.Node{}
+			if err := r.Client.Get(context.Background(), types.NamespacedName{Name: node.Name}, current); err != nil && !apierrors.IsNotFound(err) {
+				t.Fatalf("Unexpected error getting the node: %v", err)
+			}
+			if finalizer := controllerutil.ContainsFinalizer(current, NodeFinalizer); finalizer != tc.expFinalizer {
+				t.Fatalf("Expected finalizer %t, got %t", tc.expFinalizer, finalizer)
+			}
+		})
+	}
+}
@@ -0,0 +1,290 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/klog"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+	"sigs.k8s.io/ibm-powervs-block-csi-driver/pkg/cloud"
+	"sigs.k8s.io/ibm-powervs-block-csi-driver/pkg/util"
+)
+
+const (
+	// orphanedSinceTagKey is the tag recording when a volume was first found orphaned, as Unix seconds,
+	// so the grace period survives the restarts of the controller.
+	orphanedSinceTagKey = "kubernetes-orphaned-since"
+
+	// orphanedVolumeKind is the kind of the object the orphaned volume events are recorded on
+	orphanedVolumeKind = "PowerVSVolume"
+
+	// Event reasons
+	reasonOrphanedVolume             = "OrphanedVolume"
+	reasonOrphanedVolumeDeleted      = "OrphanedVolumeDeleted"
+	reasonOrphanedVolumeDeleteFailed = "OrphanedVolumeDeleteFailed"
+)
+
+var (
+	// orphanedVolumeRequest is the single request every scan of the workspace is queued with
+	orphanedVolumeRequest = reconcile.Request{NamespacedName: types.NamespacedName{Name: "orphaned-volumes"}}
+
+	orphanedVolumes = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "powervs_csi_orphaned_volumes",
+		Help: "Number of PowerVS volumes created for the cluster that are not backing any PersistentVolume.",
+	})
+	orphanedVolumesDeleted = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "powervs_csi_orphaned_volumes_deleted_total",
+		Help: "Number of orphaned PowerVS volumes deleted by the garbage collector.",
+	}, []string{"result"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(orphanedVolumes, orphanedVolumesDeleted)
+}
+
+// OrphanedVolumeReconciler looks for the PowerVS volumes created for the cluster that are no longer
+// backing a PersistentVolume, reports them and, when DryRun is off, deletes them once GracePeriod has
+// passed since they were first found orphaned.
+type OrphanedVolumeReconciler struct {
+	Client   client.Client
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+
+	// ClusterID selects the volumes tagged with the cluster ID given to the driver, it is required so
+	// that the volumes of the other clusters sharing the workspace are never touched
+	ClusterID string
+	// VolumeNamePrefix further restricts the cluster volumes to the ones whose name starts with the prefix
+	VolumeNamePrefix string
+	// DryRun only reports the orphaned volumes, without deleting them
+	DryRun bool
+	// GracePeriod is how long a volume has to stay orphaned before it is deleted
+	GracePeriod time.Duration
+	// Interval is the time between two scans of the workspace
+	Interval time.Duration
+	// ClientCache shares the PowerVS client of the workspace with the other controllers
+	ClientCache *cloud.ClientCache
+
+	// orphanedSince mirrors the orphaned-since tags of the cluster volumes, it is loaded on the first scan
+	orphanedSince map[string]time.Time
+}
+
+// Reconcile scans the workspace for orphaned volumes and requeues itself after Interval.
+func (r *OrphanedVolumeReconciler) Reconcile(ctx context.Context, _ ctrl.Request) (ctrl.Result, error) {
+	c, err := r.getCloud(ctx)
+	if err != nil {
+		return ctrl.Result{}, errors.Wrap(err, "failed to create the PowerVS client")
+	}
+
+	candidates, err := r.listClusterDisks(ctx, c)
+	if err != nil {
+		return ctrl.Result{}, errors.Wrap(err, "failed to list the cluster volumes")
+	}
+
+	pvList := corev1.PersistentVolumeList{}
+	if err := r.Client.List(ctx, &pvList); err != nil {
+		return ctrl.Result{}, fmt.Errorf("error listing persistent volumes: %v", err)
+	}
+	volumeHandles := map[string]bool{}
+	for _, pv := range pvList.Items {
+		if pv.Spec.CSI != nil && pv.Spec.CSI.Driver == util.DriverName {
+			volumeHandles[pv.Spec.CSI.VolumeHandle] = true
+		}
+	}
+
+	if r.orphanedSince == nil {
+		orphanedSince, err := loadOrphanedSince(ctx, c, candidates)
+		if err != nil {
+			return ctrl.Result{}, errors.Wrap(err, "failed to read the tags of the cluster volumes")
+		}
+		r.orphanedSince = orphanedSince
+	}
+
+	now := time.Now()
+	found := map[string]bool{}
+	orphaned := 0
+	for _, disk := range candidates {
+		found[disk.VolumeID] = true
+		since, tagged := r.orphanedSince[disk.VolumeID]
+
+		// Attached volumes are still in use by an instance, even if no PV tracks them.
+		if volumeHandles[disk.VolumeID] || len(disk.PVMInstanceIDs) > 0 {
+			if tagged {
+				// the volume is in use again, its grace period starts over when it is orphaned next time
+				if err := c.UntagDisk(ctx, disk.VolumeID, orphanedSinceTag(since)); err != nil {
+					klog.Errorf("Failed to untag volume %s (%s): %v", disk.Name, disk.VolumeID, err)
+					continue
+				}
+				delete(r.orphanedSince, disk.VolumeID)
+			}
+			continue
+		}
+
+		orphaned++
+		if !tagged {
+			since = now
+			if err := c.TagDisk(ctx, disk.VolumeID, orphanedSinceTag(since)); err != nil {
+				klog.Errorf("Failed to tag orphaned volume %s (%s): %v", disk.Name, disk.VolumeID, err)
+				continue
+			}
+			klog.Warningf("Volume %s (%s) is not backing any PersistentVolume", disk.Name, disk.VolumeID)
+			r.Recorder.Eventf(volumeReference(disk), corev1.EventTypeWarning, reasonOrphanedVolume,
+				"Volume %s (%s) is not backing any PersistentVolume", disk.Name, disk.VolumeID)
+			r.orphanedSince[disk.VolumeID] = since
+		}
+
+		if now.Sub(since) < r.GracePeriod {
+			continue
+		}
+		if r.DryRun {
+			klog.Infof("Dry run: volume %s (%s) has been orphaned since %s and would be deleted", disk.Name, disk.VolumeID, since.Format(time.RFC3339))
+			continue
+		}
+		if _, err := c.DeleteDisk(ctx, disk.VolumeID); err != nil && !errors.Is(err, cloud.ErrNotFound) {
+			klog.Errorf("Failed to delete orphaned volume %s (%s): %v", disk.Name, disk.VolumeID, err)
+			orphanedVolumesDeleted.WithLabelValues("failure").Inc()
+			r.Recorder.Eventf(volumeReference(disk), corev1.EventTypeWarning, reasonOrphanedVolumeDeleteFailed,
+				"Failed to delete orphaned volume %s (%s): %v", disk.Name, disk.VolumeID, err)
+			continue
+		}
+		klog.Infof("Deleted orphaned volume %s (%s)", disk.Name, disk.VolumeID)
+		orphanedVolumesDeleted.WithLabelValues("success").Inc()
+		r.Recorder.Eventf(volumeReference(disk), corev1.EventTypeNormal, reasonOrphanedVolumeDeleted,
+			"Deleted orphaned volume %s (%s)", disk.Name, disk.VolumeID)
+		delete(r.orphanedSince, disk.VolumeID)
+		orphaned--
+	}
+	for volumeID := range r.orphanedSince {
+		if !found[volumeID] {
+			delete(r.orphanedSince, volumeID)
+		}
+	}
+	orphanedVolumes.Set(float64(orphaned))
+
+	return ctrl.Result{RequeueAfter: r.Interval}, nil
+}
+
+// loadOrphanedSince reads the orphaned-since tags of the volumes. The tags are read once when the
+// controller starts, the scans that follow keep them up to date.
+func loadOrphanedSince(ctx context.Context, c cloud.Cloud, disks []*cloud.Disk) (map[string]time.Time, error) {
+	orphanedSince := map[string]time.Time{}
+	for _, disk := range disks {
+		tags, err := c.GetDiskTags(ctx, disk.VolumeID)
+		if err != nil {
+			return nil, err
+		}
+		value, ok := tags[orphanedSinceTagKey]
+		if !ok {
+			continue
+		}
+		seconds, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			klog.Warningf("Ignoring the invalid %s tag %q of volume %s (%s)", orphanedSinceTagKey, value, disk.Name, disk.VolumeID)
+			continue
+		}
+		orphanedSince[disk.VolumeID] = time.Unix(seconds, 0)
+	}
+	return orphanedSince, nil
+}
+
+// orphanedSinceTag returns the tag recording that a volume is orphaned since the given time.
+func orphanedSinceTag(since time.Time) map[string]string {
+	return map[string]string{orphanedSinceTagKey: strconv.FormatInt(since.Unix(), 10)}
+}
+
+// getCloud returns the PowerVS client of the workspace found in the provider ID of the first node that
+// has one, all the nodes of the cluster running in the same workspace.
+func (r *OrphanedVolumeReconciler) getCloud(ctx context.Context) (cloud.Cloud, error) {
+	nodeList := corev1.NodeList{}
+	if err := r.Client.List(ctx, &nodeList); err != nil {
+		return nil, fmt.Errorf("error listing nodes: %v", err)
+	}
+	for _, node := range nodeList.Items {
+		if node.Spec.ProviderID == "" {
+			continue
+		}
+		metadata, err := cloud.TokenizeProviderID(node.Spec.ProviderID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to tokenize the providerID and err: %v", err)
+		}
+		return r.ClientCache.Get(metadata.GetCloudInstanceId(), metadata.GetZone())
+	}
+	return nil, errors.New("no node with a providerID found")
+}
+
+// listClusterDisks returns the volumes carrying the cluster ID tag and, when VolumeNamePrefix is set,
+// the volume name prefix. The prefix alone never selects a volume, it may match the volumes of the other
+// clusters sharing the workspace.
+func (r *OrphanedVolumeReconciler) listClusterDisks(ctx context.Context, c cloud.Cloud) ([]*cloud.Disk, error) {
+	if r.ClusterID == "" {
+		return nil, errors.New("a cluster ID is required to select the cluster volumes")
+	}
+	tagged, err := c.ListDisksByTag(ctx, cloud.ClusterIDTagKey+":"+r.ClusterID)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := make([]*cloud.Disk, 0, len(tagged))
+	for _, disk := range tagged {
+		if strings.HasPrefix(disk.Name, r.VolumeNamePrefix) {
+			candidates = append(candidates, disk)
+		}
+	}
+	return candidates, nil
+}
+
+// volumeReference returns the object the events about a volume are recorded on. PowerVS volumes have
+// no Kubernetes object, so the events land in the default namespace.
+func volumeReference(disk *cloud.Disk) *corev1.ObjectReference {
+	return &corev1.ObjectReference{
+		Kind:      orphanedVolumeKind,
+		Namespace: metav1.NamespaceDefault,
+		Name:      disk.Name,
+		UID:       types.UID(disk.VolumeID),
+	}
+}
+
+// SetupWithManager sets up the controller with the Manager. The scan is triggered once on start and
+// then requeued every Interval.
+func (r *OrphanedVolumeReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	start := make(chan event.GenericEvent, 1)
+	start <- event.GenericEvent{Object: &corev1.PersistentVolume{ObjectMeta: metav1.ObjectMeta{Name: orphanedVolumeRequest.Name}}}
+
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("orphanedvolume").
+		Watches(&source.Channel{Source: start}, handler.EnqueueRequestsFromMapFunc(func(client.Object) []reconcile.Request {
+			return []reconcile.Request{orphanedVolumeRequest}
+		})).
+		Complete(r)
+}
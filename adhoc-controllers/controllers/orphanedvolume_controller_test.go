@@ -0,0 +1,287 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/ibm-powervs-block-csi-driver/pkg/cloud"
+	mocks "sigs.k8s.io/ibm-powervs-block-csi-driver/pkg/cloud/mocks"
+	"sigs.k8s.io/ibm-powervs-block-csi-driver/pkg/util"
+)
+
+const (
+	testClusterID = "cluster"
+	testVolumeID  = "vol-1"
+)
+
+var testNode = &corev1.Node{
+	ObjectMeta: metav1.ObjectMeta{Name: "node-1"},
+	Spec:       corev1.NodeSpec{ProviderID: "ibmpowervs://region/zone/service-instance/instance-1"},
+}
+
+// newTestClientCache returns a client cache serving the mock client for every workspace.
+func newTestClientCache(c cloud.Cloud) *cloud.ClientCache {
+	return cloud.NewClientCacheWithFactory(func(cloudInstanceID, zone string, debug bool) (cloud.Cloud, error) {
+		return c, nil
+	})
+}
+
+func newTestPV(volumeID string) *corev1.PersistentVolume {
+	return &corev1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{Name: "pv-" + volumeID},
+		Spec: corev1.PersistentVolumeSpec{
+			PersistentVolumeSource: corev1.PersistentVolumeSource{
+				CSI: &corev1.CSIPersistentVolumeSource{Driver: util.DriverName, VolumeHandle: volumeID},
+			},
+		},
+	}
+}
+
+// checkEvent fails the test unless the only event recorded has the reason, or no event was recorded
+// when the reason is empty.
+func checkEvent(t *testing.T, recorder *record.FakeRecorder, reason string) {
+	t.Helper()
+	select {
+	case event := <-recorder.Events:
+		if reason == "" || !strings.Contains(event, " "+reason+" ") {
+			t.Fatalf("Expected event %q, got %q", reason, event)
+		}
+	default:
+		if reason != "" {
+			t.Fatalf("Expected event %q, got none", reason)
+		}
+	}
+}
+
+func TestOrphanedVolumeReconcile(t *testing.T) {
+	orphanedLongAgo := time.Now().Add(-48 * time.Hour).Truncate(time.Second)
+	orphanedRecently := time.Now().Add(-time.Hour).Truncate(time.Second)
+	orphanedDisk := &cloud.Disk{VolumeID: testVolumeID, Name: "pvc-1"}
+
+	testCases := []struct {
+		name        string
+		disk        *cloud.Disk
+		objects     []client.Object
+		dryRun      bool
+		mockTags    map[string]string
+		setup       func(mockCloud *mocks.MockCloud)
+		expOrphaned []string
+		expEvent    string
+	}{
+		{
+			name:     "tag newly orphaned volume",
+			disk:     orphanedDisk,
+			mockTags: map[string]string{},
+			setup: func(mockCloud *mocks.MockCloud) {
+				mockCloud.EXPECT().TagDisk(gomock.Any(), testVolumeID, gomock.Any()).Return(nil)
+			},
+			expOrphaned: []string{testVolumeID},
+			expEvent:    reasonOrphanedVolume,
+		},
+		{
+			name:        "keep volume within the grace period",
+			disk:        orphanedDisk,
+			mockTags:    orphanedSinceTag(orphanedRecently),
+			expOrphaned: []string{testVolumeID},
+		},
+		{
+			name:     "delete volume past the grace period",
+			disk:     orphanedDisk,
+			mockTags: orphanedSinceTag(orphanedLongAgo),
+			setup: func(mockCloud *mocks.MockCloud) {
+				mockCloud.EXPECT().DeleteDisk(gomock.Any(), testVolumeID).Return(true, nil)
+			},
+			expEvent: reasonOrphanedVolumeDeleted,
+		},
+		{
+			name:        "dry run keeps volume past the grace period",
+			disk:        orphanedDisk,
+			dryRun:      true,
+			mockTags:    orphanedSinceTag(orphanedLongAgo),
+			expOrphaned: []string{testVolumeID},
+		},
+		{
+			name:     "delete failure keeps the volume orphaned",
+			disk:     orphanedDisk,
+			mockTags: orphanedSinceTag(orphanedLongAgo),
+			setup: func(mockCloud *mocks.MockCloud) {
+				mockCloud.EXPECT().DeleteDisk(gomock.Any(), testVolumeID).Return(false, errors.New("delete failed"))
+			},
+			expOrphaned: []string{testVolumeID},
+			expEvent:    reasonOrphanedVolumeDeleteFailed,
+		},
+		{
+			name:     "skip volume backing a PV",
+			disk:     orphanedDisk,
+			objects:  []client.Object{newTestPV(testVolumeID)},
+			mockTags: map[string]string{},
+		},
+		{
+			name:     "untag volume backing a PV again",
+			disk:     orphanedDisk,
+			objects:  []client.Object{newTestPV(testVolumeID)},
+			mockTags: orphanedSinceTag(orphanedLongAgo),
+			setup: func(mockCloud *mocks.MockCloud) {
+				mockCloud.EXPECT().UntagDisk(gomock.Any(), testVolumeID, orphanedSinceTag(orphanedLongAgo)).Return(nil)
+			},
+		},
+		{
+			name:     "skip attached volume",
+			disk:     &cloud.Disk{VolumeID: testVolumeID, Name: "pvc-1", PVMInstanceIDs: []string{"instance-1"}},
+			mockTags: map[string]string{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockCtl := gomock.NewController(t)
+			defer mockCtl.Finish()
+
+			mockCloud := mocks.NewMockCloud(mockCtl)
+			mockCloud.EXPECT().ListDisksByTag(gomock.Any(), cloud.ClusterIDTagKey+":"+testClusterID).Return([]*cloud.Disk{tc.disk}, nil)
+			mockCloud.EXPECT().GetDiskTags(gomock.Any(), testVolumeID).Return(tc.mockTags, nil)
+			if tc.setup != nil {
+				tc.setup(mockCloud)
+			}
+
+			recorder := record.NewFakeRecorder(10)
+			r := &OrphanedVolumeReconciler{
+				Client:      fake.NewClientBuilder().WithObjects(append(tc.objects, testNode)...).Build(),
+				Recorder:    recorder,
+				ClusterID:   testClusterID,
+				DryRun:      tc.dryRun,
+				GracePeriod: 24 * time.Hour,
+				Interval:    time.Hour,
+				ClientCache: newTestClientCache(mockCloud),
+			}
+			result, err := r.Reconcile(context.Background(), orphanedVolumeRequest)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if result != (ctrl.Result{RequeueAfter: r.Interval}) {
+				t.Fatalf("Expected requeue after %v, got %+v", r.Interval, result)
+			}
+
+			orphaned := []string{}
+			for volumeID := range r.orphanedSince {
+				orphaned = append(orphaned, volumeID)
+			}
+			sort.Strings(orphaned)
+			if tc.expOrphaned == nil {
+				tc.expOrphaned = []string{}
+			}
+			if !reflect.DeepEqual(orphaned, tc.expOrphaned) {
+				t.Fatalf("Expected orphaned volumes %v, got %v", tc.expOrphaned, orphaned)
+			}
+			checkEvent(t, recorder, tc.expEvent)
+		})
+	}
+}
+
+func TestOrphanedVolumeReconcileKeepsFirstSeenTime(t *testing.T) {
+	mockCtl := gomock.NewController(t)
+	defer mockCtl.Finish()
+
+	orphanedDisk := &cloud.Disk{VolumeID: testVolumeID, Name: "pvc-1"}
+	mockCloud := mocks.NewMockCloud(mockCtl)
+	mockCloud.EXPECT().ListDisksByTag(gomock.Any(), gomock.Any()).Return([]*cloud.Disk{orphanedDisk}, nil).Times(2)
+	// the tags are only read on the first scan
+	mockCloud.EXPECT().GetDiskTags(gomock.Any(), testVolumeID).Return(map[string]string{}, nil)
+	var tagged map[string]string
+	mockCloud.EXPECT().TagDisk(gomock.Any(), testVolumeID, gomock.Any()).DoAndReturn(
+		func(_ context.Context, _ string, tags map[string]string) error {
+			tagged = tags
+			return nil
+		})
+
+	r := &OrphanedVolumeReconciler{
+		Client:      fake.NewClientBuilder().WithObjects(testNode).Build(),
+		Recorder:    record.NewFakeRecorder(10),
+		ClusterID:   testClusterID,
+		GracePeriod: 24 * time.Hour,
+		Interval:    time.Hour,
+		ClientCache: newTestClientCache(mockCloud),
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := r.Reconcile(context.Background(), orphanedVolumeRequest); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+
+	seconds, err := strconv.ParseInt(tagged[orphanedSinceTagKey], 10, 64)
+	if err != nil {
+		t.Fatalf("Expected an %s tag, got %v", orphanedSinceTagKey, tagged)
+	}
+	if since := r.orphanedSince[testVolumeID]; since.Unix() != seconds {
+		t.Fatalf("Expected the volume to be orphaned since %d, got %d", seconds, since.Unix())
+	}
+}
+
+func TestOrphanedVolumeReconcileSelection(t *testing.T) {
+	mockCtl := gomock.NewController(t)
+	defer mockCtl.Finish()
+
+	// only the tagged volumes are listed, the prefix narrows them down
+	mockCloud := mocks.NewMockCloud(mockCtl)
+	mockCloud.EXPECT().ListDisksByTag(gomock.Any(), cloud.ClusterIDTagKey+":"+testClusterID).Return([]*cloud.Disk{
+		{VolumeID: testVolumeID, Name: "pvc-1"},
+		{VolumeID: "vol-2", Name: "other-2"},
+	}, nil)
+	mockCloud.EXPECT().GetDiskTags(gomock.Any(), testVolumeID).Return(orphanedSinceTag(time.Now().Add(-48*time.Hour)), nil)
+	mockCloud.EXPECT().DeleteDisk(gomock.Any(), testVolumeID).Return(true, nil)
+
+	r := &OrphanedVolumeReconciler{
+		Client:           fake.NewClientBuilder().WithObjects(testNode).Build(),
+		Recorder:         record.NewFakeRecorder(10),
+		ClusterID:        testClusterID,
+		VolumeNamePrefix: "pvc-",
+		GracePeriod:      24 * time.Hour,
+		Interval:         time.Hour,
+		ClientCache:      newTestClientCache(mockCloud),
+	}
+	if _, err := r.Reconcile(context.Background(), orphanedVolumeRequest); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// a prefix without a cluster ID never selects anything
+	r = &OrphanedVolumeReconciler{
+		Client:           fake.NewClientBuilder().WithObjects(testNode).Build(),
+		Recorder:         record.NewFakeRecorder(10),
+		VolumeNamePrefix: "pvc-",
+		GracePeriod:      24 * time.Hour,
+		Interval:         time.Hour,
+		ClientCache:      newTestClientCache(mockCloud),
+	}
+	if _, err := r.Reconcile(context.Background(), orphanedVolumeRequest); err == nil {
+		t.Fatalf("Expected an error without a cluster ID")
+	}
+}
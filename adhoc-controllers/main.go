@@ -19,6 +19,7 @@ package main
 import (
 	"flag"
 	"os"
+	"time"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
@@ -32,6 +33,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 
 	"sigs.k8s.io/ibm-powervs-block-csi-driver/adhoc-controllers/controllers"
+	"sigs.k8s.io/ibm-powervs-block-csi-driver/pkg/cloud"
 	//+kubebuilder:scaffold:imports
 )
 
@@ -50,11 +52,31 @@ func main() {
 	var metricsAddr string
 	var enableLeaderElection bool
 	var probeAddr string
+	var defaultStoragePoolAffinity bool
+	var nodeSyncPeriod time.Duration
+	var nodeDetachTimeout time.Duration
+	var orphanedVolumeGC bool
+	var clusterID string
+	var volumeNamePrefix string
+	var orphanedVolumeGCDryRun bool
+	var orphanedVolumeGCGracePeriod time.Duration
+	var orphanedVolumeGCInterval time.Duration
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8081", "The address the metric endpoint binds to.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8082", "The address the probe endpoint binds to.")
 	flag.BoolVar(&enableLeaderElection, "leader-elect", false,
 		"Enable leader election for controller manager. "+
 			"Enabling this will ensure there is only one active controller manager.")
+	flag.BoolVar(&defaultStoragePoolAffinity, "default-storage-pool-affinity", cloud.DefaultStoragePoolAffinity,
+		"The storage pool affinity of the PowerVS instances whose Node has no "+controllers.StoragePoolAffinityAnnotation+" annotation.")
+	flag.DurationVar(&nodeSyncPeriod, "node-sync-period", 10*time.Minute, "The interval between two syncs of the Node labels with the PowerVS instances.")
+	flag.DurationVar(&nodeDetachTimeout, "node-detach-timeout", 15*time.Minute,
+		"How long a deleted Node is kept while the volumes of its PowerVS instance cannot be detached, e.g. because the instance is still running.")
+	flag.BoolVar(&orphanedVolumeGC, "orphaned-volume-gc", false, "Enable the garbage collector of the PowerVS volumes no longer backing a PersistentVolume.")
+	flag.StringVar(&clusterID, "cluster-id", "", "The cluster ID the volumes were tagged with by the driver (--k8s-tag-cluster-id), used to find the volumes of the cluster.")
+	flag.StringVar(&volumeNamePrefix, "volume-name-prefix", "", "The name prefix of the volumes created by the driver, used with --cluster-id to narrow down the volumes of the cluster.")
+	flag.BoolVar(&orphanedVolumeGCDryRun, "orphaned-volume-gc-dry-run", true, "Only report the orphaned volumes, without deleting them.")
+	flag.DurationVar(&orphanedVolumeGCGracePeriod, "orphaned-volume-gc-grace-period", 24*time.Hour, "How long a volume has to stay orphaned before it is deleted.")
+	flag.DurationVar(&orphanedVolumeGCInterval, "orphaned-volume-gc-interval", time.Hour, "The interval between two scans for orphaned volumes.")
 	opts := zap.Options{
 		Development: true,
 	}
@@ -76,13 +98,41 @@ func main() {
 		os.Exit(1)
 	}
 
+	clientCache := cloud.NewClientCache(cloud.DefaultRetryPolicy)
+
 	if err = (&controllers.NodeUpdateReconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
+		Client:   mgr.GetClient(),
+		Scheme:   mgr.GetScheme(),
+		Recorder: mgr.GetEventRecorderFor("node-update-controller"),
+
+		DefaultStoragePoolAffinity: defaultStoragePoolAffinity,
+		ClientCache:                clientCache,
+		SyncPeriod:                 nodeSyncPeriod,
+		DetachTimeout:              nodeDetachTimeout,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "NodeUpdate")
 		os.Exit(1)
 	}
+	if orphanedVolumeGC {
+		if clusterID == "" {
+			setupLog.Error(nil, "--cluster-id is required by the orphaned volume garbage collector")
+			os.Exit(1)
+		}
+		if err = (&controllers.OrphanedVolumeReconciler{
+			Client:           mgr.GetClient(),
+			Scheme:           mgr.GetScheme(),
+			Recorder:         mgr.GetEventRecorderFor("orphaned-volume-controller"),
+			ClusterID:        clusterID,
+			VolumeNamePrefix: volumeNamePrefix,
+			DryRun:           orphanedVolumeGCDryRun,
+			GracePeriod:      orphanedVolumeGCGracePeriod,
+			Interval:         orphanedVolumeGCInterval,
+			ClientCache:      clientCache,
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "OrphanedVolume")
+			os.Exit(1)
+		}
+	}
 	//+kubebuilder:scaffold:builder
 
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
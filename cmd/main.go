@@ -21,6 +21,7 @@ package main
 import (
 	"flag"
 
+	"sigs.k8s.io/ibm-powervs-block-csi-driver/pkg/cloud"
 	"sigs.k8s.io/ibm-powervs-block-csi-driver/pkg/driver"
 
 	"k8s.io/klog/v2"
@@ -30,14 +31,29 @@ func main() {
 	fs := flag.NewFlagSet("ibm-powervs-block-csi-driver", flag.ExitOnError)
 	options := GetOptions(fs)
 
-	drv, err := driver.NewDriver(
+	driverOptions := []func(*driver.Options){
 		driver.WithEndpoint(options.ServerOptions.Endpoint),
 		driver.WithMode(options.DriverMode),
 		driver.WithDebug(options.ServerOptions.Debug),
 		driver.WithVolumeAttachLimit(options.NodeOptions.VolumeAttachLimit),
 		driver.WithKubeConfig(options.ServerOptions.Kubeconfig),
 		driver.WithCloudConfig(options.ServerOptions.Cloudconfig),
-	)
+		driver.WithExtraTags(options.ControllerOptions.ExtraTags),
+		driver.WithKubernetesClusterID(options.ControllerOptions.KubernetesClusterID),
+		driver.WithVolumeNameTemplate(options.ControllerOptions.VolumeNameTemplate),
+	}
+	// The API flags are controller flags, the node keeps the default policy.
+	if options.DriverMode != driver.NodeMode {
+		driverOptions = append(driverOptions, driver.WithRetryPolicy(cloud.RetryPolicy{
+			QPS:            options.ControllerOptions.APIQPS,
+			Burst:          options.ControllerOptions.APIBurst,
+			MaxRetries:     options.ControllerOptions.APIMaxRetries,
+			InitialBackoff: options.ControllerOptions.APIRetryInitialBackoff,
+			MaxBackoff:     options.ControllerOptions.APIRetryMaxBackoff,
+		}))
+	}
+
+	drv, err := driver.NewDriver(driverOptions...)
 	if err != nil {
 		klog.Fatalln(err)
 	}
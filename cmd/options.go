@@ -34,7 +34,7 @@ type Options struct {
 	DriverMode driver.Mode
 
 	*options.ServerOptions
-	//*options.ControllerOptions
+	*options.ControllerOptions
 	*options.NodeOptions
 }
 
@@ -50,9 +50,9 @@ func GetOptions(fs *flag.FlagSet) *Options {
 		args = os.Args[1:]
 		mode = driver.AllMode
 
-		serverOptions = options.ServerOptions{}
-		//controllerOptions = options.ControllerOptions{}
-		nodeOptions = options.NodeOptions{}
+		serverOptions     = options.ServerOptions{}
+		controllerOptions = options.ControllerOptions{}
+		nodeOptions       = options.NodeOptions{}
 	)
 
 	serverOptions.AddFlags(fs)
@@ -63,7 +63,7 @@ func GetOptions(fs *flag.FlagSet) *Options {
 
 		switch {
 		case cmd == string(driver.ControllerMode):
-			//controllerOptions.AddFlags(fs)
+			controllerOptions.AddFlags(fs)
 			args = os.Args[2:]
 			mode = driver.ControllerMode
 
@@ -73,12 +73,12 @@ func GetOptions(fs *flag.FlagSet) *Options {
 			mode = driver.NodeMode
 
 		case cmd == string(driver.AllMode):
-			//controllerOptions.AddFlags(fs)
+			controllerOptions.AddFlags(fs)
 			nodeOptions.AddFlags(fs)
 			args = os.Args[2:]
 
 		case strings.HasPrefix(cmd, "-"):
-			//controllerOptions.AddFlags(fs)
+			controllerOptions.AddFlags(fs)
 			nodeOptions.AddFlags(fs)
 			args = os.Args[1:]
 
@@ -104,8 +104,8 @@ func GetOptions(fs *flag.FlagSet) *Options {
 	return &Options{
 		DriverMode: mode,
 
-		ServerOptions: &serverOptions,
-		//ControllerOptions: &controllerOptions,
-		NodeOptions: &nodeOptions,
+		ServerOptions:     &serverOptions,
+		ControllerOptions: &controllerOptions,
+		NodeOptions:       &nodeOptions,
 	}
 }
@@ -16,23 +16,42 @@ limitations under the License.
 
 package options
 
-//DONE
-
-//// ControllerOptions contains options and configuration settings for the controller service.
-//type ControllerOptions struct {
-//	// ExtraTags is a map of tags that will be attached to each dynamically provisioned
-//	// resource.
-//	ExtraTags map[string]string
-//	//// ExtraVolumeTags is a map of tags that will be attached to each dynamically provisioned
-//	//// volume.
-//	//// DEPRECATED: Use ExtraTags instead.
-//	//ExtraVolumeTags map[string]string
-//	// ID of the kubernetes cluster.
-//	KubernetesClusterID string
-//}
-
-//func (s *ControllerOptions) AddFlags(fs *flag.FlagSet) {
-//	//fs.Var(cliflag.NewMapStringString(&s.ExtraTags), "extra-tags", "Extra tags to attach to each dynamically provisioned resource. It is a comma separated list of key value pairs like '<key1>=<value1>,<key2>=<value2>'")
-//	//fs.Var(cliflag.NewMapStringString(&s.ExtraVolumeTags), "extra-volume-tags", "DEPRECATED: Please use --extra-tags instead. Extra volume tags to attach to each dynamically provisioned volume. It is a comma separated list of key value pairs like '<key1>=<value1>,<key2>=<value2>'")
-//	//fs.StringVar(&s.KubernetesClusterID, "k8s-tag-cluster-id", "", "ID of the Kubernetes cluster used for tagging provisioned EBS volumes (optional).")
-//}
+import (
+	"flag"
+	"time"
+
+	cliflag "k8s.io/component-base/cli/flag"
+	"sigs.k8s.io/ibm-powervs-block-csi-driver/pkg/cloud"
+)
+
+// ControllerOptions contains options and configuration settings for the controller service.
+type ControllerOptions struct {
+	// ExtraTags is a map of tags that will be attached to each dynamically provisioned
+	// resource.
+	ExtraTags map[string]string
+	// ID of the kubernetes cluster.
+	KubernetesClusterID string
+	// VolumeNameTemplate is a Go template used to name the provisioned PowerVS volumes.
+	VolumeNameTemplate string
+	// APIQPS is the sustained number of PowerVS API requests per second.
+	APIQPS float64
+	// APIBurst is the number of PowerVS API requests that can be sent at once above APIQPS.
+	APIBurst int
+	// APIMaxRetries is the number of retries of a failed idempotent PowerVS API request.
+	APIMaxRetries int
+	// APIRetryInitialBackoff is the delay before the first retry.
+	APIRetryInitialBackoff time.Duration
+	// APIRetryMaxBackoff caps the delay between two retries.
+	APIRetryMaxBackoff time.Duration
+}
+
+func (s *ControllerOptions) AddFlags(fs *flag.FlagSet) {
+	fs.Var(cliflag.NewMapStringString(&s.ExtraTags), "extra-tags", "Extra tags to attach to each dynamically provisioned resource. It is a comma separated list of key value pairs like '<key1>=<value1>,<key2>=<value2>'")
+	fs.StringVar(&s.KubernetesClusterID, "k8s-tag-cluster-id", "", "ID of the Kubernetes cluster used for tagging provisioned PowerVS volumes (optional).")
+	fs.StringVar(&s.VolumeNameTemplate, "volume-name-template", "", "Go template used to name the provisioned PowerVS volumes, e.g. '{{.ClusterID}}-{{.PVCNamespace}}-{{.PVCName}}'. Available fields are .Name, .ClusterID, .PVCName, .PVCNamespace and .PVName. A hash of the CSI volume name is appended to keep names unique (optional).")
+	fs.Float64Var(&s.APIQPS, "api-qps", cloud.DefaultRetryPolicy.QPS, "Sustained number of PowerVS API requests per second, 0 disables the rate limiting.")
+	fs.IntVar(&s.APIBurst, "api-burst", cloud.DefaultRetryPolicy.Burst, "Number of PowerVS API requests that can be sent at once above api-qps.")
+	fs.IntVar(&s.APIMaxRetries, "api-max-retries", cloud.DefaultRetryPolicy.MaxRetries, "Number of retries of the idempotent PowerVS API requests failing with a throttling or server error, 0 disables the retries.")
+	fs.DurationVar(&s.APIRetryInitialBackoff, "api-retry-initial-backoff", cloud.DefaultRetryPolicy.InitialBackoff, "Delay before the first retry of a PowerVS API request, doubled on every following retry and randomized.")
+	fs.DurationVar(&s.APIRetryMaxBackoff, "api-retry-max-backoff", cloud.DefaultRetryPolicy.MaxBackoff, "Maximum delay between two retries of a PowerVS API request. Responses asking to retry later than that are not retried.")
+}
@@ -16,6 +16,7 @@ package main
 import (
 	"flag"
 	"os"
+	"reflect"
 	"strconv"
 	"testing"
 
@@ -34,6 +35,14 @@ func TestGetOptions(t *testing.T) {
 
 		endpointFlagName := "endpoint"
 		endpoint := "foo"
+		extraTagsFlagName := "extra-tags"
+		extraTagKey := "bar"
+		extraTagValue := "baz"
+		extraTags := map[string]string{
+			extraTagKey: extraTagValue,
+		}
+		clusterIDFlagName := "k8s-tag-cluster-id"
+		clusterID := "cluster-1"
 		VolumeAttachLimitFlagName := "volume-attach-limit"
 		var VolumeAttachLimit int64 = 42
 
@@ -45,6 +54,11 @@ func TestGetOptions(t *testing.T) {
 			args = append(args, "-"+endpointFlagName+"="+endpoint)
 		}
 
+		if withControllerOptions {
+			args = append(args, "-"+extraTagsFlagName+"="+extraTagKey+"="+extraTagValue)
+			args = append(args, "-"+clusterIDFlagName+"="+clusterID)
+		}
+
 		if withNodeOptions {
 			args = append(args, "-"+VolumeAttachLimitFlagName+"="+strconv.FormatInt(VolumeAttachLimit, 10))
 		}
@@ -65,6 +79,19 @@ func TestGetOptions(t *testing.T) {
 			}
 		}
 
+		if withControllerOptions {
+			extraTagsFlag := flagSet.Lookup(extraTagsFlagName)
+			if extraTagsFlag == nil {
+				t.Fatalf("expected %q flag to be added but it is not", extraTagsFlagName)
+			}
+			if !reflect.DeepEqual(options.ControllerOptions.ExtraTags, extraTags) {
+				t.Fatalf("expected extra tags to be %q but it is %q", extraTags, options.ControllerOptions.ExtraTags)
+			}
+			if options.ControllerOptions.KubernetesClusterID != clusterID {
+				t.Fatalf("expected cluster ID to be %q but it is %q", clusterID, options.ControllerOptions.KubernetesClusterID)
+			}
+		}
+
 		if withNodeOptions {
 			VolumeAttachLimitFlag := flagSet.Lookup(VolumeAttachLimitFlagName)
 			if VolumeAttachLimitFlag == nil {
@@ -0,0 +1,255 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloud
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// cacheResyncPeriod is how often the cached volumes and instances are listed again in the background
+	cacheResyncPeriod = 5 * time.Minute
+	// cacheMissResyncPeriod is how old the cached instances have to be for a miss to list them again
+	cacheMissResyncPeriod = 10 * time.Second
+)
+
+// diskEntry holds the keys a volume is indexed with.
+type diskEntry struct {
+	name    string
+	wwn     string
+	updated time.Time
+}
+
+type instanceEntry struct {
+	instance PVMInstance
+	updated  time.Time
+}
+
+// resourceCache indexes the volumes and the PVM instances of a workspace so that the lookups by name and
+// WWN do not list the whole workspace on every call. The cache is filled by the listings, which run every
+// cacheResyncPeriod once the cache is used, and kept up to date by the writes and the direct GETs of the
+// client. A volume miss only lists the volumes when the background listing is late, the volumes created
+// by the driver being cached by the create; an instance miss lists the instances once the last listing is
+// older than cacheMissResyncPeriod, so that new nodes are found.
+//
+// Volumes are only indexed, a lookup resolves the volume ID from the cache and then gets the volume so
+// that its state is never stale. Instances hardly change and are served from the cache.
+type resourceCache struct {
+	mu sync.Mutex
+
+	disks         map[string]*diskEntry
+	diskIDsByName map[string]string
+	// diskIDsByWWN is keyed by the lowercase WWN
+	diskIDsByWWN map[string]string
+	disksSynced  time.Time
+
+	instances         map[string]*instanceEntry
+	instanceIDsByName map[string]string
+	instancesSynced   time.Time
+
+	// volumeTypes holds the storage types offered by the workspace
+	volumeTypes       map[string]bool
+	volumeTypesSynced time.Time
+
+	// disksSyncMu and instancesSyncMu make the concurrent misses wait for a single listing
+	disksSyncMu     sync.Mutex
+	instancesSyncMu sync.Mutex
+
+	// disksRefresh and instancesRefresh start the background listings on the first lookup
+	disksRefresh     sync.Once
+	instancesRefresh sync.Once
+}
+
+func newResourceCache() *resourceCache {
+	return &resourceCache{
+		disks:             map[string]*diskEntry{},
+		diskIDsByName:     map[string]string{},
+		diskIDsByWWN:      map[string]string{},
+		instances:         map[string]*instanceEntry{},
+		instanceIDsByName: map[string]string{},
+	}
+}
+
+func (c *resourceCache) diskIDByName(name string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	volumeID, found := c.diskIDsByName[name]
+	return volumeID, found
+}
+
+func (c *resourceCache) diskIDByWWN(wwn string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	volumeID, found := c.diskIDsByWWN[strings.ToLower(wwn)]
+	return volumeID, found
+}
+
+// disksStale returns true when the volumes were last listed more than maxAge ago.
+func (c *resourceCache) disksStale(maxAge time.Duration) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return time.Since(c.disksSynced) > maxAge
+}
+
+// invalidateDisks makes the next miss list the volumes again, it is called when a create fails after
+// the request may have reached PowerVS.
+func (c *resourceCache) invalidateDisks() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.disksSynced = time.Time{}
+}
+
+// setDisks replaces the cached volumes with a listing started at synced. The volumes written since
+// then are kept, the listing may have missed them.
+func (c *resourceCache) setDisks(disks []*Disk, synced time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	previous := c.disks
+	c.disks = map[string]*diskEntry{}
+	c.diskIDsByName = map[string]string{}
+	c.diskIDsByWWN = map[string]string{}
+	for _, disk := range disks {
+		c.putDiskLocked(disk.VolumeID, disk.Name, disk.WWN, synced)
+	}
+	for volumeID, entry := range previous {
+		if entry.updated.After(synced) {
+			c.putDiskLocked(volumeID, entry.name, entry.wwn, entry.updated)
+		}
+	}
+	c.disksSynced = synced
+}
+
+func (c *resourceCache) putDisk(disk *Disk) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.putDiskLocked(disk.VolumeID, disk.Name, disk.WWN, time.Now())
+}
+
+func (c *resourceCache) putDiskLocked(volumeID, name, wwn string, updated time.Time) {
+	c.deleteDiskLocked(volumeID)
+	wwn = strings.ToLower(wwn)
+	c.disks[volumeID] = &diskEntry{name: name, wwn: wwn, updated: updated}
+	if name != "" {
+		c.diskIDsByName[name] = volumeID
+	}
+	if wwn != "" {
+		c.diskIDsByWWN[wwn] = volumeID
+	}
+}
+
+func (c *resourceCache) deleteDisk(volumeID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.deleteDiskLocked(volumeID)
+}
+
+func (c *resourceCache) deleteDiskLocked(volumeID string) {
+	entry, found := c.disks[volumeID]
+	if !found {
+		return
+	}
+	if c.diskIDsByName[entry.name] == volumeID {
+		delete(c.diskIDsByName, entry.name)
+	}
+	if c.diskIDsByWWN[entry.wwn] == volumeID {
+		delete(c.diskIDsByWWN, entry.wwn)
+	}
+	delete(c.disks, volumeID)
+}
+
+// instance returns the cached instance, unless it was cached more than cacheResyncPeriod ago.
+func (c *resourceCache) instance(instanceID string) (*PVMInstance, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, found := c.instances[instanceID]
+	if !found || time.Since(entry.updated) > cacheResyncPeriod {
+		return nil, false
+	}
+	in := entry.instance
+	return &in, true
+}
+
+func (c *resourceCache) instanceByName(name string) (*PVMInstance, bool) {
+	c.mu.Lock()
+	instanceID, found := c.instanceIDsByName[name]
+	c.mu.Unlock()
+	if !found {
+		return nil, false
+	}
+	return c.instance(instanceID)
+}
+
+// instancesStale returns true when the instances were last listed more than maxAge ago.
+func (c *resourceCache) instancesStale(maxAge time.Duration) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return time.Since(c.instancesSynced) > maxAge
+}
+
+func (c *resourceCache) setInstances(instances []*PVMInstance, synced time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	previous := c.instances
+	c.instances = map[string]*instanceEntry{}
+	c.instanceIDsByName = map[string]string{}
+	for _, in := range instances {
+		c.putInstanceLocked(in, synced)
+	}
+	for _, entry := range previous {
+		if entry.updated.After(synced) {
+			c.putInstanceLocked(&entry.instance, entry.updated)
+		}
+	}
+	c.instancesSynced = synced
+}
+
+func (c *resourceCache) putInstance(in *PVMInstance) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.putInstanceLocked(in, time.Now())
+}
+
+func (c *resourceCache) putInstanceLocked(in *PVMInstance, updated time.Time) {
+	if previous, found := c.instances[in.ID]; found && c.instanceIDsByName[previous.instance.Name] == in.ID {
+		delete(c.instanceIDsByName, previous.instance.Name)
+	}
+	c.instances[in.ID] = &instanceEntry{instance: *in, updated: updated}
+	c.instanceIDsByName[in.Name] = in.ID
+}
+
+// volumeTypeOffered returns whether the workspace offers the volume type, fresh is false when the
+// storage types have to be listed again.
+func (c *resourceCache) volumeTypeOffered(volumeType string) (offered, fresh bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if time.Since(c.volumeTypesSynced) > cacheResyncPeriod {
+		return false, false
+	}
+	return c.volumeTypes[volumeType], true
+}
+
+func (c *resourceCache) setVolumeTypes(volumeTypes []string, synced time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.volumeTypes = map[string]bool{}
+	for _, volumeType := range volumeTypes {
+		c.volumeTypes[volumeType] = true
+	}
+	c.volumeTypesSynced = synced
+}
@@ -0,0 +1,182 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloud
+
+import (
+	"testing"
+	"time"
+)
+
+func expectDiskID(t *testing.T, c *resourceCache, name, expVolumeID string) {
+	t.Helper()
+	volumeID, found := c.diskIDByName(name)
+	if expVolumeID == "" {
+		if found {
+			t.Fatalf("Expected no volume named %q, got %q", name, volumeID)
+		}
+		return
+	}
+	if !found || volumeID != expVolumeID {
+		t.Fatalf("Expected volume %q named %q, got %q (found %t)", expVolumeID, name, volumeID, found)
+	}
+}
+
+func TestSetDisks(t *testing.T) {
+	c := newResourceCache()
+	c.putDisk(&Disk{VolumeID: "vol-1", Name: "pvc-1"})
+	synced := time.Now()
+	// written while the listing runs, the listing may not return it
+	c.putDisk(&Disk{VolumeID: "vol-2", Name: "pvc-2"})
+
+	c.setDisks([]*Disk{{VolumeID: "vol-3", Name: "pvc-3"}}, synced)
+
+	// vol-1 was written before the listing started and is not in it anymore
+	expectDiskID(t, c, "pvc-1", "")
+	expectDiskID(t, c, "pvc-2", "vol-2")
+	expectDiskID(t, c, "pvc-3", "vol-3")
+	if c.disksStale(time.Minute) {
+		t.Fatalf("Expected the volumes to be fresh after a listing")
+	}
+}
+
+func TestSetDisksKeepsNewerWrite(t *testing.T) {
+	c := newResourceCache()
+	synced := time.Now()
+	// renamed while the listing runs, the listing returns the old name
+	c.putDisk(&Disk{VolumeID: "vol-1", Name: "pvc-new"})
+
+	c.setDisks([]*Disk{{VolumeID: "vol-1", Name: "pvc-old"}}, synced)
+
+	expectDiskID(t, c, "pvc-new", "vol-1")
+	expectDiskID(t, c, "pvc-old", "")
+}
+
+func TestDisksStale(t *testing.T) {
+	c := newResourceCache()
+	if !c.disksStale(cacheResyncPeriod) {
+		t.Fatalf("Expected the volumes to be stale before the first listing")
+	}
+
+	c.setDisks(nil, time.Now().Add(-time.Minute))
+	if c.disksStale(cacheResyncPeriod) {
+		t.Fatalf("Expected the volumes listed a minute ago to be fresh for %v", cacheResyncPeriod)
+	}
+	if !c.disksStale(cacheMissResyncPeriod) {
+		t.Fatalf("Expected the volumes listed a minute ago to be stale for %v", cacheMissResyncPeriod)
+	}
+
+	c.setDisks(nil, time.Now())
+	c.invalidateDisks()
+	if !c.disksStale(cacheMissResyncPeriod) {
+		t.Fatalf("Expected the volumes to be stale once invalidated")
+	}
+}
+
+func TestPutDiskRename(t *testing.T) {
+	c := newResourceCache()
+	c.putDisk(&Disk{VolumeID: "vol-1", Name: "pvc-old"})
+	c.putDisk(&Disk{VolumeID: "vol-1", Name: "pvc-new"})
+
+	expectDiskID(t, c, "pvc-old", "")
+	expectDiskID(t, c, "pvc-new", "vol-1")
+}
+
+func TestDeleteDisk(t *testing.T) {
+	c := newResourceCache()
+	c.putDisk(&Disk{VolumeID: "vol-1", Name: "pvc-1"})
+	// the name moved to another volume, deleting the first one keeps it
+	c.putDisk(&Disk{VolumeID: "vol-2", Name: "pvc-1"})
+	c.deleteDisk("vol-1")
+	expectDiskID(t, c, "pvc-1", "vol-2")
+
+	c.deleteDisk("vol-2")
+	expectDiskID(t, c, "pvc-1", "")
+	if len(c.disks) != 0 {
+		t.Fatalf("Expected no cached volume, got %v", c.disks)
+	}
+}
+
+func TestDiskIDByWWN(t *testing.T) {
+	c := newResourceCache()
+	c.putDisk(&Disk{VolumeID: "vol-1", Name: "pvc-1", WWN: "600507681081818B"})
+
+	// the WWN is matched regardless of its case
+	for _, wwn := range []string{"600507681081818b", "600507681081818B"} {
+		if volumeID, found := c.diskIDByWWN(wwn); !found || volumeID != "vol-1" {
+			t.Fatalf("Expected volume vol-1 with WWN %q, got %q (found %t)", wwn, volumeID, found)
+		}
+	}
+
+	c.setDisks([]*Disk{{VolumeID: "vol-2", Name: "pvc-2", WWN: "600507681081818C"}}, time.Now())
+	if _, found := c.diskIDByWWN("600507681081818b"); found {
+		t.Fatalf("Expected the WWN of the volume gone from the listing to be dropped")
+	}
+	c.deleteDisk("vol-2")
+	if _, found := c.diskIDByWWN("600507681081818c"); found {
+		t.Fatalf("Expected the WWN of the deleted volume to be dropped")
+	}
+}
+
+func TestInstanceCache(t *testing.T) {
+	c := newResourceCache()
+	c.putInstance(&PVMInstance{ID: "instance-1", Name: "node-old"})
+	c.putInstance(&PVMInstance{ID: "instance-1", Name: "node-new"})
+
+	if _, found := c.instanceByName("node-old"); found {
+		t.Fatalf("Expected the old name of a renamed instance to be dropped")
+	}
+	if in, found := c.instanceByName("node-new"); !found || in.ID != "instance-1" {
+		t.Fatalf("Expected instance-1 named node-new, got %+v (found %t)", in, found)
+	}
+
+	// listed before the rename, the listing keeps the newer entry
+	c.setInstances([]*PVMInstance{{ID: "instance-1", Name: "node-old"}, {ID: "instance-2", Name: "node-2"}}, time.Now().Add(-time.Minute))
+	if in, found := c.instanceByName("node-new"); !found || in.ID != "instance-1" {
+		t.Fatalf("Expected instance-1 named node-new, got %+v (found %t)", in, found)
+	}
+	if _, found := c.instance("instance-2"); !found {
+		t.Fatalf("Expected the listed instance-2 to be cached")
+	}
+	if !c.instancesStale(cacheMissResyncPeriod) || c.instancesStale(cacheResyncPeriod) {
+		t.Fatalf("Expected the instances listed a minute ago to be stale for a miss only")
+	}
+
+	c.setInstances([]*PVMInstance{{ID: "instance-3", Name: "node-3"}}, time.Now().Add(-2*cacheResyncPeriod))
+	if _, found := c.instance("instance-3"); found {
+		t.Fatalf("Expected an instance cached more than %v ago to expire", cacheResyncPeriod)
+	}
+}
+
+func TestVolumeTypeOffered(t *testing.T) {
+	c := newResourceCache()
+	if _, fresh := c.volumeTypeOffered("tier1"); fresh {
+		t.Fatalf("Expected the storage types to be stale before the first listing")
+	}
+
+	c.setVolumeTypes([]string{"tier1"}, time.Now())
+	if offered, fresh := c.volumeTypeOffered("tier1"); !offered || !fresh {
+		t.Fatalf("Expected tier1 to be offered, got offered %t fresh %t", offered, fresh)
+	}
+	if offered, fresh := c.volumeTypeOffered("tier3"); offered || !fresh {
+		t.Fatalf("Expected tier3 not to be offered, got offered %t fresh %t", offered, fresh)
+	}
+
+	c.setVolumeTypes([]string{"tier1"}, time.Now().Add(-2*cacheResyncPeriod))
+	if _, fresh := c.volumeTypeOffered("tier1"); fresh {
+		t.Fatalf("Expected the storage types listed more than %v ago to be stale", cacheResyncPeriod)
+	}
+}
@@ -18,21 +18,63 @@ package cloud
 
 import (
 	"errors"
+	"time"
 
 	"sigs.k8s.io/ibm-powervs-block-csi-driver/pkg/util"
 )
 
+// Tags attached to the volumes provisioned by the driver
+const (
+	// ClusterIDTagKey is the key of the tag holding the ID of the Kubernetes cluster owning the volume
+	ClusterIDTagKey = "kubernetes-cluster-id"
+	// PVCNameTagKey is the key of the tag holding the name of the PVC the volume was created for
+	PVCNameTagKey = "kubernetes-pvc-name"
+	// PVCNamespaceTagKey is the key of the tag holding the namespace of the PVC the volume was created for
+	PVCNamespaceTagKey = "kubernetes-pvc-namespace"
+	// PVNameTagKey is the key of the tag holding the name of the PV the volume backs
+	PVNameTagKey = "kubernetes-pv-name"
+)
+
+// PowerVS affinity policies
+const (
+	AffinityPolicyAffinity     = "affinity"
+	AffinityPolicyAntiAffinity = "anti-affinity"
+)
+
 // PowerVS volume types
 const (
+	VolumeTypeTier0 = "tier0"
 	VolumeTypeTier1 = "tier1"
 	VolumeTypeTier3 = "tier3"
+	// VolumeTypeTier5k is the fixed IOPS tier, it delivers 5000 IOPS whatever the volume size
+	VolumeTypeTier5k = "tier5k"
 )
 
 var (
+	// ValidVolumeTypes lists the PowerVS tiers, a workspace only offers some of them
 	ValidVolumeTypes = []string{
+		VolumeTypeTier0,
 		VolumeTypeTier1,
 		VolumeTypeTier3,
+		VolumeTypeTier5k,
 	}
+
+	// VolumeTypeSizeLimits holds the volume sizes accepted by the tiers that restrict them
+	VolumeTypeSizeLimits = map[string]SizeLimits{
+		VolumeTypeTier5k: {MinGiB: 10, MaxGiB: 200},
+	}
+)
+
+// SizeLimits represents the range of volume sizes a volume type accepts, a zero value means no limit
+type SizeLimits struct {
+	MinGiB int64
+	MaxGiB int64
+}
+
+// PowerVS limits
+const (
+	// MaxVolumesPerInstance is the number of volumes PowerVS attaches to a PVM instance at most, boot volumes included
+	MaxVolumesPerInstance int64 = 127
 )
 
 // Defaults
@@ -47,8 +89,23 @@ var (
 	// ErrNotFound is returned when a resource is not found.
 	ErrNotFound = errors.New("resource was not found")
 
-	// ErrAlreadyExists is returned when a resource is already existent.
-	ErrAlreadyExists = errors.New("resource already exists")
+	// ErrConflict is returned when the resource is in use or another operation conflicts with the call.
+	ErrConflict = errors.New("resource is in use or in conflict with another operation")
+
+	// ErrQuotaExceeded is returned when the call would go over a quota of the account or the workspace.
+	ErrQuotaExceeded = errors.New("quota exceeded")
+
+	// ErrThrottled is returned when the PowerVS API rejects the call for its rate or is unavailable.
+	ErrThrottled = errors.New("request throttled by the PowerVS API")
+
+	// ErrInvalidState is returned when the resource is not in a state that allows the call.
+	ErrInvalidState = errors.New("resource is in an invalid state")
+
+	// ErrUnauthorized is returned when the credentials are missing or lack the permissions for the call.
+	ErrUnauthorized = errors.New("unauthorized")
+
+	// ErrUnsupportedVolumeType is returned when the workspace does not offer the requested volume type.
+	ErrUnsupportedVolumeType = errors.New("volume type is not offered by the workspace")
 )
 
 // Disk represents a PowerVS volume
@@ -59,6 +116,14 @@ type Disk struct {
 	Name        string
 	Shareable   bool
 	CapacityGiB int64
+	// PVMInstanceIDs lists the PVM instances the volume is attached to
+	PVMInstanceIDs []string
+	State          string
+	LastUpdateTime time.Time
+	// OutOfBandDeleted is set when the volume no longer exists on the storage controller
+	OutOfBandDeleted bool
+	// BootVolume is set for the boot volumes of the PVM instances
+	BootVolume bool
 }
 
 // DiskOptions represents parameters to create an PowerVS volume
@@ -68,4 +133,41 @@ type DiskOptions struct {
 	//CapacityGigaBytes float64
 	CapacityBytes int64
 	VolumeType    string
+	// VolumePool places the volume in the given storage pool, VolumeType and AffinityPolicy are then ignored
+	VolumePool string
+	// AffinityPolicy is either "affinity" or "anti-affinity", VolumeType is then ignored
+	AffinityPolicy           string
+	AffinityVolume           string
+	AffinityPVMInstance      string
+	AntiAffinityVolumes      []string
+	AntiAffinityPVMInstances []string
+	// Tags are attached to the volume as "key:value" user tags
+	Tags map[string]string
+}
+
+// ModifyDiskOptions represents the attributes to change on an existing PowerVS volume,
+// unset fields are left untouched
+type ModifyDiskOptions struct {
+	VolumeType string
+	Shareable  *bool
+}
+
+// Snapshot represents a PowerVS volume snapshot
+type Snapshot struct {
+	SnapshotID     string
+	SourceVolumeID string
+	// SnapshotVolumeID is the ID of the flash-copy volume holding the snapshot data
+	SnapshotVolumeID string
+	Name             string
+	State            string
+	CreationTime     time.Time
+	ReadyToUse       bool
+}
+
+// StorageCapacity represents the storage capacity left in a PowerVS workspace
+type StorageCapacity struct {
+	// AvailableCapacityGiB is the unused space of the matching storage pools
+	AvailableCapacityGiB int64
+	// MaximumVolumeSizeGiB is the largest single allocation offered by one of the matching storage pools
+	MaximumVolumeSizeGiB int64
 }
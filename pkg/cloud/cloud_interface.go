@@ -17,22 +17,43 @@ limitations under the License.
 package cloud
 
 import (
+	"context"
+
 	"github.com/IBM-Cloud/power-go-client/power/models"
 )
 
 type Cloud interface {
-	CreateDisk(volumeName string, diskOptions *DiskOptions) (disk *Disk, err error)
-	DeleteDisk(volumeID string) (success bool, err error)
-	AttachDisk(volumeID string, nodeID string) (err error)
-	DetachDisk(volumeID string, nodeID string) (err error)
-	ResizeDisk(volumeID string, reqSize int64) (newSize int64, err error)
-	WaitForVolumeState(volumeID, state string) error
-	GetDiskByName(name string) (disk *Disk, err error)
-	GetDiskByID(volumeID string) (disk *Disk, err error)
-	GetPVMInstanceByName(instanceName string) (instance *PVMInstance, err error)
-	GetPVMInstanceByID(instanceID string) (instance *PVMInstance, err error)
-	GetPVMInstanceDetails(instanceID string) (*models.PVMInstance, error)
-	UpdateStoragePoolAffinity(instanceID string) error
-	GetImageByID(imageID string) (image *PVMImage, err error)
-	IsAttached(volumeID string, nodeID string) (attached bool, err error)
+	CreateDisk(ctx context.Context, volumeName string, diskOptions *DiskOptions) (disk *Disk, err error)
+	CreateDiskFromSnapshot(ctx context.Context, volumeName string, snapshotID string, diskOptions *DiskOptions) (disk *Disk, err error)
+	CloneDisk(ctx context.Context, sourceVolumeID string, cloneName string, diskOptions *DiskOptions) (disk *Disk, err error)
+	DeleteDisk(ctx context.Context, volumeID string) (success bool, err error)
+	AttachDisk(ctx context.Context, volumeID string, nodeID string) (err error)
+	DetachDisk(ctx context.Context, volumeID string, nodeID string) (err error)
+	ResizeDisk(ctx context.Context, volumeID string, reqSize int64) (newSize int64, err error)
+	ModifyDisk(ctx context.Context, volumeID string, modifyOptions *ModifyDiskOptions) (disk *Disk, err error)
+	WaitForVolumeState(ctx context.Context, volumeID, state string) error
+	TagDisk(ctx context.Context, volumeID string, tags map[string]string) (err error)
+	UntagDisk(ctx context.Context, volumeID string, tags map[string]string) (err error)
+	GetDiskTags(ctx context.Context, volumeID string) (tags map[string]string, err error)
+	GetDiskByName(ctx context.Context, name string) (disk *Disk, err error)
+	GetDiskByID(ctx context.Context, volumeID string) (disk *Disk, err error)
+	GetDiskByWWN(ctx context.Context, wwn string) (disk *Disk, err error)
+	ListDisks(ctx context.Context) (disks []*Disk, err error)
+	ListDisksByTag(ctx context.Context, tag string) (disks []*Disk, err error)
+	ListPVMInstanceDisks(ctx context.Context, instanceID string) (disks []*Disk, err error)
+	GetStorageCapacity(ctx context.Context, volumeType string) (capacity *StorageCapacity, err error)
+	GetPVMInstanceByName(ctx context.Context, instanceName string) (instance *PVMInstance, err error)
+	GetPVMInstanceByID(ctx context.Context, instanceID string) (instance *PVMInstance, err error)
+	GetPVMInstanceDetails(ctx context.Context, instanceID string) (*models.PVMInstance, error)
+	UpdateStoragePoolAffinity(ctx context.Context, instanceID string, affinity bool) error
+	GetImageByID(ctx context.Context, imageID string) (image *PVMImage, err error)
+	IsAttached(ctx context.Context, volumeID string, nodeID string) (attached bool, err error)
+	CreateSnapshot(ctx context.Context, volumeID string, snapshotName string) (snapshot *Snapshot, err error)
+	DeleteSnapshot(ctx context.Context, snapshotID string) (err error)
+	WaitForSnapshotState(ctx context.Context, snapshotID, state string) error
+	GetSnapshotByName(ctx context.Context, name string) (snapshot *Snapshot, err error)
+	GetSnapshotByID(ctx context.Context, snapshotID string) (snapshot *Snapshot, err error)
+	ListSnapshots(ctx context.Context) (snapshots []*Snapshot, err error)
+	// Close stops the background work of the client, it is called once the client is no longer used
+	Close()
 }
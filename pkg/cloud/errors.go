@@ -0,0 +1,123 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloud
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/IBM-Cloud/power-go-client/power/models"
+)
+
+// statusCodes are the HTTP statuses of the PowerVS API that map to a sentinel error
+var statusCodes = []int{
+	http.StatusBadRequest,
+	http.StatusUnauthorized,
+	http.StatusForbidden,
+	http.StatusNotFound,
+	http.StatusConflict,
+	http.StatusPreconditionFailed,
+	http.StatusTooManyRequests,
+	http.StatusServiceUnavailable,
+}
+
+// cloudError is a PowerVS API error classified as one of the sentinel errors, errors.Is matches it with
+// the sentinel while the original error stays available to errors.As.
+type cloudError struct {
+	kind       error
+	statusCode int
+	err        error
+}
+
+func (e *cloudError) Error() string {
+	return fmt.Sprintf("%v: %v", e.kind, e.err)
+}
+
+func (e *cloudError) Is(target error) bool {
+	return target == e.kind
+}
+
+func (e *cloudError) Unwrap() error {
+	return e.err
+}
+
+// toCloudError classifies an error returned by the PowerVS clients from its HTTP status and the message
+// of its payload. Errors that match none of the sentinel errors are returned unchanged.
+func toCloudError(err error) error {
+	if err == nil {
+		return nil
+	}
+	var ce *cloudError
+	if errors.As(err, &ce) {
+		return err
+	}
+
+	statusCode := httpStatusCode(err)
+	message := strings.ToLower(payloadMessage(err))
+	var kind error
+	switch {
+	case strings.Contains(message, "quota"):
+		kind = ErrQuotaExceeded
+	case statusCode == http.StatusNotFound, strings.Contains(strings.ToLower(err.Error()), "resource not found"):
+		kind = ErrNotFound
+	case statusCode == http.StatusUnauthorized, statusCode == http.StatusForbidden:
+		kind = ErrUnauthorized
+	case statusCode == http.StatusTooManyRequests, statusCode == http.StatusServiceUnavailable:
+		kind = ErrThrottled
+	case statusCode == http.StatusConflict, statusCode == http.StatusPreconditionFailed:
+		// the validation errors of a 400 mention the state and status fields too, only these carry a state
+		if strings.Contains(message, "state") || strings.Contains(message, "status") {
+			kind = ErrInvalidState
+		} else {
+			kind = ErrConflict
+		}
+	case statusCode == http.StatusBadRequest:
+		if strings.Contains(message, "in use") || strings.Contains(message, "attached") {
+			kind = ErrConflict
+		}
+	}
+	if kind == nil {
+		return err
+	}
+	return &cloudError{kind: kind, statusCode: statusCode, err: err}
+}
+
+// httpStatusCode returns the status of the response carried by err, 0 when there is none.
+func httpStatusCode(err error) int {
+	var response interface{ IsCode(int) bool }
+	if !errors.As(err, &response) {
+		return 0
+	}
+	for _, code := range statusCodes {
+		if response.IsCode(code) {
+			return code
+		}
+	}
+	return 0
+}
+
+// payloadMessage returns the description and message of the PowerVS error payload carried by err.
+func payloadMessage(err error) string {
+	var response interface{ GetPayload() *models.Error }
+	if !errors.As(err, &response) || response.GetPayload() == nil {
+		return ""
+	}
+	payload := response.GetPayload()
+	return strings.Join([]string{payload.Error, payload.Description, payload.Message}, " ")
+}
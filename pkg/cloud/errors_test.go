@@ -0,0 +1,116 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloud
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/IBM-Cloud/power-go-client/ibmpisession"
+	"github.com/IBM-Cloud/power-go-client/power/client/p_cloud_volumes"
+	"github.com/IBM-Cloud/power-go-client/power/models"
+	"github.com/go-openapi/runtime"
+)
+
+// wrapClientError wraps err the way the clients of power-go-client do.
+func wrapClientError(err error) error {
+	return ibmpisession.SDKFailWithAPIError(err, fmt.Errorf("failed to Get volume vol-1: %w", err))
+}
+
+func TestToCloudError(t *testing.T) {
+	notFound := p_cloud_volumes.NewPcloudCloudinstancesVolumesGetNotFound()
+	notFound.Payload = &models.Error{Description: "volume does not exist"}
+
+	conflictInUse := p_cloud_volumes.NewPcloudCloudinstancesVolumesPutConflict()
+	conflictInUse.Payload = &models.Error{Description: "volume vol-1 is being resized"}
+
+	conflictState := p_cloud_volumes.NewPcloudCloudinstancesVolumesActionPostConflict()
+	conflictState.Payload = &models.Error{Description: "volume vol-1 is in error state"}
+
+	badRequestInUse := p_cloud_volumes.NewPcloudCloudinstancesVolumesDeleteBadRequest()
+	badRequestInUse.Payload = &models.Error{Description: "volume vol-1 is attached to a PVM instance"}
+
+	badRequestValidation := p_cloud_volumes.NewPcloudCloudinstancesVolumesPutBadRequest()
+	badRequestValidation.Payload = &models.Error{Message: "validation failure list: status in body should be one of [available]"}
+
+	badRequestQuota := p_cloud_volumes.NewPcloudCloudinstancesVolumesPostBadRequest()
+	badRequestQuota.Payload = &models.Error{Description: "Storage quota exceeded for the cloud instance"}
+
+	unauthorized := p_cloud_volumes.NewPcloudCloudinstancesVolumesGetUnauthorized()
+
+	testCases := []struct {
+		name    string
+		err     error
+		expKind error
+		expCode int
+	}{
+		{name: "not found", err: wrapClientError(notFound), expKind: ErrNotFound, expCode: http.StatusNotFound},
+		{name: "conflict", err: wrapClientError(conflictInUse), expKind: ErrConflict, expCode: http.StatusConflict},
+		{name: "conflict on the state", err: wrapClientError(conflictState), expKind: ErrInvalidState, expCode: http.StatusConflict},
+		{name: "bad request on an attached volume", err: wrapClientError(badRequestInUse), expKind: ErrConflict, expCode: http.StatusBadRequest},
+		{name: "bad request validating the status", err: wrapClientError(badRequestValidation)},
+		{name: "bad request over quota", err: wrapClientError(badRequestQuota), expKind: ErrQuotaExceeded, expCode: http.StatusBadRequest},
+		{name: "unauthorized", err: wrapClientError(unauthorized), expKind: ErrUnauthorized, expCode: http.StatusUnauthorized},
+		{
+			// the responses undefined by the API, like the ones the retry transport gives up on, are API errors
+			name:    "too many requests",
+			err:     wrapClientError(runtime.NewAPIError("pcloudCloudinstancesVolumesGet", nil, http.StatusTooManyRequests)),
+			expKind: ErrThrottled,
+			expCode: http.StatusTooManyRequests,
+		},
+		{
+			name:    "service unavailable",
+			err:     wrapClientError(runtime.NewAPIError("pcloudCloudinstancesVolumesGet", nil, http.StatusServiceUnavailable)),
+			expKind: ErrThrottled,
+			expCode: http.StatusServiceUnavailable,
+		},
+		{name: "internal server error", err: wrapClientError(runtime.NewAPIError("pcloudCloudinstancesVolumesGet", nil, http.StatusInternalServerError))},
+		{name: "resource not found message", err: errors.New("failed to Get volume vol-1: Resource not found"), expKind: ErrNotFound},
+		{name: "other error", err: errors.New("connection refused")},
+	}
+
+	sentinels := []error{ErrNotFound, ErrConflict, ErrInvalidState, ErrQuotaExceeded, ErrUnauthorized, ErrThrottled}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := toCloudError(tc.err)
+			if !errors.Is(err, tc.err) {
+				t.Fatalf("Expected the original error to be wrapped, got %v", err)
+			}
+			for _, sentinel := range sentinels {
+				if is := errors.Is(err, sentinel); is != (sentinel == tc.expKind) {
+					t.Fatalf("Expected errors.Is(%v) to be %t, got %t for %v", sentinel, sentinel == tc.expKind, is, err)
+				}
+			}
+
+			var ce *cloudError
+			if tc.expKind == nil {
+				if errors.As(err, &ce) {
+					t.Fatalf("Expected the error to be returned unchanged, got %v", err)
+				}
+				return
+			}
+			if !errors.As(err, &ce) || ce.statusCode != tc.expCode {
+				t.Fatalf("Expected status %d, got %+v", tc.expCode, ce)
+			}
+			if again := toCloudError(err); again != err {
+				t.Fatalf("Expected a classified error to be returned as is, got %v", again)
+			}
+		})
+	}
+}
@@ -5,6 +5,7 @@
 package mocks
 
 import (
+	context "context"
 	reflect "reflect"
 
 	models "github.com/IBM-Cloud/power-go-client/power/models"
@@ -36,207 +37,470 @@ func (m *MockCloud) EXPECT() *MockCloudMockRecorder {
 }
 
 // AttachDisk mocks base method.
-func (m *MockCloud) AttachDisk(volumeID, nodeID string) error {
+func (m *MockCloud) AttachDisk(ctx context.Context, volumeID, nodeID string) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "AttachDisk", volumeID, nodeID)
+	ret := m.ctrl.Call(m, "AttachDisk", ctx, volumeID, nodeID)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // AttachDisk indicates an expected call of AttachDisk.
-func (mr *MockCloudMockRecorder) AttachDisk(volumeID, nodeID interface{}) *gomock.Call {
+func (mr *MockCloudMockRecorder) AttachDisk(ctx, volumeID, nodeID interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AttachDisk", reflect.TypeOf((*MockCloud)(nil).AttachDisk), volumeID, nodeID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AttachDisk", reflect.TypeOf((*MockCloud)(nil).AttachDisk), ctx, volumeID, nodeID)
+}
+
+// CloneDisk mocks base method.
+func (m *MockCloud) CloneDisk(ctx context.Context, sourceVolumeID, cloneName string, diskOptions *cloud.DiskOptions) (*cloud.Disk, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CloneDisk", ctx, sourceVolumeID, cloneName, diskOptions)
+	ret0, _ := ret[0].(*cloud.Disk)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CloneDisk indicates an expected call of CloneDisk.
+func (mr *MockCloudMockRecorder) CloneDisk(ctx, sourceVolumeID, cloneName, diskOptions interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CloneDisk", reflect.TypeOf((*MockCloud)(nil).CloneDisk), ctx, sourceVolumeID, cloneName, diskOptions)
+}
+
+// Close mocks base method.
+func (m *MockCloud) Close() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Close")
+}
+
+// Close indicates an expected call of Close.
+func (mr *MockCloudMockRecorder) Close() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockCloud)(nil).Close))
 }
 
 // CreateDisk mocks base method.
-func (m *MockCloud) CreateDisk(volumeName string, diskOptions *cloud.DiskOptions) (*cloud.Disk, error) {
+func (m *MockCloud) CreateDisk(ctx context.Context, volumeName string, diskOptions *cloud.DiskOptions) (*cloud.Disk, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateDisk", volumeName, diskOptions)
+	ret := m.ctrl.Call(m, "CreateDisk", ctx, volumeName, diskOptions)
 	ret0, _ := ret[0].(*cloud.Disk)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // CreateDisk indicates an expected call of CreateDisk.
-func (mr *MockCloudMockRecorder) CreateDisk(volumeName, diskOptions interface{}) *gomock.Call {
+func (mr *MockCloudMockRecorder) CreateDisk(ctx, volumeName, diskOptions interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateDisk", reflect.TypeOf((*MockCloud)(nil).CreateDisk), volumeName, diskOptions)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateDisk", reflect.TypeOf((*MockCloud)(nil).CreateDisk), ctx, volumeName, diskOptions)
+}
+
+// CreateDiskFromSnapshot mocks base method.
+func (m *MockCloud) CreateDiskFromSnapshot(ctx context.Context, volumeName, snapshotID string, diskOptions *cloud.DiskOptions) (*cloud.Disk, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateDiskFromSnapshot", ctx, volumeName, snapshotID, diskOptions)
+	ret0, _ := ret[0].(*cloud.Disk)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateDiskFromSnapshot indicates an expected call of CreateDiskFromSnapshot.
+func (mr *MockCloudMockRecorder) CreateDiskFromSnapshot(ctx, volumeName, snapshotID, diskOptions interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateDiskFromSnapshot", reflect.TypeOf((*MockCloud)(nil).CreateDiskFromSnapshot), ctx, volumeName, snapshotID, diskOptions)
+}
+
+// CreateSnapshot mocks base method.
+func (m *MockCloud) CreateSnapshot(ctx context.Context, volumeID, snapshotName string) (*cloud.Snapshot, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateSnapshot", ctx, volumeID, snapshotName)
+	ret0, _ := ret[0].(*cloud.Snapshot)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateSnapshot indicates an expected call of CreateSnapshot.
+func (mr *MockCloudMockRecorder) CreateSnapshot(ctx, volumeID, snapshotName interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSnapshot", reflect.TypeOf((*MockCloud)(nil).CreateSnapshot), ctx, volumeID, snapshotName)
 }
 
 // DeleteDisk mocks base method.
-func (m *MockCloud) DeleteDisk(volumeID string) (bool, error) {
+func (m *MockCloud) DeleteDisk(ctx context.Context, volumeID string) (bool, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteDisk", volumeID)
+	ret := m.ctrl.Call(m, "DeleteDisk", ctx, volumeID)
 	ret0, _ := ret[0].(bool)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // DeleteDisk indicates an expected call of DeleteDisk.
-func (mr *MockCloudMockRecorder) DeleteDisk(volumeID interface{}) *gomock.Call {
+func (mr *MockCloudMockRecorder) DeleteDisk(ctx, volumeID interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteDisk", reflect.TypeOf((*MockCloud)(nil).DeleteDisk), volumeID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteDisk", reflect.TypeOf((*MockCloud)(nil).DeleteDisk), ctx, volumeID)
+}
+
+// DeleteSnapshot mocks base method.
+func (m *MockCloud) DeleteSnapshot(ctx context.Context, snapshotID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteSnapshot", ctx, snapshotID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteSnapshot indicates an expected call of DeleteSnapshot.
+func (mr *MockCloudMockRecorder) DeleteSnapshot(ctx, snapshotID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteSnapshot", reflect.TypeOf((*MockCloud)(nil).DeleteSnapshot), ctx, snapshotID)
 }
 
 // DetachDisk mocks base method.
-func (m *MockCloud) DetachDisk(volumeID, nodeID string) error {
+func (m *MockCloud) DetachDisk(ctx context.Context, volumeID, nodeID string) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DetachDisk", volumeID, nodeID)
+	ret := m.ctrl.Call(m, "DetachDisk", ctx, volumeID, nodeID)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // DetachDisk indicates an expected call of DetachDisk.
-func (mr *MockCloudMockRecorder) DetachDisk(volumeID, nodeID interface{}) *gomock.Call {
+func (mr *MockCloudMockRecorder) DetachDisk(ctx, volumeID, nodeID interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DetachDisk", reflect.TypeOf((*MockCloud)(nil).DetachDisk), volumeID, nodeID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DetachDisk", reflect.TypeOf((*MockCloud)(nil).DetachDisk), ctx, volumeID, nodeID)
 }
 
 // GetDiskByID mocks base method.
-func (m *MockCloud) GetDiskByID(volumeID string) (*cloud.Disk, error) {
+func (m *MockCloud) GetDiskByID(ctx context.Context, volumeID string) (*cloud.Disk, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetDiskByID", volumeID)
+	ret := m.ctrl.Call(m, "GetDiskByID", ctx, volumeID)
 	ret0, _ := ret[0].(*cloud.Disk)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetDiskByID indicates an expected call of GetDiskByID.
-func (mr *MockCloudMockRecorder) GetDiskByID(volumeID interface{}) *gomock.Call {
+func (mr *MockCloudMockRecorder) GetDiskByID(ctx, volumeID interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDiskByID", reflect.TypeOf((*MockCloud)(nil).GetDiskByID), volumeID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDiskByID", reflect.TypeOf((*MockCloud)(nil).GetDiskByID), ctx, volumeID)
 }
 
 // GetDiskByName mocks base method.
-func (m *MockCloud) GetDiskByName(name string) (*cloud.Disk, error) {
+func (m *MockCloud) GetDiskByName(ctx context.Context, name string) (*cloud.Disk, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetDiskByName", name)
+	ret := m.ctrl.Call(m, "GetDiskByName", ctx, name)
 	ret0, _ := ret[0].(*cloud.Disk)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetDiskByName indicates an expected call of GetDiskByName.
-func (mr *MockCloudMockRecorder) GetDiskByName(name interface{}) *gomock.Call {
+func (mr *MockCloudMockRecorder) GetDiskByName(ctx, name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDiskByName", reflect.TypeOf((*MockCloud)(nil).GetDiskByName), ctx, name)
+}
+
+// GetDiskByWWN mocks base method.
+func (m *MockCloud) GetDiskByWWN(ctx context.Context, wwn string) (*cloud.Disk, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDiskByWWN", ctx, wwn)
+	ret0, _ := ret[0].(*cloud.Disk)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDiskByWWN indicates an expected call of GetDiskByWWN.
+func (mr *MockCloudMockRecorder) GetDiskByWWN(ctx, wwn interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDiskByWWN", reflect.TypeOf((*MockCloud)(nil).GetDiskByWWN), ctx, wwn)
+}
+
+// GetDiskTags mocks base method.
+func (m *MockCloud) GetDiskTags(ctx context.Context, volumeID string) (map[string]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDiskTags", ctx, volumeID)
+	ret0, _ := ret[0].(map[string]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDiskTags indicates an expected call of GetDiskTags.
+func (mr *MockCloudMockRecorder) GetDiskTags(ctx, volumeID interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDiskByName", reflect.TypeOf((*MockCloud)(nil).GetDiskByName), name)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDiskTags", reflect.TypeOf((*MockCloud)(nil).GetDiskTags), ctx, volumeID)
 }
 
 // GetImageByID mocks base method.
-func (m *MockCloud) GetImageByID(imageID string) (*cloud.PVMImage, error) {
+func (m *MockCloud) GetImageByID(ctx context.Context, imageID string) (*cloud.PVMImage, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetImageByID", imageID)
+	ret := m.ctrl.Call(m, "GetImageByID", ctx, imageID)
 	ret0, _ := ret[0].(*cloud.PVMImage)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetImageByID indicates an expected call of GetImageByID.
-func (mr *MockCloudMockRecorder) GetImageByID(imageID interface{}) *gomock.Call {
+func (mr *MockCloudMockRecorder) GetImageByID(ctx, imageID interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetImageByID", reflect.TypeOf((*MockCloud)(nil).GetImageByID), imageID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetImageByID", reflect.TypeOf((*MockCloud)(nil).GetImageByID), ctx, imageID)
 }
 
 // GetPVMInstanceByID mocks base method.
-func (m *MockCloud) GetPVMInstanceByID(instanceID string) (*cloud.PVMInstance, error) {
+func (m *MockCloud) GetPVMInstanceByID(ctx context.Context, instanceID string) (*cloud.PVMInstance, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetPVMInstanceByID", instanceID)
+	ret := m.ctrl.Call(m, "GetPVMInstanceByID", ctx, instanceID)
 	ret0, _ := ret[0].(*cloud.PVMInstance)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetPVMInstanceByID indicates an expected call of GetPVMInstanceByID.
-func (mr *MockCloudMockRecorder) GetPVMInstanceByID(instanceID interface{}) *gomock.Call {
+func (mr *MockCloudMockRecorder) GetPVMInstanceByID(ctx, instanceID interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPVMInstanceByID", reflect.TypeOf((*MockCloud)(nil).GetPVMInstanceByID), instanceID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPVMInstanceByID", reflect.TypeOf((*MockCloud)(nil).GetPVMInstanceByID), ctx, instanceID)
 }
 
 // GetPVMInstanceByName mocks base method.
-func (m *MockCloud) GetPVMInstanceByName(instanceName string) (*cloud.PVMInstance, error) {
+func (m *MockCloud) GetPVMInstanceByName(ctx context.Context, instanceName string) (*cloud.PVMInstance, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetPVMInstanceByName", instanceName)
+	ret := m.ctrl.Call(m, "GetPVMInstanceByName", ctx, instanceName)
 	ret0, _ := ret[0].(*cloud.PVMInstance)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetPVMInstanceByName indicates an expected call of GetPVMInstanceByName.
-func (mr *MockCloudMockRecorder) GetPVMInstanceByName(instanceName interface{}) *gomock.Call {
+func (mr *MockCloudMockRecorder) GetPVMInstanceByName(ctx, instanceName interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPVMInstanceByName", reflect.TypeOf((*MockCloud)(nil).GetPVMInstanceByName), instanceName)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPVMInstanceByName", reflect.TypeOf((*MockCloud)(nil).GetPVMInstanceByName), ctx, instanceName)
 }
 
 // GetPVMInstanceDetails mocks base method.
-func (m *MockCloud) GetPVMInstanceDetails(instanceID string) (*models.PVMInstance, error) {
+func (m *MockCloud) GetPVMInstanceDetails(ctx context.Context, instanceID string) (*models.PVMInstance, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetPVMInstanceDetails", instanceID)
+	ret := m.ctrl.Call(m, "GetPVMInstanceDetails", ctx, instanceID)
 	ret0, _ := ret[0].(*models.PVMInstance)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetPVMInstanceDetails indicates an expected call of GetPVMInstanceDetails.
-func (mr *MockCloudMockRecorder) GetPVMInstanceDetails(instanceID interface{}) *gomock.Call {
+func (mr *MockCloudMockRecorder) GetPVMInstanceDetails(ctx, instanceID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPVMInstanceDetails", reflect.TypeOf((*MockCloud)(nil).GetPVMInstanceDetails), ctx, instanceID)
+}
+
+// GetSnapshotByID mocks base method.
+func (m *MockCloud) GetSnapshotByID(ctx context.Context, snapshotID string) (*cloud.Snapshot, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSnapshotByID", ctx, snapshotID)
+	ret0, _ := ret[0].(*cloud.Snapshot)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSnapshotByID indicates an expected call of GetSnapshotByID.
+func (mr *MockCloudMockRecorder) GetSnapshotByID(ctx, snapshotID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSnapshotByID", reflect.TypeOf((*MockCloud)(nil).GetSnapshotByID), ctx, snapshotID)
+}
+
+// GetSnapshotByName mocks base method.
+func (m *MockCloud) GetSnapshotByName(ctx context.Context, name string) (*cloud.Snapshot, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSnapshotByName", ctx, name)
+	ret0, _ := ret[0].(*cloud.Snapshot)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSnapshotByName indicates an expected call of GetSnapshotByName.
+func (mr *MockCloudMockRecorder) GetSnapshotByName(ctx, name interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPVMInstanceDetails", reflect.TypeOf((*MockCloud)(nil).GetPVMInstanceDetails), instanceID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSnapshotByName", reflect.TypeOf((*MockCloud)(nil).GetSnapshotByName), ctx, name)
+}
+
+// GetStorageCapacity mocks base method.
+func (m *MockCloud) GetStorageCapacity(ctx context.Context, volumeType string) (*cloud.StorageCapacity, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetStorageCapacity", ctx, volumeType)
+	ret0, _ := ret[0].(*cloud.StorageCapacity)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetStorageCapacity indicates an expected call of GetStorageCapacity.
+func (mr *MockCloudMockRecorder) GetStorageCapacity(ctx, volumeType interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetStorageCapacity", reflect.TypeOf((*MockCloud)(nil).GetStorageCapacity), ctx, volumeType)
 }
 
 // IsAttached mocks base method.
-func (m *MockCloud) IsAttached(volumeID, nodeID string) (bool, error) {
+func (m *MockCloud) IsAttached(ctx context.Context, volumeID, nodeID string) (bool, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "IsAttached", volumeID, nodeID)
+	ret := m.ctrl.Call(m, "IsAttached", ctx, volumeID, nodeID)
 	ret0, _ := ret[0].(bool)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // IsAttached indicates an expected call of IsAttached.
-func (mr *MockCloudMockRecorder) IsAttached(volumeID, nodeID interface{}) *gomock.Call {
+func (mr *MockCloudMockRecorder) IsAttached(ctx, volumeID, nodeID interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsAttached", reflect.TypeOf((*MockCloud)(nil).IsAttached), volumeID, nodeID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsAttached", reflect.TypeOf((*MockCloud)(nil).IsAttached), ctx, volumeID, nodeID)
+}
+
+// ListDisks mocks base method.
+func (m *MockCloud) ListDisks(ctx context.Context) ([]*cloud.Disk, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListDisks", ctx)
+	ret0, _ := ret[0].([]*cloud.Disk)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListDisks indicates an expected call of ListDisks.
+func (mr *MockCloudMockRecorder) ListDisks(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListDisks", reflect.TypeOf((*MockCloud)(nil).ListDisks), ctx)
+}
+
+// ListDisksByTag mocks base method.
+func (m *MockCloud) ListDisksByTag(ctx context.Context, tag string) ([]*cloud.Disk, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListDisksByTag", ctx, tag)
+	ret0, _ := ret[0].([]*cloud.Disk)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListDisksByTag indicates an expected call of ListDisksByTag.
+func (mr *MockCloudMockRecorder) ListDisksByTag(ctx, tag interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListDisksByTag", reflect.TypeOf((*MockCloud)(nil).ListDisksByTag), ctx, tag)
+}
+
+// ListPVMInstanceDisks mocks base method.
+func (m *MockCloud) ListPVMInstanceDisks(ctx context.Context, instanceID string) ([]*cloud.Disk, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListPVMInstanceDisks", ctx, instanceID)
+	ret0, _ := ret[0].([]*cloud.Disk)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListPVMInstanceDisks indicates an expected call of ListPVMInstanceDisks.
+func (mr *MockCloudMockRecorder) ListPVMInstanceDisks(ctx, instanceID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPVMInstanceDisks", reflect.TypeOf((*MockCloud)(nil).ListPVMInstanceDisks), ctx, instanceID)
+}
+
+// ListSnapshots mocks base method.
+func (m *MockCloud) ListSnapshots(ctx context.Context) ([]*cloud.Snapshot, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListSnapshots", ctx)
+	ret0, _ := ret[0].([]*cloud.Snapshot)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListSnapshots indicates an expected call of ListSnapshots.
+func (mr *MockCloudMockRecorder) ListSnapshots(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListSnapshots", reflect.TypeOf((*MockCloud)(nil).ListSnapshots), ctx)
+}
+
+// ModifyDisk mocks base method.
+func (m *MockCloud) ModifyDisk(ctx context.Context, volumeID string, modifyOptions *cloud.ModifyDiskOptions) (*cloud.Disk, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ModifyDisk", ctx, volumeID, modifyOptions)
+	ret0, _ := ret[0].(*cloud.Disk)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ModifyDisk indicates an expected call of ModifyDisk.
+func (mr *MockCloudMockRecorder) ModifyDisk(ctx, volumeID, modifyOptions interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ModifyDisk", reflect.TypeOf((*MockCloud)(nil).ModifyDisk), ctx, volumeID, modifyOptions)
 }
 
 // ResizeDisk mocks base method.
-func (m *MockCloud) ResizeDisk(volumeID string, reqSize int64) (int64, error) {
+func (m *MockCloud) ResizeDisk(ctx context.Context, volumeID string, reqSize int64) (int64, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ResizeDisk", volumeID, reqSize)
+	ret := m.ctrl.Call(m, "ResizeDisk", ctx, volumeID, reqSize)
 	ret0, _ := ret[0].(int64)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // ResizeDisk indicates an expected call of ResizeDisk.
-func (mr *MockCloudMockRecorder) ResizeDisk(volumeID, reqSize interface{}) *gomock.Call {
+func (mr *MockCloudMockRecorder) ResizeDisk(ctx, volumeID, reqSize interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResizeDisk", reflect.TypeOf((*MockCloud)(nil).ResizeDisk), ctx, volumeID, reqSize)
+}
+
+// TagDisk mocks base method.
+func (m *MockCloud) TagDisk(ctx context.Context, volumeID string, tags map[string]string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TagDisk", ctx, volumeID, tags)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// TagDisk indicates an expected call of TagDisk.
+func (mr *MockCloudMockRecorder) TagDisk(ctx, volumeID, tags interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TagDisk", reflect.TypeOf((*MockCloud)(nil).TagDisk), ctx, volumeID, tags)
+}
+
+// UntagDisk mocks base method.
+func (m *MockCloud) UntagDisk(ctx context.Context, volumeID string, tags map[string]string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UntagDisk", ctx, volumeID, tags)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UntagDisk indicates an expected call of UntagDisk.
+func (mr *MockCloudMockRecorder) UntagDisk(ctx, volumeID, tags interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResizeDisk", reflect.TypeOf((*MockCloud)(nil).ResizeDisk), volumeID, reqSize)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UntagDisk", reflect.TypeOf((*MockCloud)(nil).UntagDisk), ctx, volumeID, tags)
 }
 
 // UpdateStoragePoolAffinity mocks base method.
-func (m *MockCloud) UpdateStoragePoolAffinity(instanceID string) error {
+func (m *MockCloud) UpdateStoragePoolAffinity(ctx context.Context, instanceID string, affinity bool) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UpdateStoragePoolAffinity", instanceID)
+	ret := m.ctrl.Call(m, "UpdateStoragePoolAffinity", ctx, instanceID, affinity)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // UpdateStoragePoolAffinity indicates an expected call of UpdateStoragePoolAffinity.
-func (mr *MockCloudMockRecorder) UpdateStoragePoolAffinity(instanceID interface{}) *gomock.Call {
+func (mr *MockCloudMockRecorder) UpdateStoragePoolAffinity(ctx, instanceID, affinity interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateStoragePoolAffinity", reflect.TypeOf((*MockCloud)(nil).UpdateStoragePoolAffinity), ctx, instanceID, affinity)
+}
+
+// WaitForSnapshotState mocks base method.
+func (m *MockCloud) WaitForSnapshotState(ctx context.Context, snapshotID, state string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WaitForSnapshotState", ctx, snapshotID, state)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WaitForSnapshotState indicates an expected call of WaitForSnapshotState.
+func (mr *MockCloudMockRecorder) WaitForSnapshotState(ctx, snapshotID, state interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateStoragePoolAffinity", reflect.TypeOf((*MockCloud)(nil).UpdateStoragePoolAffinity), instanceID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WaitForSnapshotState", reflect.TypeOf((*MockCloud)(nil).WaitForSnapshotState), ctx, snapshotID, state)
 }
 
 // WaitForVolumeState mocks base method.
-func (m *MockCloud) WaitForVolumeState(volumeID, state string) error {
+func (m *MockCloud) WaitForVolumeState(ctx context.Context, volumeID, state string) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "WaitForVolumeState", volumeID, state)
+	ret := m.ctrl.Call(m, "WaitForVolumeState", ctx, volumeID, state)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // WaitForVolumeState indicates an expected call of WaitForVolumeState.
-func (mr *MockCloudMockRecorder) WaitForVolumeState(volumeID, state interface{}) *gomock.Call {
+func (mr *MockCloudMockRecorder) WaitForVolumeState(ctx, volumeID, state interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WaitForVolumeState", reflect.TypeOf((*MockCloud)(nil).WaitForVolumeState), volumeID, state)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WaitForVolumeState", reflect.TypeOf((*MockCloud)(nil).WaitForVolumeState), ctx, volumeID, state)
 }
@@ -18,20 +18,26 @@ package cloud
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/IBM-Cloud/power-go-client/clients/instance"
-	"github.com/IBM-Cloud/power-go-client/errors"
 	"github.com/IBM-Cloud/power-go-client/ibmpisession"
 	"github.com/IBM-Cloud/power-go-client/power/client/p_cloud_volumes"
 	"github.com/IBM-Cloud/power-go-client/power/models"
 	"github.com/IBM/go-sdk-core/v5/core"
+	"github.com/IBM/platform-services-go-sdk/globalsearchv2"
+	"github.com/IBM/platform-services-go-sdk/globaltaggingv1"
 	"github.com/IBM/platform-services-go-sdk/resourcecontrollerv2"
 	"github.com/davecgh/go-spew/spew"
+	httptransport "github.com/go-openapi/runtime/client"
+	"github.com/go-openapi/strfmt"
 	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
 	"k8s.io/utils/pointer"
 	"sigs.k8s.io/ibm-powervs-block-csi-driver/pkg/util"
 )
@@ -58,6 +64,21 @@ const (
 	TIMEOUT              = 60 * time.Minute
 	VolumeInUseState     = "in-use"
 	VolumeAvailableState = "available"
+	VolumeErrorState     = "error"
+	VolumeResizingState  = "resizing"
+
+	SnapshotAvailableState = "available"
+	SnapshotErrorState     = "error"
+
+	CloneTaskCompletedState = "completed"
+	CloneTaskFailedState    = "failed"
+	ClonePollTimeout        = 10 * time.Minute
+
+	// ModifyPollTimeout bounds the wait for a volume to move to another storage tier
+	ModifyPollTimeout = 10 * time.Minute
+
+	// searchLimit is the number of resources fetched per global search call
+	searchLimit = 1000
 )
 
 type PowerVSClient interface {
@@ -67,10 +88,17 @@ type powerVSCloud struct {
 	piSession *ibmpisession.IBMPISession
 
 	cloudInstanceID string
+	// cloudInstanceCRN is the CRN of the Power VS service instance, volume CRNs are derived from it
+	cloudInstanceCRN string
 
-	imageClient        *instance.IBMPIImageClient
-	pvmInstancesClient *instance.IBMPIInstanceClient
-	volClient          *instance.IBMPIVolumeClient
+	globalSearchClient  *globalsearchv2.GlobalSearchV2
+	globalTaggingClient *globaltaggingv1.GlobalTaggingV1
+
+	// cache indexes the volumes and instances of the workspace for the lookups by name and WWN
+	cache *resourceCache
+	// refreshCtx bounds the background refreshes of the cache, stopRefresh cancels it when the client is closed
+	refreshCtx  context.Context
+	stopRefresh context.CancelFunc
 }
 
 type PVMInstance struct {
@@ -85,13 +113,18 @@ type PVMImage struct {
 	DiskType string
 }
 
-func NewPowerVSCloud(cloudInstanceID, zone string, debug bool) (Cloud, error) {
-	return newPowerVSCloud(cloudInstanceID, zone, debug)
+func NewPowerVSCloud(cloudInstanceID, zone string, debug bool, retryPolicy RetryPolicy) (Cloud, error) {
+	return newPowerVSCloud(cloudInstanceID, zone, debug, retryPolicy)
 }
 
-func newPowerVSCloud(cloudInstanceID, zone string, debug bool) (Cloud, error) {
+func newPowerVSCloud(cloudInstanceID, zone string, debug bool, retryPolicy RetryPolicy) (Cloud, error) {
 	apikey := os.Getenv("IBMCLOUD_API_KEY")
 
+	// All the clients of the workspace share the rate limiter and retry policy.
+	limiter := newRateLimiter(retryPolicy)
+	httpClient := core.DefaultHTTPClient()
+	httpClient.Transport = newRetryTransport(httpClient.Transport, retryPolicy, limiter)
+
 	serviceClientOptions := &resourcecontrollerv2.ResourceControllerV2Options{
 		Authenticator: &core.IamAuthenticator{ApiKey: apikey},
 	}
@@ -99,6 +132,7 @@ func newPowerVSCloud(cloudInstanceID, zone string, debug bool) (Cloud, error) {
 	if err != nil {
 		return nil, fmt.Errorf("errored while creating NewResourceControllerV2UsingExternalConfig: %v", err)
 	}
+	serviceClient.Service.SetHTTPClient(httpClient)
 	resourceInstanceList, _, err := serviceClient.ListResourceInstances(&resourcecontrollerv2.ListResourceInstancesOptions{
 		GUID:           &cloudInstanceID,
 		ResourceID:     &powerVSServiceID,
@@ -111,6 +145,9 @@ func newPowerVSCloud(cloudInstanceID, zone string, debug bool) (Cloud, error) {
 	if len(resourceInstanceList.Resources) == 0 {
 		return nil, fmt.Errorf("no Power VS service instance found with ID: %s", cloudInstanceID)
 	}
+	if resourceInstanceList.Resources[0].AccountID == nil || resourceInstanceList.Resources[0].CRN == nil {
+		return nil, fmt.Errorf("the Power VS service instance with ID: %s has no account ID or CRN", cloudInstanceID)
+	}
 
 	authenticator := &core.IamAuthenticator{ApiKey: apikey}
 	piOptions := ibmpisession.IBMPIOptions{Authenticator: authenticator, Debug: debug, UserAccount: *resourceInstanceList.Resources[0].AccountID, Zone: zone}
@@ -118,55 +155,133 @@ func newPowerVSCloud(cloudInstanceID, zone string, debug bool) (Cloud, error) {
 	if err != nil {
 		return nil, err
 	}
+	if rt, ok := piSession.Power.Transport.(*httptransport.Runtime); ok {
+		rt.Transport = newRetryTransport(rt.Transport, retryPolicy, limiter)
+	}
 
-	backgroundContext := context.Background()
-	volClient := instance.NewIBMPIVolumeClient(backgroundContext, piSession, cloudInstanceID)
-	pvmInstancesClient := instance.NewIBMPIInstanceClient(backgroundContext, piSession, cloudInstanceID)
-	imageClient := instance.NewIBMPIImageClient(backgroundContext, piSession, cloudInstanceID)
+	globalSearchClient, err := globalsearchv2.NewGlobalSearchV2(&globalsearchv2.GlobalSearchV2Options{Authenticator: authenticator})
+	if err != nil {
+		return nil, fmt.Errorf("errored while creating NewGlobalSearchV2: %v", err)
+	}
+	globalSearchClient.Service.SetHTTPClient(httpClient)
+	globalTaggingClient, err := globaltaggingv1.NewGlobalTaggingV1(&globaltaggingv1.GlobalTaggingV1Options{Authenticator: authenticator})
+	if err != nil {
+		return nil, fmt.Errorf("errored while creating NewGlobalTaggingV1: %v", err)
+	}
+	globalTaggingClient.Service.SetHTTPClient(httpClient)
 
+	refreshCtx, stopRefresh := context.WithCancel(context.Background())
 	return &powerVSCloud{
-		piSession:          piSession,
-		cloudInstanceID:    cloudInstanceID,
-		imageClient:        imageClient,
-		pvmInstancesClient: pvmInstancesClient,
-		volClient:          volClient,
+		piSession:           piSession,
+		cloudInstanceID:     cloudInstanceID,
+		cloudInstanceCRN:    *resourceInstanceList.Resources[0].CRN,
+		globalSearchClient:  globalSearchClient,
+		globalTaggingClient: globalTaggingClient,
+		cache:               newResourceCache(),
+		refreshCtx:          refreshCtx,
+		stopRefresh:         stopRefresh,
 	}, nil
 }
 
-func (p *powerVSCloud) GetPVMInstanceByName(name string) (*PVMInstance, error) {
-	in, err := p.pvmInstancesClient.GetAll()
-	if err != nil {
+// Close stops the background refreshes of the cache.
+func (p *powerVSCloud) Close() {
+	if p.stopRefresh != nil {
+		p.stopRefresh()
+	}
+}
+
+// The PowerVS clients bind the context of their requests when they are created, so a client is created
+// for every call to stop its requests when the context of the call is cancelled.
+
+func (p *powerVSCloud) volClient(ctx context.Context) *instance.IBMPIVolumeClient {
+	return instance.NewIBMPIVolumeClient(ctx, p.piSession, p.cloudInstanceID)
+}
+
+func (p *powerVSCloud) cloneVolumeClient(ctx context.Context) *instance.IBMPICloneVolumeClient {
+	return instance.NewIBMPICloneVolumeClient(ctx, p.piSession, p.cloudInstanceID)
+}
+
+func (p *powerVSCloud) pvmInstancesClient(ctx context.Context) *instance.IBMPIInstanceClient {
+	return instance.NewIBMPIInstanceClient(ctx, p.piSession, p.cloudInstanceID)
+}
+
+func (p *powerVSCloud) imageClient(ctx context.Context) *instance.IBMPIImageClient {
+	return instance.NewIBMPIImageClient(ctx, p.piSession, p.cloudInstanceID)
+}
+
+func (p *powerVSCloud) snapshotClient(ctx context.Context) *instance.IBMPISnapshotClient {
+	return instance.NewIBMPISnapshotClient(ctx, p.piSession, p.cloudInstanceID)
+}
+
+func (p *powerVSCloud) storageCapacityClient(ctx context.Context) *instance.IBMPIStorageCapacityClient {
+	return instance.NewIBMPIStorageCapacityClient(ctx, p.piSession, p.cloudInstanceID)
+}
+
+// GetPVMInstanceByName returns the instance from the cache, the instances are listed again on a miss
+// when the cache is older than cacheMissResyncPeriod.
+func (p *powerVSCloud) GetPVMInstanceByName(ctx context.Context, name string) (*PVMInstance, error) {
+	p.cache.instancesRefresh.Do(func() { go p.refreshCache("instances", p.syncInstances) })
+	if in, found := p.cache.instanceByName(name); found {
+		return in, nil
+	}
+	if err := p.syncInstances(ctx, cacheMissResyncPeriod); err != nil {
 		return nil, err
 	}
-	for _, pvmInstance := range in.PvmInstances {
-		if name == *pvmInstance.ServerName {
-			return &PVMInstance{
-				ID:      *pvmInstance.PvmInstanceID,
-				ImageID: *pvmInstance.ImageID,
-				Name:    *pvmInstance.ServerName,
-			}, nil
-		}
+	if in, found := p.cache.instanceByName(name); found {
+		return in, nil
 	}
 	return nil, ErrNotFound
 }
 
-func (p *powerVSCloud) GetPVMInstanceByID(instanceID string) (*PVMInstance, error) {
-	in, err := p.pvmInstancesClient.Get(instanceID)
+// syncInstances lists the instances of the workspace into the cache, unless they were listed within maxAge.
+func (p *powerVSCloud) syncInstances(ctx context.Context, maxAge time.Duration) error {
+	p.cache.instancesSyncMu.Lock()
+	defer p.cache.instancesSyncMu.Unlock()
+	if !p.cache.instancesStale(maxAge) {
+		return nil
+	}
+
+	synced := time.Now()
+	in, err := p.pvmInstancesClient(ctx).GetAll()
 	if err != nil {
-		return nil, err
+		return toCloudError(err)
+	}
+	instances := make([]*PVMInstance, 0, len(in.PvmInstances))
+	for _, pvmInstance := range in.PvmInstances {
+		instances = append(instances, &PVMInstance{
+			ID:      *pvmInstance.PvmInstanceID,
+			ImageID: *pvmInstance.ImageID,
+			Name:    *pvmInstance.ServerName,
+		})
+	}
+	p.cache.setInstances(instances, synced)
+	return nil
+}
+
+// GetPVMInstanceByID returns the instance from the cache, or gets it from PowerVS on a miss.
+func (p *powerVSCloud) GetPVMInstanceByID(ctx context.Context, instanceID string) (*PVMInstance, error) {
+	if cached, found := p.cache.instance(instanceID); found {
+		return cached, nil
+	}
+
+	in, err := p.pvmInstancesClient(ctx).Get(instanceID)
+	if err != nil {
+		return nil, toCloudError(err)
 	}
 
-	return &PVMInstance{
+	pvmInstance := &PVMInstance{
 		ID:      *in.PvmInstanceID,
 		ImageID: *in.ImageID,
 		Name:    *in.ServerName,
-	}, nil
+	}
+	p.cache.putInstance(pvmInstance)
+	return pvmInstance, nil
 }
 
-func (p *powerVSCloud) GetImageByID(imageID string) (*PVMImage, error) {
-	image, err := p.imageClient.Get(imageID)
+func (p *powerVSCloud) GetImageByID(ctx context.Context, imageID string) (*PVMImage, error) {
+	image, err := p.imageClient(ctx).Get(imageID)
 	if err != nil {
-		return nil, err
+		return nil, toCloudError(err)
 	}
 	return &PVMImage{
 		ID:       *image.ImageID,
@@ -175,83 +290,249 @@ func (p *powerVSCloud) GetImageByID(imageID string) (*PVMImage, error) {
 	}, nil
 }
 
-func (p *powerVSCloud) CreateDisk(volumeName string, diskOptions *DiskOptions) (disk *Disk, err error) {
-	var volumeType string
+func (p *powerVSCloud) CreateDisk(ctx context.Context, volumeName string, diskOptions *DiskOptions) (disk *Disk, err error) {
+	volumeType := diskOptions.VolumeType
 	capacityGiB := util.BytesToGiB(diskOptions.CapacityBytes)
 
-	switch diskOptions.VolumeType {
-	case VolumeTypeTier1, VolumeTypeTier3:
-		volumeType = diskOptions.VolumeType
-	case "":
+	if volumeType == "" {
 		volumeType = DefaultVolumeType
-	default:
-		return nil, fmt.Errorf("invalid PowerVS VolumeType %q", diskOptions.VolumeType)
+	}
+	// the storage pool or the affinity target decides the volume type, no disk type is sent with them
+	if diskOptions.VolumePool != "" || diskOptions.AffinityPolicy != "" {
+		volumeType = ""
+	} else {
+		offered, err := p.isVolumeTypeOffered(ctx, volumeType)
+		if err != nil {
+			return nil, err
+		}
+		if !offered {
+			return nil, fmt.Errorf("invalid PowerVS VolumeType %q: %w", volumeType, ErrUnsupportedVolumeType)
+		}
 	}
 
 	dataVolume := &models.CreateDataVolume{
-		Name:      &volumeName,
-		Size:      pointer.Float64(float64(capacityGiB)),
-		Shareable: &diskOptions.Shareable,
-		DiskType:  volumeType,
+		Name:                     &volumeName,
+		Size:                     pointer.Float64(float64(capacityGiB)),
+		Shareable:                &diskOptions.Shareable,
+		DiskType:                 volumeType,
+		VolumePool:               diskOptions.VolumePool,
+		AntiAffinityVolumes:      diskOptions.AntiAffinityVolumes,
+		AntiAffinityPVMInstances: diskOptions.AntiAffinityPVMInstances,
+	}
+	if diskOptions.AffinityPolicy != "" {
+		dataVolume.AffinityPolicy = &diskOptions.AffinityPolicy
+	}
+	if diskOptions.AffinityVolume != "" {
+		dataVolume.AffinityVolume = &diskOptions.AffinityVolume
+	}
+	if diskOptions.AffinityPVMInstance != "" {
+		dataVolume.AffinityPVMInstance = &diskOptions.AffinityPVMInstance
 	}
 
-	v, err := p.volClient.CreateVolume(dataVolume)
+	v, err := p.volClient(ctx).CreateVolume(dataVolume)
 	if err != nil {
-		return nil, err
+		// the volume may have been created even though the call failed
+		p.cache.invalidateDisks()
+		return nil, toCloudError(err)
 	}
+	p.cache.putDisk(&Disk{VolumeID: *v.VolumeID, Name: volumeName, WWN: strings.ToLower(v.Wwn)})
 
-	err = p.WaitForVolumeState(*v.VolumeID, VolumeAvailableState)
+	err = p.WaitForVolumeState(ctx, *v.VolumeID, VolumeAvailableState)
 	if err != nil {
 		return nil, err
 	}
 
+	// Tagging is best effort, the volume is usable without its tags.
+	if err := p.TagDisk(ctx, *v.VolumeID, diskOptions.Tags); err != nil {
+		klog.Warningf("Could not tag volume %s, it is left untagged: %v", *v.VolumeID, err)
+	}
+
 	return &Disk{CapacityGiB: capacityGiB, VolumeID: *v.VolumeID, DiskType: v.DiskType, WWN: strings.ToLower(v.Wwn)}, nil
 }
 
-func (p *powerVSCloud) DeleteDisk(volumeID string) (success bool, err error) {
-	err = p.volClient.DeleteVolume(volumeID)
+// TagDisk attaches the tags to the volume as "key:value" user tags, tags the volume already has are kept.
+func (p *powerVSCloud) TagDisk(ctx context.Context, volumeID string, tags map[string]string) error {
+	if len(tags) == 0 {
+		return nil
+	}
+	tagNames := toTagNames(tags)
+	resourceID := p.volumeCRN(volumeID)
+	attachTagOptions := &globaltaggingv1.AttachTagOptions{
+		Resources: []globaltaggingv1.Resource{{ResourceID: &resourceID}},
+		TagNames:  tagNames,
+		TagType:   pointer.String(globaltaggingv1.AttachTagOptionsTagTypeUserConst),
+	}
+	results, _, err := p.globalTaggingClient.AttachTagWithContext(ctx, attachTagOptions)
 	if err != nil {
-		return false, err
+		return toCloudError(err)
+	}
+	for _, r := range results.Results {
+		if r.IsError != nil && *r.IsError {
+			return fmt.Errorf("could not attach tags %v to %s", tagNames, resourceID)
+		}
+	}
+	return nil
+}
+
+// UntagDisk detaches the "key:value" user tags from the volume.
+func (p *powerVSCloud) UntagDisk(ctx context.Context, volumeID string, tags map[string]string) error {
+	if len(tags) == 0 {
+		return nil
+	}
+	tagNames := toTagNames(tags)
+	resourceID := p.volumeCRN(volumeID)
+	detachTagOptions := &globaltaggingv1.DetachTagOptions{
+		Resources: []globaltaggingv1.Resource{{ResourceID: &resourceID}},
+		TagNames:  tagNames,
+		TagType:   pointer.String(globaltaggingv1.DetachTagOptionsTagTypeUserConst),
+	}
+	results, _, err := p.globalTaggingClient.DetachTagWithContext(ctx, detachTagOptions)
+	if err != nil {
+		return toCloudError(err)
+	}
+	for _, r := range results.Results {
+		if r.IsError != nil && *r.IsError {
+			return fmt.Errorf("could not detach tags %v from %s", tagNames, resourceID)
+		}
+	}
+	return nil
+}
+
+// GetDiskTags returns the "key:value" user tags of the volume, keyed by the part before the first colon.
+func (p *powerVSCloud) GetDiskTags(ctx context.Context, volumeID string) (map[string]string, error) {
+	tags := map[string]string{}
+	listTagsOptions := &globaltaggingv1.ListTagsOptions{
+		AttachedTo: pointer.String(p.volumeCRN(volumeID)),
+		TagType:    pointer.String(globaltaggingv1.ListTagsOptionsTagTypeUserConst),
+		Limit:      pointer.Int64(searchLimit),
+		Offset:     pointer.Int64(0),
+	}
+	for {
+		result, _, err := p.globalTaggingClient.ListTagsWithContext(ctx, listTagsOptions)
+		if err != nil {
+			return nil, toCloudError(err)
+		}
+		for _, tag := range result.Items {
+			if tag.Name == nil {
+				continue
+			}
+			key, value, _ := strings.Cut(*tag.Name, ":")
+			tags[key] = value
+		}
+		if len(result.Items) == 0 || result.TotalCount == nil || *listTagsOptions.Offset+int64(len(result.Items)) >= *result.TotalCount {
+			break
+		}
+		listTagsOptions.Offset = pointer.Int64(*listTagsOptions.Offset + int64(len(result.Items)))
+	}
+	return tags, nil
+}
+
+// toTagNames returns the sorted "key:value" names of the tags.
+func toTagNames(tags map[string]string) []string {
+	tagNames := make([]string, 0, len(tags))
+	for key, value := range tags {
+		tagNames = append(tagNames, key+":"+value)
+	}
+	sort.Strings(tagNames)
+	return tagNames
+}
+
+// ListDisksByTag returns the data volumes of the workspace carrying the given user tag.
+func (p *powerVSCloud) ListDisksByTag(ctx context.Context, tag string) (disks []*Disk, err error) {
+	volumeIDs := make(map[string]bool)
+	prefix := p.volumeCRN("")
+	searchOptions := &globalsearchv2.SearchOptions{
+		Query: pointer.String(fmt.Sprintf("tags:%q", tag)),
+		Limit: pointer.Int64(searchLimit),
+	}
+	for {
+		result, _, err := p.globalSearchClient.SearchWithContext(ctx, searchOptions)
+		if err != nil {
+			return nil, toCloudError(err)
+		}
+		for _, item := range result.Items {
+			if item.CRN != nil && strings.HasPrefix(*item.CRN, prefix) {
+				volumeIDs[strings.TrimPrefix(*item.CRN, prefix)] = true
+			}
+		}
+		if len(result.Items) == 0 || result.SearchCursor == nil {
+			break
+		}
+		searchOptions.SearchCursor = result.SearchCursor
+	}
+	if len(volumeIDs) == 0 {
+		return nil, nil
 	}
 
+	all, err := p.ListDisks(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, disk := range all {
+		if volumeIDs[disk.VolumeID] {
+			disks = append(disks, disk)
+		}
+	}
+	return disks, nil
+}
+
+// volumeCRN builds the CRN of a volume from the CRN of the service instance, which ends
+// with an empty resource type and ID, e.g. crn:v1:bluemix:public:power-iaas:<zone>:a/<account>:<instance>::
+func (p *powerVSCloud) volumeCRN(volumeID string) string {
+	return strings.TrimSuffix(p.cloudInstanceCRN, "::") + ":volume:" + volumeID
+}
+
+func (p *powerVSCloud) DeleteDisk(ctx context.Context, volumeID string) (success bool, err error) {
+	err = p.volClient(ctx).DeleteVolume(volumeID)
+	if err != nil {
+		return false, toCloudError(err)
+	}
+	p.cache.deleteDisk(volumeID)
+
 	return true, nil
 }
 
-func (p *powerVSCloud) AttachDisk(volumeID string, nodeID string) (err error) {
-	err = p.volClient.Attach(nodeID, volumeID)
+func (p *powerVSCloud) AttachDisk(ctx context.Context, volumeID string, nodeID string) (err error) {
+	err = p.volClient(ctx).Attach(nodeID, volumeID)
 	if err != nil {
-		return err
+		return toCloudError(err)
 	}
 
-	err = p.WaitForVolumeState(volumeID, VolumeInUseState)
+	err = p.WaitForVolumeState(ctx, volumeID, VolumeInUseState)
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
-func (p *powerVSCloud) DetachDisk(volumeID string, nodeID string) (err error) {
-	err = p.volClient.Detach(nodeID, volumeID)
+func (p *powerVSCloud) DetachDisk(ctx context.Context, volumeID string, nodeID string) (err error) {
+	err = p.volClient(ctx).Detach(nodeID, volumeID)
 	if err != nil {
-		return err
+		return toCloudError(err)
 	}
-	err = p.WaitForVolumeState(volumeID, VolumeAvailableState)
+	err = p.WaitForVolumeState(ctx, volumeID, VolumeAvailableState)
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
-func (p *powerVSCloud) IsAttached(volumeID string, nodeID string) (attached bool, err error) {
-	_, err = p.volClient.CheckVolumeAttach(nodeID, volumeID)
+// IsAttached returns false without error when PowerVS does not find the volume attached to the instance,
+// other failures to check the attachment are returned.
+func (p *powerVSCloud) IsAttached(ctx context.Context, volumeID string, nodeID string) (attached bool, err error) {
+	_, err = p.volClient(ctx).CheckVolumeAttach(nodeID, volumeID)
 	if err != nil {
+		err = toCloudError(err)
+		if errors.Is(err, ErrNotFound) {
+			return false, nil
+		}
 		return false, err
 	}
 	return true, nil
 }
 
-func (p *powerVSCloud) ResizeDisk(volumeID string, reqSize int64) (newSize int64, err error) {
-	disk, err := p.GetDiskByID(volumeID)
+func (p *powerVSCloud) ResizeDisk(ctx context.Context, volumeID string, reqSize int64) (newSize int64, err error) {
+	disk, err := p.GetDiskByID(ctx, volumeID)
 	if err != nil {
 		return 0, err
 	}
@@ -264,20 +545,171 @@ func (p *powerVSCloud) ResizeDisk(volumeID string, reqSize int64) (newSize int64
 		Shareable: &disk.Shareable,
 	}
 
-	v, err := p.volClient.UpdateVolume(volumeID, dataVolume)
+	v, err := p.volClient(ctx).UpdateVolume(volumeID, dataVolume)
 	if err != nil {
-		return 0, err
+		return 0, toCloudError(err)
 	}
 	return int64(*v.Size), nil
 }
 
-func (p *powerVSCloud) WaitForVolumeState(volumeID, state string) error {
-	err := wait.PollImmediate(PollInterval, PollTimeout, func() (bool, error) {
-		v, err := p.volClient.Get(volumeID)
+// ModifyDisk applies the modify options to the volume and waits for the volume to be available or
+// in-use again, with the new volume type when it is changed. PowerVS changes the storage tier of the
+// volume in place.
+func (p *powerVSCloud) ModifyDisk(ctx context.Context, volumeID string, modifyOptions *ModifyDiskOptions) (disk *Disk, err error) {
+	disk, err = p.GetDiskByID(ctx, volumeID)
+	if err != nil {
+		return nil, err
+	}
+
+	var targetType string
+	if modifyOptions.VolumeType != "" && modifyOptions.VolumeType != disk.DiskType {
+		targetType = modifyOptions.VolumeType
+	}
+	updateShareable := modifyOptions.Shareable != nil && *modifyOptions.Shareable != disk.Shareable
+	if targetType == "" && !updateShareable {
+		return disk, nil
+	}
+
+	if targetType != "" {
+		offered, err := p.isVolumeTypeOffered(ctx, targetType)
+		if err != nil {
+			return nil, err
+		}
+		if !offered {
+			return nil, fmt.Errorf("invalid PowerVS VolumeType %q: %w", targetType, ErrUnsupportedVolumeType)
+		}
+		if err = p.volClient(ctx).VolumeAction(volumeID, &models.VolumeAction{TargetStorageTier: &targetType}); err != nil {
+			return nil, toCloudError(err)
+		}
+	}
+	if updateShareable {
+		dataVolume := &models.UpdateVolume{
+			Name:      &disk.Name,
+			Shareable: modifyOptions.Shareable,
+		}
+		if _, err = p.volClient(ctx).UpdateVolume(volumeID, dataVolume); err != nil {
+			return nil, toCloudError(err)
+		}
+	}
+
+	if err = p.waitForVolumeModified(ctx, volumeID, targetType); err != nil {
+		return nil, err
+	}
+	return p.GetDiskByID(ctx, volumeID)
+}
+
+// waitForVolumeModified waits for the volume to be usable again after a modification, and to have the
+// target volume type when it is set.
+func (p *powerVSCloud) waitForVolumeModified(ctx context.Context, volumeID, targetType string) error {
+	return wait.PollImmediateWithContext(ctx, PollInterval, ModifyPollTimeout, func(ctx context.Context) (bool, error) {
+		v, err := p.volClient(ctx).Get(volumeID)
+		if err != nil {
+			return false, toCloudError(err)
+		}
+		if v.State == VolumeErrorState {
+			return false, fmt.Errorf("volume %s is in %s state: %w", volumeID, v.State, ErrInvalidState)
+		}
+		if v.State != VolumeAvailableState && v.State != VolumeInUseState {
+			return false, nil
+		}
+		return targetType == "" || v.DiskType == targetType, nil
+	})
+}
+
+// CloneDisk creates a copy of the source volume named cloneName.
+func (p *powerVSCloud) CloneDisk(ctx context.Context, sourceVolumeID string, cloneName string, diskOptions *DiskOptions) (disk *Disk, err error) {
+	clonedVolumeID, err := p.cloneVolume(ctx, sourceVolumeID, cloneName)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.updateClonedVolume(ctx, clonedVolumeID, cloneName, diskOptions)
+}
+
+// cloneVolume runs a PowerVS clone task for the given volume and waits for it to
+// finish. It returns the ID of the cloned volume.
+func (p *powerVSCloud) cloneVolume(ctx context.Context, sourceVolumeID, cloneName string) (clonedVolumeID string, err error) {
+	body := &models.VolumesCloneAsyncRequest{
+		Name:      &cloneName,
+		VolumeIDs: []string{sourceVolumeID},
+	}
+	ref, err := p.cloneVolumeClient(ctx).Create(body)
+	if err != nil {
+		p.cache.invalidateDisks()
+		return "", toCloudError(err)
+	}
+	// the cloned volume shows up in the workspace before the task completes
+	defer func() {
+		if err != nil {
+			p.cache.invalidateDisks()
+		}
+	}()
+
+	err = wait.PollImmediateWithContext(ctx, PollInterval, ClonePollTimeout, func(ctx context.Context) (bool, error) {
+		task, err := p.cloneVolumeClient(ctx).Get(*ref.CloneTaskID)
+		if err != nil {
+			return false, toCloudError(err)
+		}
+		switch *task.Status {
+		case CloneTaskCompletedState:
+			if len(task.ClonedVolumes) == 0 {
+				return false, fmt.Errorf("clone task %s completed without cloned volumes", *ref.CloneTaskID)
+			}
+			clonedVolumeID = task.ClonedVolumes[0].ClonedVolumeID
+			return true, nil
+		case CloneTaskFailedState:
+			return false, fmt.Errorf("clone task %s failed: %s", *ref.CloneTaskID, task.FailedReason)
+		}
+		return false, nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return clonedVolumeID, nil
+}
+
+// updateClonedVolume gives a freshly cloned volume its final name, shareable
+// mode and size, and waits for it to become available.
+func (p *powerVSCloud) updateClonedVolume(ctx context.Context, volumeID, volumeName string, diskOptions *DiskOptions) (disk *Disk, err error) {
+	v, err := p.volClient(ctx).Get(volumeID)
+	if err != nil {
+		return nil, toCloudError(err)
+	}
+	p.cache.putDisk(&Disk{VolumeID: volumeID, Name: volumeName, WWN: strings.ToLower(v.Wwn)})
+
+	dataVolume := &models.UpdateVolume{
+		Name:      &volumeName,
+		Shareable: &diskOptions.Shareable,
+	}
+	if capacityGiB := util.BytesToGiB(diskOptions.CapacityBytes); float64(capacityGiB) > *v.Size {
+		dataVolume.Size = float64(capacityGiB)
+	}
+	if _, err = p.volClient(ctx).UpdateVolume(volumeID, dataVolume); err != nil {
+		return nil, toCloudError(err)
+	}
+
+	err = p.WaitForVolumeState(ctx, volumeID, VolumeAvailableState)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.TagDisk(ctx, volumeID, diskOptions.Tags); err != nil {
+		klog.Warningf("Could not tag volume %s, it is left untagged: %v", volumeID, err)
+	}
+
+	return p.GetDiskByID(ctx, volumeID)
+}
+
+func (p *powerVSCloud) WaitForVolumeState(ctx context.Context, volumeID, state string) error {
+	err := wait.PollImmediateWithContext(ctx, PollInterval, PollTimeout, func(ctx context.Context) (bool, error) {
+		v, err := p.volClient(ctx).Get(volumeID)
 		if err != nil {
-			return false, err
+			return false, toCloudError(err)
 		}
 		spew.Dump(v)
+		if v.State == VolumeErrorState && state != VolumeErrorState {
+			return false, fmt.Errorf("volume %s is in %s state: %w", volumeID, v.State, ErrInvalidState)
+		}
 		return v.State == state, nil
 	})
 	if err != nil {
@@ -286,43 +718,196 @@ func (p *powerVSCloud) WaitForVolumeState(volumeID, state string) error {
 	return nil
 }
 
-func (p *powerVSCloud) GetDiskByName(name string) (disk *Disk, err error) {
-	//TODO: remove capacityBytes
-	params := p_cloud_volumes.NewPcloudCloudinstancesVolumesGetallParamsWithTimeout(TIMEOUT).WithCloudInstanceID(p.cloudInstanceID)
+// GetDiskByName resolves the volume ID from the cache and gets the volume. A miss only lists the volumes
+// again when the background refresh is late, a volume created by the driver being cached by the create.
+func (p *powerVSCloud) GetDiskByName(ctx context.Context, name string) (disk *Disk, err error) {
+	disk, err = p.getCachedDisk(ctx, func() (string, bool) { return p.cache.diskIDByName(name) })
+	if err != nil {
+		return nil, err
+	}
+	if disk.Name != name {
+		return nil, ErrNotFound
+	}
+	return disk, nil
+}
+
+// GetDiskByWWN resolves the volume ID from the cache and gets the volume like GetDiskByName does. The WWN
+// is matched regardless of its case.
+func (p *powerVSCloud) GetDiskByWWN(ctx context.Context, wwn string) (disk *Disk, err error) {
+	disk, err = p.getCachedDisk(ctx, func() (string, bool) { return p.cache.diskIDByWWN(wwn) })
+	if err != nil {
+		return nil, err
+	}
+	if !strings.EqualFold(disk.WWN, wwn) {
+		return nil, ErrNotFound
+	}
+	return disk, nil
+}
+
+func (p *powerVSCloud) getCachedDisk(ctx context.Context, lookup func() (string, bool)) (*Disk, error) {
+	p.cache.disksRefresh.Do(func() { go p.refreshCache("volumes", p.syncDisks) })
+	volumeID, found := lookup()
+	if !found {
+		if err := p.syncDisks(ctx, cacheResyncPeriod); err != nil {
+			return nil, err
+		}
+		if volumeID, found = lookup(); !found {
+			return nil, ErrNotFound
+		}
+	}
+	return p.GetDiskByID(ctx, volumeID)
+}
+
+// syncDisks lists the volumes of the workspace into the cache, unless they were listed within maxAge.
+func (p *powerVSCloud) syncDisks(ctx context.Context, maxAge time.Duration) error {
+	p.cache.disksSyncMu.Lock()
+	defer p.cache.disksSyncMu.Unlock()
+	if !p.cache.disksStale(maxAge) {
+		return nil
+	}
+	_, err := p.ListDisks(ctx)
+	return err
+}
+
+// refreshCache lists the resources into the cache every cacheResyncPeriod, so that the changes made
+// outside of the driver are picked up by the lookups hitting the cache. It runs until the client is closed.
+func (p *powerVSCloud) refreshCache(resources string, list func(ctx context.Context, maxAge time.Duration) error) {
+	if p.refreshCtx == nil {
+		return
+	}
+	ticker := time.NewTicker(cacheResyncPeriod)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.refreshCtx.Done():
+			return
+		case <-ticker.C:
+		}
+		ctx, cancel := context.WithTimeout(p.refreshCtx, cacheResyncPeriod)
+		if err := list(ctx, cacheMissResyncPeriod); err != nil && p.refreshCtx.Err() == nil {
+			klog.Warningf("Failed to refresh the cached %s of workspace %s: %v", resources, p.cloudInstanceID, err)
+		}
+		cancel()
+	}
+}
+
+// GetDiskByID gets the volume from PowerVS and refreshes its cache entry.
+func (p *powerVSCloud) GetDiskByID(ctx context.Context, volumeID string) (disk *Disk, err error) {
+	v, err := p.volClient(ctx).Get(volumeID)
+	if err != nil {
+		err = toCloudError(err)
+		if errors.Is(err, ErrNotFound) {
+			p.cache.deleteDisk(volumeID)
+		}
+		return nil, err
+	}
+	disk = &Disk{
+		Name:             *v.Name,
+		DiskType:         v.DiskType,
+		VolumeID:         *v.VolumeID,
+		WWN:              strings.ToLower(v.Wwn),
+		Shareable:        *v.Shareable,
+		CapacityGiB:      int64(*v.Size),
+		PVMInstanceIDs:   v.PvmInstanceIDs,
+		State:            v.State,
+		LastUpdateTime:   toTime(v.LastUpdateDate),
+		OutOfBandDeleted: v.OutOfBandDeleted,
+	}
+	p.cache.putDisk(disk)
+	return disk, nil
+}
+
+// ListDisks returns the data volumes of the workspace, boot volumes of the PVM instances are skipped.
+// The listing replaces the volumes of the cache.
+func (p *powerVSCloud) ListDisks(ctx context.Context) (disks []*Disk, err error) {
+	synced := time.Now()
+	params := p_cloud_volumes.NewPcloudCloudinstancesVolumesGetallParamsWithContext(ctx).WithTimeout(TIMEOUT).WithCloudInstanceID(p.cloudInstanceID)
 	resp, err := p.piSession.Power.PCloudVolumes.PcloudCloudinstancesVolumesGetall(params, p.piSession.AuthInfo(p.cloudInstanceID))
 	if err != nil {
-		return nil, errors.ToError(err)
+		return nil, toCloudError(err)
 	}
 	for _, v := range resp.Payload.Volumes {
-		if name == *v.Name {
-			return &Disk{
-				Name:        *v.Name,
-				DiskType:    *v.DiskType,
-				VolumeID:    *v.VolumeID,
-				WWN:         strings.ToLower(*v.Wwn),
-				Shareable:   *v.Shareable,
-				CapacityGiB: int64(*v.Size),
-			}, nil
+		if v.BootVolume != nil && *v.BootVolume {
+			continue
 		}
+		disks = append(disks, toDisk(v))
 	}
+	p.cache.setDisks(disks, synced)
+	return disks, nil
+}
 
-	return nil, ErrNotFound
+// ListPVMInstanceDisks returns the volumes attached to the instance, boot volumes included.
+func (p *powerVSCloud) ListPVMInstanceDisks(ctx context.Context, instanceID string) (disks []*Disk, err error) {
+	resp, err := p.volClient(ctx).GetAllInstanceVolumes(instanceID)
+	if err != nil {
+		return nil, toCloudError(err)
+	}
+	for _, v := range resp.Volumes {
+		disks = append(disks, toDisk(v))
+	}
+	return disks, nil
 }
 
-func (p *powerVSCloud) GetDiskByID(volumeID string) (disk *Disk, err error) {
-	v, err := p.volClient.Get(volumeID)
+// isVolumeTypeOffered checks the volume type against the storage types of the workspace, the storage
+// types are listed again once the cached ones are older than cacheResyncPeriod.
+func (p *powerVSCloud) isVolumeTypeOffered(ctx context.Context, volumeType string) (bool, error) {
+	if offered, fresh := p.cache.volumeTypeOffered(volumeType); fresh {
+		return offered, nil
+	}
+	synced := time.Now()
+	resp, err := p.storageCapacityClient(ctx).GetAllStorageTypesCapacity()
 	if err != nil {
-		if strings.Contains(err.Error(), "Resource not found") {
-			return nil, ErrNotFound
+		return false, toCloudError(err)
+	}
+	var volumeTypes []string
+	for _, t := range resp.StorageTypesCapacity {
+		volumeTypes = append(volumeTypes, t.StorageType)
+	}
+	p.cache.setVolumeTypes(volumeTypes, synced)
+	offered, _ := p.cache.volumeTypeOffered(volumeType)
+	return offered, nil
+}
+
+// GetStorageCapacity returns the capacity left in the storage pools of the workspace.
+// When volumeType is set, only the pools backing that storage type are accounted.
+func (p *powerVSCloud) GetStorageCapacity(ctx context.Context, volumeType string) (capacity *StorageCapacity, err error) {
+	resp, err := p.storageCapacityClient(ctx).GetAllStoragePoolsCapacity()
+	if err != nil {
+		return nil, toCloudError(err)
+	}
+	capacity = &StorageCapacity{}
+	for _, pool := range resp.StoragePoolsCapacity {
+		if volumeType != "" && !strings.EqualFold(pool.StorageType, volumeType) {
+			continue
+		}
+		capacity.AvailableCapacityGiB += pool.AvailableCapacity
+		// MaxAllocationSize is the largest volume the pool can hold, not its free space
+		if pool.MaxAllocationSize != nil && *pool.MaxAllocationSize > capacity.MaximumVolumeSizeGiB {
+			capacity.MaximumVolumeSizeGiB = *pool.MaxAllocationSize
 		}
-		return nil, err
 	}
+	return capacity, nil
+}
+
+func toDisk(v *models.VolumeReference) *Disk {
 	return &Disk{
-		Name:        *v.Name,
-		DiskType:    v.DiskType,
-		VolumeID:    *v.VolumeID,
-		WWN:         strings.ToLower(v.Wwn),
-		Shareable:   *v.Shareable,
-		CapacityGiB: int64(*v.Size),
-	}, nil
+		Name:             *v.Name,
+		DiskType:         *v.DiskType,
+		VolumeID:         *v.VolumeID,
+		WWN:              strings.ToLower(*v.Wwn),
+		Shareable:        *v.Shareable,
+		CapacityGiB:      int64(*v.Size),
+		PVMInstanceIDs:   v.PvmInstanceIDs,
+		State:            *v.State,
+		LastUpdateTime:   toTime(v.LastUpdateDate),
+		OutOfBandDeleted: v.OutOfBandDeleted,
+		BootVolume:       v.BootVolume != nil && *v.BootVolume,
+	}
+}
+
+func toTime(t *strfmt.DateTime) time.Time {
+	if t == nil {
+		return time.Time{}
+	}
+	return time.Time(*t)
 }
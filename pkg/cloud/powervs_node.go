@@ -17,7 +17,10 @@ limitations under the License.
 package cloud
 
 import (
+	"context"
 	"errors"
+	"sync"
+	"time"
 
 	"github.com/IBM-Cloud/power-go-client/power/models"
 	"k8s.io/utils/pointer"
@@ -26,13 +29,17 @@ import (
 const (
 	PowerVSInstanceStateSHUTOFF = "SHUTOFF"
 	PowerVSInstanceStateACTIVE  = "ACTIVE"
-	StoragePoolAffinity         = false
+	// DefaultStoragePoolAffinity is the storage pool affinity of the instances when neither the node
+	// nor the cluster ask for another one. Without affinity, volumes of other storage pools can be attached.
+	DefaultStoragePoolAffinity = false
 )
 
 type NodeUpdateScopeParams struct {
 	ServiceInstanceId string
 	InstanceId        string
 	Zone              string
+	// ClientCache, when set, provides the PowerVS client of the scope instead of creating a new one
+	ClientCache *ClientCache
 }
 
 type NodeUpdateScope struct {
@@ -63,32 +70,130 @@ func NewNodeUpdateScope(params NodeUpdateScopeParams) (scope *NodeUpdateScope, e
 	}
 	scope.Zone = params.Zone
 
-	c, err := NewPowerVSCloud(scope.ServiceInstanceId, scope.Zone, false)
+	var c Cloud
+	if params.ClientCache != nil {
+		c, err = params.ClientCache.Get(scope.ServiceInstanceId, scope.Zone)
+	} else {
+		c, err = NewPowerVSCloud(scope.ServiceInstanceId, scope.Zone, false, DefaultRetryPolicy)
+	}
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 	scope.Cloud = c
 
 	return scope, nil
 }
 
-func (p *powerVSCloud) GetPVMInstanceDetails(instanceID string) (*models.PVMInstance, error) {
-	insDetails, err := p.pvmInstancesClient.Get(instanceID)
+// clientIdleTimeout is how long a cached client can stay unused before it is dropped, it is longer than
+// the default periods of the controllers so that only the clients of the workspaces no longer in use,
+// e.g. deleted ones, are dropped.
+const clientIdleTimeout = 2 * time.Hour
+
+// ClientCache shares the PowerVS clients, keyed by service instance ID and zone, so the resource
+// controller lookup and the session creation are done once per workspace instead of on every call.
+// The IAM authenticator of a cached client refreshes its token before it expires.
+type ClientCache struct {
+	mu      sync.Mutex
+	clients map[clientCacheKey]*clientCacheEntry
+	// creating holds the clients being created, so the concurrent calls for a workspace wait for a
+	// single creation without holding mu
+	creating map[clientCacheKey]*clientCreation
+	newCloud func(cloudInstanceID, zone string, debug bool) (Cloud, error)
+}
+
+type clientCacheKey struct {
+	serviceInstanceID string
+	zone              string
+}
+
+type clientCacheEntry struct {
+	client   Cloud
+	lastUsed time.Time
+}
+
+type clientCreation struct {
+	done   chan struct{}
+	client Cloud
+	err    error
+}
+
+// NewClientCache returns a cache creating its clients with the retry policy.
+func NewClientCache(retryPolicy RetryPolicy) *ClientCache {
+	return NewClientCacheWithFactory(func(cloudInstanceID, zone string, debug bool) (Cloud, error) {
+		return NewPowerVSCloud(cloudInstanceID, zone, debug, retryPolicy)
+	})
+}
+
+// NewClientCacheWithFactory returns a cache creating its clients with newCloud instead of NewPowerVSCloud.
+func NewClientCacheWithFactory(newCloud func(cloudInstanceID, zone string, debug bool) (Cloud, error)) *ClientCache {
+	return &ClientCache{
+		clients:  map[clientCacheKey]*clientCacheEntry{},
+		creating: map[clientCacheKey]*clientCreation{},
+		newCloud: newCloud,
+	}
+}
+
+// Get returns the client of the workspace, creating it on the first call. Clients that fail to be
+// created are not cached, so the next call tries again. The clients unused for clientIdleTimeout are
+// dropped and closed.
+func (c *ClientCache) Get(serviceInstanceID, zone string) (Cloud, error) {
+	key := clientCacheKey{serviceInstanceID: serviceInstanceID, zone: zone}
+	now := time.Now()
+
+	c.mu.Lock()
+	c.evictIdleLocked(now)
+	if entry, ok := c.clients[key]; ok {
+		entry.lastUsed = now
+		c.mu.Unlock()
+		return entry.client, nil
+	}
+	if creation, ok := c.creating[key]; ok {
+		c.mu.Unlock()
+		<-creation.done
+		return creation.client, creation.err
+	}
+	creation := &clientCreation{done: make(chan struct{})}
+	c.creating[key] = creation
+	c.mu.Unlock()
+
+	creation.client, creation.err = c.newCloud(serviceInstanceID, zone, false)
+
+	c.mu.Lock()
+	if creation.err == nil {
+		c.clients[key] = &clientCacheEntry{client: creation.client, lastUsed: time.Now()}
+	}
+	delete(c.creating, key)
+	c.mu.Unlock()
+	close(creation.done)
+	return creation.client, creation.err
+}
+
+func (c *ClientCache) evictIdleLocked(now time.Time) {
+	for key, entry := range c.clients {
+		if now.Sub(entry.lastUsed) > clientIdleTimeout {
+			delete(c.clients, key)
+			entry.client.Close()
+		}
+	}
+}
+
+func (p *powerVSCloud) GetPVMInstanceDetails(ctx context.Context, instanceID string) (*models.PVMInstance, error) {
+	insDetails, err := p.pvmInstancesClient(ctx).Get(instanceID)
 	if err != nil {
-		return nil, err
+		return nil, toCloudError(err)
 	}
 	return insDetails, nil
 }
 
-func (p *powerVSCloud) UpdateStoragePoolAffinity(instanceID string) error {
+func (p *powerVSCloud) UpdateStoragePoolAffinity(ctx context.Context, instanceID string, affinity bool) error {
 
 	body := &models.PVMInstanceUpdate{
-		StoragePoolAffinity: pointer.Bool(StoragePoolAffinity),
+		StoragePoolAffinity: pointer.Bool(affinity),
 	}
 
-	_, err := p.pvmInstancesClient.Update(instanceID, body)
+	_, err := p.pvmInstancesClient(ctx).Update(instanceID, body)
 	if err != nil {
-		return err
+		return toCloudError(err)
 	}
 	return nil
 }
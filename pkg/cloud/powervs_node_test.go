@@ -0,0 +1,135 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloud
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestClientCacheGet(t *testing.T) {
+	var created int32
+	var startOnce sync.Once
+	started := make(chan struct{})
+	release := make(chan struct{})
+	c := NewClientCacheWithFactory(func(cloudInstanceID, zone string, debug bool) (Cloud, error) {
+		if cloudInstanceID == "workspace-1" {
+			atomic.AddInt32(&created, 1)
+			startOnce.Do(func() { close(started) })
+			<-release
+		}
+		return &powerVSCloud{cloudInstanceID: cloudInstanceID}, nil
+	})
+
+	// the concurrent calls for a workspace wait for a single creation
+	var wg sync.WaitGroup
+	clients := make([]Cloud, 3)
+	for i := range clients {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			clients[i], _ = c.Get("workspace-1", "zone")
+		}(i)
+	}
+
+	// the creation does not hold the cache, the other workspaces are served meanwhile
+	<-started
+	if _, err := c.Get("workspace-2", "zone"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	close(release)
+	wg.Wait()
+	if created != 1 {
+		t.Fatalf("Expected a single client creation, got %d", created)
+	}
+	for _, client := range clients {
+		if client == nil || client != clients[0] {
+			t.Fatalf("Expected the same client for every call, got %v", clients)
+		}
+	}
+}
+
+func TestClientCacheGetError(t *testing.T) {
+	fail := true
+	c := NewClientCacheWithFactory(func(cloudInstanceID, zone string, debug bool) (Cloud, error) {
+		if fail {
+			return nil, errors.New("resource controller unreachable")
+		}
+		return &powerVSCloud{}, nil
+	})
+
+	if _, err := c.Get("workspace-1", "zone"); err == nil {
+		t.Fatalf("Expected an error")
+	}
+	fail = false
+	if client, err := c.Get("workspace-1", "zone"); err != nil || client == nil {
+		t.Fatalf("Expected the creation to be tried again, got %v, %v", client, err)
+	}
+}
+
+func TestClientCacheEvictsIdleClients(t *testing.T) {
+	created := 0
+	c := NewClientCacheWithFactory(func(cloudInstanceID, zone string, debug bool) (Cloud, error) {
+		created++
+		refreshCtx, stopRefresh := context.WithCancel(context.Background())
+		return &powerVSCloud{cloudInstanceID: cloudInstanceID, refreshCtx: refreshCtx, stopRefresh: stopRefresh}, nil
+	})
+
+	idle, err := c.Get("deleted-workspace", "zone")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	c.clients[clientCacheKey{serviceInstanceID: "deleted-workspace", zone: "zone"}].lastUsed = time.Now().Add(-clientIdleTimeout - time.Minute)
+
+	if _, err := c.Get("workspace", "zone"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, found := c.clients[clientCacheKey{serviceInstanceID: "deleted-workspace", zone: "zone"}]; found {
+		t.Fatalf("Expected the idle client to be dropped")
+	}
+	if idle.(*powerVSCloud).refreshCtx.Err() == nil {
+		t.Fatalf("Expected the idle client to be closed")
+	}
+	if _, err := c.Get("workspace", "zone"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if created != 2 {
+		t.Fatalf("Expected the client in use to stay cached, got %d creations", created)
+	}
+}
+
+func TestRefreshCacheStopsOnClose(t *testing.T) {
+	refreshCtx, stopRefresh := context.WithCancel(context.Background())
+	p := &powerVSCloud{refreshCtx: refreshCtx, stopRefresh: stopRefresh}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		p.refreshCache("volumes", func(ctx context.Context, maxAge time.Duration) error { return nil })
+	}()
+	p.Close()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("Expected the refresh to stop once the client is closed")
+	}
+}
@@ -0,0 +1,161 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloud
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/IBM-Cloud/power-go-client/power/models"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// CreateSnapshot takes a flash-copy snapshot of the given volume. PowerVS only
+// snapshots volumes through the PVM instance they are attached to, so the
+// volume has to be attached to at least one instance, ErrInvalidState is returned otherwise.
+func (p *powerVSCloud) CreateSnapshot(ctx context.Context, volumeID string, snapshotName string) (snapshot *Snapshot, err error) {
+	v, err := p.volClient(ctx).Get(volumeID)
+	if err != nil {
+		return nil, toCloudError(err)
+	}
+	if len(v.PvmInstanceIDs) == 0 {
+		return nil, fmt.Errorf("volume %s must be attached to a PVM instance to take a snapshot: %w", volumeID, ErrInvalidState)
+	}
+
+	body := &models.SnapshotCreate{
+		Name:      &snapshotName,
+		VolumeIDs: []string{volumeID},
+	}
+	resp, err := p.pvmInstancesClient(ctx).CreatePvmSnapShot(v.PvmInstanceIDs[0], body)
+	if err != nil {
+		return nil, toCloudError(err)
+	}
+	if resp == nil || resp.SnapshotID == nil {
+		return nil, fmt.Errorf("snapshot %s of volume %s was created without an ID", snapshotName, volumeID)
+	}
+
+	return &Snapshot{
+		SnapshotID:     *resp.SnapshotID,
+		SourceVolumeID: volumeID,
+		Name:           snapshotName,
+		CreationTime:   time.Now(),
+	}, nil
+}
+
+// CreateDiskFromSnapshot restores a snapshot into a new volume by cloning the
+// flash-copy volume behind it. The clone is renamed to volumeName so that
+// GetDiskByName finds it on retries, and grown when a larger size is requested.
+func (p *powerVSCloud) CreateDiskFromSnapshot(ctx context.Context, volumeName string, snapshotID string, diskOptions *DiskOptions) (disk *Disk, err error) {
+	snapshot, err := p.GetSnapshotByID(ctx, snapshotID)
+	if err != nil {
+		return nil, err
+	}
+	if !snapshot.ReadyToUse {
+		return nil, fmt.Errorf("snapshot %s is not ready to use, current state: %s", snapshotID, snapshot.State)
+	}
+
+	clonedVolumeID, err := p.cloneVolume(ctx, snapshot.SnapshotVolumeID, volumeName)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.updateClonedVolume(ctx, clonedVolumeID, volumeName, diskOptions)
+}
+
+func (p *powerVSCloud) DeleteSnapshot(ctx context.Context, snapshotID string) (err error) {
+	return toCloudError(p.snapshotClient(ctx).Delete(snapshotID))
+}
+
+func (p *powerVSCloud) WaitForSnapshotState(ctx context.Context, snapshotID, state string) error {
+	err := wait.PollImmediateWithContext(ctx, PollInterval, PollTimeout, func(ctx context.Context) (bool, error) {
+		s, err := p.snapshotClient(ctx).Get(snapshotID)
+		if err != nil {
+			return false, toCloudError(err)
+		}
+		if strings.EqualFold(s.Status, SnapshotErrorState) && state != SnapshotErrorState {
+			return false, fmt.Errorf("snapshot %s is in %s state", snapshotID, s.Status)
+		}
+		return strings.EqualFold(s.Status, state), nil
+	})
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (p *powerVSCloud) GetSnapshotByName(ctx context.Context, name string) (snapshot *Snapshot, err error) {
+	snapshots, err := p.ListSnapshots(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range snapshots {
+		if s.Name == name {
+			return s, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (p *powerVSCloud) GetSnapshotByID(ctx context.Context, snapshotID string) (snapshot *Snapshot, err error) {
+	s, err := p.snapshotClient(ctx).Get(snapshotID)
+	if err != nil {
+		return nil, toCloudError(err)
+	}
+	snapshot = toSnapshot(s)
+	if snapshot == nil {
+		return nil, ErrNotFound
+	}
+	return snapshot, nil
+}
+
+// ListSnapshots returns the single-volume snapshots of the workspace. Snapshots
+// spanning several volumes are not created by the driver and are skipped.
+func (p *powerVSCloud) ListSnapshots(ctx context.Context) (snapshots []*Snapshot, err error) {
+	resp, err := p.snapshotClient(ctx).GetAll()
+	if err != nil {
+		return nil, toCloudError(err)
+	}
+	for _, s := range resp.Snapshots {
+		if snapshot := toSnapshot(s); snapshot != nil {
+			snapshots = append(snapshots, snapshot)
+		}
+	}
+	return snapshots, nil
+}
+
+// toSnapshot converts a PowerVS snapshot into a Snapshot, returning nil when it
+// does not hold exactly one volume or misses its ID or name.
+func toSnapshot(s *models.Snapshot) *Snapshot {
+	if s == nil || s.SnapshotID == nil || s.Name == nil || len(s.VolumeSnapshots) != 1 {
+		return nil
+	}
+	var sourceVolumeID, snapshotVolumeID string
+	for volumeID, volumeSnapshotID := range s.VolumeSnapshots {
+		sourceVolumeID, snapshotVolumeID = volumeID, volumeSnapshotID
+	}
+	return &Snapshot{
+		SnapshotID:       *s.SnapshotID,
+		SourceVolumeID:   sourceVolumeID,
+		SnapshotVolumeID: snapshotVolumeID,
+		Name:             *s.Name,
+		State:            s.Status,
+		CreationTime:     time.Time(s.CreationDate),
+		ReadyToUse:       strings.EqualFold(s.Status, SnapshotAvailableState),
+	}
+}
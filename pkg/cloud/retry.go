@@ -0,0 +1,195 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloud
+
+import (
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+
+	"golang.org/x/time/rate"
+	"k8s.io/klog/v2"
+)
+
+// RetryPolicy configures the client-side rate limiting and the retries of the PowerVS API calls.
+type RetryPolicy struct {
+	// QPS is the sustained number of requests per second, 0 disables the rate limiting
+	QPS float64
+	// Burst is the number of requests that can be sent at once above QPS
+	Burst int
+	// MaxRetries is the number of retries of a failed request, 0 disables the retries
+	MaxRetries int
+	// InitialBackoff is the delay before the first retry, doubled on every following retry
+	InitialBackoff time.Duration
+	// MaxBackoff caps the delay between two retries, Retry-After headers included
+	MaxBackoff time.Duration
+}
+
+// DefaultRetryPolicy is the policy of the clients when none is configured.
+var DefaultRetryPolicy = RetryPolicy{
+	QPS:            10,
+	Burst:          20,
+	MaxRetries:     5,
+	InitialBackoff: time.Second,
+	MaxBackoff:     30 * time.Second,
+}
+
+func newRateLimiter(policy RetryPolicy) *rate.Limiter {
+	if policy.QPS <= 0 {
+		return rate.NewLimiter(rate.Inf, 0)
+	}
+	return rate.NewLimiter(rate.Limit(policy.QPS), policy.Burst)
+}
+
+// newRetryTransport wraps next with the rate limiter and retry policy. The transports of the clients of
+// a workspace share one limiter, so QPS and Burst apply to all their calls.
+func newRetryTransport(next http.RoundTripper, policy RetryPolicy, limiter *rate.Limiter) http.RoundTripper {
+	return &retryTransport{
+		next:    next,
+		limiter: limiter,
+		policy:  policy,
+	}
+}
+
+// retryTransport rate limits the requests and retries the idempotent ones on throttling, server errors
+// and connection failures, waiting for the Retry-After delay when the server sends one, or else for an
+// exponential backoff with jitter.
+type retryTransport struct {
+	next    http.RoundTripper
+	limiter *rate.Limiter
+	policy  RetryPolicy
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	for attempt := 0; ; attempt++ {
+		if err := t.limiter.Wait(ctx); err != nil {
+			return nil, err
+		}
+
+		r := req
+		if attempt > 0 {
+			r = req.Clone(ctx)
+			if req.Body != nil && req.Body != http.NoBody {
+				body, err := req.GetBody()
+				if err != nil {
+					return nil, err
+				}
+				r.Body = body
+			}
+		}
+
+		resp, err := t.next.RoundTrip(r)
+		if attempt >= t.policy.MaxRetries || !isRetryableRequest(req) || !isRetryableResponse(resp, err) || ctx.Err() != nil {
+			return resp, err
+		}
+
+		delay := t.backoff(attempt)
+		if resp != nil {
+			if retryAfter, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok {
+				if retryAfter > t.policy.MaxBackoff {
+					// Waiting longer than the policy allows, give the response back to the caller.
+					return resp, nil
+				}
+				delay = retryAfter
+			}
+			// Drain the body so the connection can be reused.
+			_, _ = io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+			klog.V(4).Infof("Retrying %s %s in %v after status %d, attempt %d of %d", req.Method, req.URL.Path, delay, resp.StatusCode, attempt+1, t.policy.MaxRetries)
+		} else {
+			klog.V(4).Infof("Retrying %s %s in %v after error %v, attempt %d of %d", req.Method, req.URL.Path, delay, err, attempt+1, t.policy.MaxRetries)
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// backoff returns the exponential backoff of the attempt, randomized between half and all of it so the
+// retries of concurrent calls are spread out.
+func (t *retryTransport) backoff(attempt int) time.Duration {
+	delay := t.policy.MaxBackoff
+	if attempt < 32 {
+		if d := t.policy.InitialBackoff << uint(attempt); d > 0 && d < delay {
+			delay = d
+		}
+	}
+	half := delay / 2
+	if half <= 0 {
+		return delay
+	}
+	return half + time.Duration(rand.Int63n(int64(half)))
+}
+
+// isRetryableRequest returns true for the safe and idempotent methods whose body, if any, can be sent again.
+// A DELETE retried after the first one went through gets a 404, the callers deleting or detaching a
+// volume take ErrNotFound as a success.
+func isRetryableRequest(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+	default:
+		return false
+	}
+	return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+}
+
+func isRetryableResponse(resp *http.Response, err error) bool {
+	if err != nil {
+		var netErr net.Error
+		if errors.As(err, &netErr) && netErr.Timeout() {
+			return true
+		}
+		return errors.Is(err, syscall.ECONNRESET) || errors.Is(err, io.ErrUnexpectedEOF)
+	}
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// parseRetryAfter parses a Retry-After header given in seconds or as an HTTP date.
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		if delay := time.Until(date); delay > 0 {
+			return delay, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
@@ -0,0 +1,227 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloud
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestBackoff(t *testing.T) {
+	transport := &retryTransport{policy: RetryPolicy{InitialBackoff: time.Second, MaxBackoff: 8 * time.Second}}
+
+	testCases := []struct {
+		attempt int
+		expMax  time.Duration
+	}{
+		{attempt: 0, expMax: time.Second},
+		{attempt: 1, expMax: 2 * time.Second},
+		{attempt: 3, expMax: 8 * time.Second},
+		{attempt: 4, expMax: 8 * time.Second},
+		{attempt: 40, expMax: 8 * time.Second},
+	}
+
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("attempt %d", tc.attempt), func(t *testing.T) {
+			for i := 0; i < 100; i++ {
+				if delay := transport.backoff(tc.attempt); delay < tc.expMax/2 || delay >= tc.expMax {
+					t.Fatalf("Expected a delay in [%v, %v), got %v", tc.expMax/2, tc.expMax, delay)
+				}
+			}
+		})
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	testCases := []struct {
+		name     string
+		value    string
+		expOK    bool
+		expDelay time.Duration
+		// expAtLeast accepts any delay between itself and expDelay, for the HTTP dates
+		expAtLeast time.Duration
+	}{
+		{name: "empty", value: ""},
+		{name: "seconds", value: "5", expOK: true, expDelay: 5 * time.Second, expAtLeast: 5 * time.Second},
+		{name: "zero seconds", value: "0", expOK: true},
+		{name: "negative seconds", value: "-1"},
+		{name: "invalid", value: "soon"},
+		{
+			name:       "future date",
+			value:      time.Now().Add(time.Minute).UTC().Format(http.TimeFormat),
+			expOK:      true,
+			expDelay:   time.Minute,
+			expAtLeast: 58 * time.Second,
+		},
+		{name: "past date", value: time.Now().Add(-time.Minute).UTC().Format(http.TimeFormat), expOK: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			delay, ok := parseRetryAfter(tc.value)
+			if ok != tc.expOK {
+				t.Fatalf("Expected ok %t, got %t", tc.expOK, ok)
+			}
+			if delay < tc.expAtLeast || delay > tc.expDelay {
+				t.Fatalf("Expected a delay in [%v, %v], got %v", tc.expAtLeast, tc.expDelay, delay)
+			}
+		})
+	}
+}
+
+func TestIsRetryableRequest(t *testing.T) {
+	newRequest := func(method string, body io.Reader) *http.Request {
+		req, err := http.NewRequest(method, "https://example.com/volumes", body)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		return req
+	}
+	// a body http.NewRequest cannot rewind
+	oneShotBody := func(method string) *http.Request {
+		return newRequest(method, io.MultiReader(strings.NewReader("{}")))
+	}
+
+	testCases := []struct {
+		name string
+		req  *http.Request
+		exp  bool
+	}{
+		{name: "get", req: newRequest(http.MethodGet, nil), exp: true},
+		{name: "head", req: newRequest(http.MethodHead, nil), exp: true},
+		{name: "delete", req: newRequest(http.MethodDelete, nil), exp: true},
+		{name: "put with a rewindable body", req: newRequest(http.MethodPut, bytes.NewReader([]byte("{}"))), exp: true},
+		{name: "put with a one shot body", req: oneShotBody(http.MethodPut)},
+		{name: "post", req: newRequest(http.MethodPost, bytes.NewReader([]byte("{}")))},
+		{name: "patch", req: newRequest(http.MethodPatch, bytes.NewReader([]byte("{}")))},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if retryable := isRetryableRequest(tc.req); retryable != tc.exp {
+				t.Fatalf("Expected retryable %t, got %t", tc.exp, retryable)
+			}
+		})
+	}
+}
+
+// timeoutError is a net.Error timing out.
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+var _ net.Error = timeoutError{}
+
+func TestIsRetryableResponse(t *testing.T) {
+	testCases := []struct {
+		name string
+		code int
+		err  error
+		exp  bool
+	}{
+		{name: "too many requests", code: http.StatusTooManyRequests, exp: true},
+		{name: "internal server error", code: http.StatusInternalServerError, exp: true},
+		{name: "bad gateway", code: http.StatusBadGateway, exp: true},
+		{name: "service unavailable", code: http.StatusServiceUnavailable, exp: true},
+		{name: "gateway timeout", code: http.StatusGatewayTimeout, exp: true},
+		{name: "ok", code: http.StatusOK},
+		{name: "bad request", code: http.StatusBadRequest},
+		{name: "not found", code: http.StatusNotFound},
+		{name: "conflict", code: http.StatusConflict},
+		{name: "timeout", err: &net.OpError{Op: "read", Err: timeoutError{}}, exp: true},
+		{name: "connection reset", err: fmt.Errorf("read: %w", syscall.ECONNRESET), exp: true},
+		{name: "unexpected EOF", err: fmt.Errorf("read: %w", io.ErrUnexpectedEOF), exp: true},
+		{name: "connection refused", err: fmt.Errorf("dial: %w", syscall.ECONNREFUSED)},
+		{name: "other error", err: errors.New("certificate signed by unknown authority")},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var resp *http.Response
+			if tc.err == nil {
+				resp = &http.Response{StatusCode: tc.code}
+			}
+			if retryable := isRetryableResponse(resp, tc.err); retryable != tc.exp {
+				t.Fatalf("Expected retryable %t, got %t", tc.exp, retryable)
+			}
+		})
+	}
+}
+
+func TestRetryTransport(t *testing.T) {
+	testCases := []struct {
+		name        string
+		method      string
+		failures    int
+		retryAfter  string
+		expStatus   int
+		expAttempts int
+	}{
+		{name: "retry until success", method: http.MethodGet, failures: 2, expStatus: http.StatusOK, expAttempts: 3},
+		{name: "give up after max retries", method: http.MethodGet, failures: 5, expStatus: http.StatusServiceUnavailable, expAttempts: 3},
+		{name: "no retry of a post", method: http.MethodPost, failures: 1, expStatus: http.StatusServiceUnavailable, expAttempts: 1},
+		{name: "no retry after max backoff", method: http.MethodGet, failures: 1, retryAfter: "60", expStatus: http.StatusServiceUnavailable, expAttempts: 1},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			attempts := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				attempts++
+				if attempts <= tc.failures {
+					if tc.retryAfter != "" {
+						w.Header().Set("Retry-After", tc.retryAfter)
+					}
+					w.WriteHeader(http.StatusServiceUnavailable)
+					return
+				}
+				w.WriteHeader(http.StatusOK)
+			}))
+			defer server.Close()
+
+			policy := RetryPolicy{MaxRetries: 2, InitialBackoff: time.Millisecond, MaxBackoff: 10 * time.Millisecond}
+			client := &http.Client{Transport: newRetryTransport(http.DefaultTransport, policy, newRateLimiter(policy))}
+			req, err := http.NewRequest(tc.method, server.URL, nil)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			resp, err := client.Do(req)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode != tc.expStatus {
+				t.Fatalf("Expected status %d, got %d", tc.expStatus, resp.StatusCode)
+			}
+			if attempts != tc.expAttempts {
+				t.Fatalf("Expected %d attempts, got %d", tc.expAttempts, attempts)
+			}
+		})
+	}
+}
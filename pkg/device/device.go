@@ -39,6 +39,7 @@ type LinuxDevice interface {
 	CreateDevice() (err error)
 	GetMapper() string
 	Populate(bool) error
+	SetReadOnly() (err error)
 }
 
 // Device struct
@@ -120,6 +121,21 @@ func (d *Device) DeleteDevice() (err error) {
 	return nil
 }
 
+// SetReadOnly: reject the writes to the multipath device and its paths
+func (d *Device) SetReadOnly() (err error) {
+	devices := []string{d.Mapper}
+	for _, slave := range d.Slaves {
+		devices = append(devices, filepath.Join("/dev", slave))
+	}
+	for _, dev := range devices {
+		outBytes, err := exec.Command(blockdevcommand, "--setro", dev).CombinedOutput()
+		if err != nil {
+			return fmt.Errorf("failed to set %s read-only: %s", dev, string(outBytes))
+		}
+	}
+	return nil
+}
+
 // CreateDevice: attach and create linux devices to host
 func (d *Device) CreateDevice() (err error) {
 	if err = scsiHostRescan(); err != nil {
@@ -31,6 +31,7 @@ import (
 const (
 	multipathd           = "multipathd"
 	dmsetupcommand       = "dmsetup"
+	blockdevcommand      = "blockdev"
 	majorMinorPattern    = "(.*)\\((?P<Major>\\d+),\\s+(?P<Minor>\\d+)\\)"
 	orphanPathsPattern   = ".*\\s+(?P<host>\\d+):(?P<channel>\\d+):(?P<target>\\d+):(?P<lun>\\d+).*orphan"
 	faultyPathsPattern   = ".*failed.*(?P<host>\\d+):(?P<channel>\\d+):(?P<target>\\d+):(?P<lun>\\d+).*faulty"
@@ -21,12 +21,39 @@ package driver
 // constants of keys in PublishContext
 const (
 	WWNKey = "wwn"
+	// ReadOnlyKey is set to true when the volume is published read-only, the node then enforces it on the device
+	ReadOnlyKey = "readonly"
 )
 
 // constants of keys in volume parameters
 const (
 	// VolumeTypeKey represents key for volume type
 	VolumeTypeKey = "type"
+	// ShareableKey represents key for the shareable attribute, only accepted as a mutable parameter
+	ShareableKey = "shareable"
+	// VolumePoolKey represents key for the storage pool the volume is created in
+	VolumePoolKey = "volumepool"
+	// AffinityPolicyKey represents key for the affinity policy, either affinity or anti-affinity
+	AffinityPolicyKey = "affinitypolicy"
+	// AffinityVolumeKey represents key for the volume to place the volume next to
+	AffinityVolumeKey = "affinityvolume"
+	// AffinityPVMInstanceKey represents key for the PVM instance to place the volume next to
+	AffinityPVMInstanceKey = "affinitypvminstance"
+	// AntiAffinityVolumesKey represents key for the comma separated volumes to keep the volume away from
+	AntiAffinityVolumesKey = "antiaffinityvolumes"
+	// AntiAffinityPVMInstancesKey represents key for the comma separated PVM instances to keep the volume away from
+	AntiAffinityPVMInstancesKey = "antiaffinitypvminstances"
+)
+
+// constants of keys in volume parameters passed by the external-provisioner
+// when it runs with --extra-create-metadata
+const (
+	// PVCNameKey contains name of the PVC for which is a volume provisioned
+	PVCNameKey = "csi.storage.k8s.io/pvc/name"
+	// PVCNamespaceKey contains namespace of the PVC for which is a volume provisioned
+	PVCNamespaceKey = "csi.storage.k8s.io/pvc/namespace"
+	// PVNameKey contains name of the final PV that will be used for the dynamically provisioned volume
+	PVNameKey = "csi.storage.k8s.io/pv/name"
 )
 
 // constants for default command line flag values
@@ -18,12 +18,19 @@ package driver
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"os"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	csi "github.com/container-storage-interface/spec/lib/go/csi"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
 	gcfg "gopkg.in/gcfg.v1"
 	"k8s.io/klog/v2"
 	"sigs.k8s.io/ibm-powervs-block-csi-driver/pkg/cloud"
@@ -37,6 +44,15 @@ var (
 		{
 			Mode: csi.VolumeCapability_AccessMode_SINGLE_NODE_WRITER,
 		},
+		{
+			Mode: csi.VolumeCapability_AccessMode_SINGLE_NODE_READER_ONLY,
+		},
+		{
+			Mode: csi.VolumeCapability_AccessMode_SINGLE_NODE_SINGLE_WRITER,
+		},
+		{
+			Mode: csi.VolumeCapability_AccessMode_SINGLE_NODE_MULTI_WRITER,
+		},
 		{
 			Mode: csi.VolumeCapability_AccessMode_MULTI_NODE_MULTI_WRITER,
 		},
@@ -59,15 +75,32 @@ var (
 	controllerCaps = []csi.ControllerServiceCapability_RPC_Type{
 		csi.ControllerServiceCapability_RPC_CREATE_DELETE_VOLUME,
 		csi.ControllerServiceCapability_RPC_PUBLISH_UNPUBLISH_VOLUME,
+		csi.ControllerServiceCapability_RPC_PUBLISH_READONLY,
 		csi.ControllerServiceCapability_RPC_EXPAND_VOLUME,
+		csi.ControllerServiceCapability_RPC_CREATE_DELETE_SNAPSHOT,
+		csi.ControllerServiceCapability_RPC_LIST_SNAPSHOTS,
+		csi.ControllerServiceCapability_RPC_CLONE_VOLUME,
+		csi.ControllerServiceCapability_RPC_LIST_VOLUMES,
+		csi.ControllerServiceCapability_RPC_LIST_VOLUMES_PUBLISHED_NODES,
+		csi.ControllerServiceCapability_RPC_GET_VOLUME,
+		csi.ControllerServiceCapability_RPC_VOLUME_CONDITION,
+		csi.ControllerServiceCapability_RPC_GET_CAPACITY,
+		csi.ControllerServiceCapability_RPC_SINGLE_NODE_MULTI_WRITER,
+		csi.ControllerServiceCapability_RPC_MODIFY_VOLUME,
 	}
 )
 
+const (
+	// volumeTransitionTimeout is how long a volume may stay in a transitional state before it is reported as abnormal
+	volumeTransitionTimeout = 10 * time.Minute
+)
+
 // controllerService represents the controller service of CSI driver
 type controllerService struct {
 	cloud         cloud.Cloud
 	driverOptions *Options
 	volumeLocks   *util.VolumeLocks
+	operations    *operationTracker
 }
 
 // Provider holds information from the cloud provider.
@@ -121,7 +154,7 @@ func newControllerService(driverOptions *Options) controllerService {
 		zone = metadata.GetZone()
 	}
 
-	c, err := NewPowerVSCloudFunc(cloudInstanceId, zone, driverOptions.debug)
+	c, err := NewPowerVSCloudFunc(cloudInstanceId, zone, driverOptions.debug, driverOptions.retryPolicy)
 	if err != nil {
 		panic(err)
 	}
@@ -130,6 +163,7 @@ func newControllerService(driverOptions *Options) controllerService {
 		cloud:         c,
 		driverOptions: driverOptions,
 		volumeLocks:   util.NewVolumeLocks(),
+		operations:    newOperationTracker(),
 	}
 }
 
@@ -158,49 +192,209 @@ func (d *controllerService) CreateVolume(ctx context.Context, req *csi.CreateVol
 	if !isValidVolumeCapabilities(volCaps) {
 		modes := util.GetAccessModes(volCaps)
 		stringModes := strings.Join(*modes, ", ")
-		errString := "Volume capabilities " + stringModes + " not supported. Only AccessModes [ReadWriteOnce], [ReadWriteMany], [ReadOnlyMany] supported."
+		errString := "Volume capabilities " + stringModes + " not supported. Only AccessModes [ReadWriteOnce], [ReadWriteOncePod], [ReadWriteMany], [ReadOnlyMany] supported."
 		return nil, status.Error(codes.InvalidArgument, errString)
 	}
 
-	volumeType := cloud.DefaultVolumeType
+	var volumeType string
+	placement := &cloud.DiskOptions{}
+	tags := make(map[string]string)
+	for key, value := range d.driverOptions.extraTags {
+		tags[key] = value
+	}
+	if len(d.driverOptions.kubernetesClusterID) != 0 {
+		tags[cloud.ClusterIDTagKey] = d.driverOptions.kubernetesClusterID
+	}
 
 	for key, value := range req.GetParameters() {
 		switch strings.ToLower(key) {
 		case VolumeTypeKey:
 			volumeType = value
+		case PVCNameKey:
+			tags[cloud.PVCNameTagKey] = value
+		case PVCNamespaceKey:
+			tags[cloud.PVCNamespaceTagKey] = value
+		case PVNameKey:
+			tags[cloud.PVNameTagKey] = value
+		case VolumePoolKey:
+			placement.VolumePool = value
+		case AffinityPolicyKey:
+			placement.AffinityPolicy = value
+		case AffinityVolumeKey:
+			placement.AffinityVolume = value
+		case AffinityPVMInstanceKey:
+			placement.AffinityPVMInstance = value
+		case AntiAffinityVolumesKey:
+			placement.AntiAffinityVolumes = splitList(value)
+		case AntiAffinityPVMInstancesKey:
+			placement.AntiAffinityPVMInstances = splitList(value)
 		default:
 			return nil, status.Errorf(codes.InvalidArgument, "Invalid parameter key %s for CreateVolume", key)
 		}
 	}
+	if err := verifyPlacement(placement); err != nil {
+		return nil, err
+	}
+
+	diskName, err := generateVolumeName(d.driverOptions.volumeNameTemplate, volumeNameData{
+		Name:         volName,
+		ClusterID:    d.driverOptions.kubernetesClusterID,
+		PVCName:      tags[cloud.PVCNameTagKey],
+		PVCNamespace: tags[cloud.PVCNamespaceTagKey],
+		PVName:       tags[cloud.PVNameTagKey],
+	})
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "Could not generate volume name for %q: %v", volName, err)
+	}
+
+	var snapshotID, sourceVolumeID string
+	volumeSource := req.GetVolumeContentSource()
+	if volumeSource != nil {
+		switch source := volumeSource.GetType().(type) {
+		case *csi.VolumeContentSource_Snapshot:
+			snapshotID = source.Snapshot.GetSnapshotId()
+			if len(snapshotID) == 0 {
+				return nil, status.Error(codes.InvalidArgument, "Snapshot ID not provided in volumeContentSource")
+			}
+		case *csi.VolumeContentSource_Volume:
+			sourceVolumeID = source.Volume.GetVolumeId()
+			if len(sourceVolumeID) == 0 {
+				return nil, status.Error(codes.InvalidArgument, "Volume ID not provided in volumeContentSource")
+			}
+		default:
+			return nil, status.Error(codes.InvalidArgument, "Unsupported volumeContentSource type")
+		}
+		// clones and restored volumes are placed next to their source by PowerVS
+		if hasPlacement(placement) {
+			return nil, status.Error(codes.InvalidArgument, "Storage pool and affinity parameters are not supported with a volumeContentSource")
+		}
+	}
+
+	accessibilityRequirements := req.GetAccessibilityRequirements()
+	if len(volumeType) == 0 {
+		volumeType = pickVolumeType(accessibilityRequirements)
+	} else if !isVolumeTypeAccessible(volumeType, accessibilityRequirements) {
+		return nil, status.Errorf(codes.ResourceExhausted, "Volume type %s is not accessible from the requisite topologies", volumeType)
+	}
+
+	// clones and restored volumes inherit the volume type of their source unless one is requested
+	if len(volumeType) == 0 && volumeSource == nil {
+		volumeType = cloud.DefaultVolumeType
+	}
+	if len(volumeType) != 0 {
+		if err := verifyVolumeSize(volumeType, volSizeBytes); err != nil {
+			return nil, err
+		}
+	}
 
 	shareable := isShareableVolume(volCaps)
 	opts := &cloud.DiskOptions{
-		Shareable:     shareable,
-		CapacityBytes: volSizeBytes,
-		VolumeType:    volumeType,
+		Shareable:                shareable,
+		CapacityBytes:            volSizeBytes,
+		VolumeType:               volumeType,
+		VolumePool:               placement.VolumePool,
+		AffinityPolicy:           placement.AffinityPolicy,
+		AffinityVolume:           placement.AffinityVolume,
+		AffinityPVMInstance:      placement.AffinityPVMInstance,
+		AntiAffinityVolumes:      placement.AntiAffinityVolumes,
+		AntiAffinityPVMInstances: placement.AntiAffinityPVMInstances,
+	}
+	if len(tags) != 0 {
+		opts.Tags = tags
 	}
 
-	// check if disk exists
-	// disk exists only if previous createVolume request fails due to any network/tcp error
-	diskDetails, _ := d.cloud.GetDiskByName(volName)
-	if diskDetails != nil {
-		// wait for volume to be available as the volume already exists
-		err := verifyVolumeDetails(opts, diskDetails)
-		if err != nil {
-			return nil, err
+	// The create keeps running when the provisioner times out, its retries wait for the same operation.
+	params := createVolumeParams{opts: *opts, snapshotID: snapshotID, sourceVolumeID: sourceVolumeID}
+	resp, err := d.operations.runCreate(ctx, volName, params, func(ctx context.Context) (interface{}, error) {
+		var err error
+		// check if disk exists
+		// disk exists only if previous createVolume request fails due to any network/tcp error
+		diskDetails, _ := d.cloud.GetDiskByName(ctx, diskName)
+		if diskDetails != nil {
+			// wait for volume to be available as the volume already exists
+			if volumeSource != nil {
+				err = verifyRestoredVolumeDetails(opts, diskDetails)
+			} else {
+				err = verifyVolumeDetails(opts, diskDetails)
+			}
+			if err != nil {
+				return nil, err
+			}
+			err = d.cloud.WaitForVolumeState(ctx, diskDetails.VolumeID, cloud.VolumeAvailableState)
+			if err != nil {
+				return nil, status.Errorf(codes.Internal, "Disk already exists and not in expected state")
+			}
+			// the earlier request may have failed to tag the disk, tagging stays best effort
+			if err = d.cloud.TagDisk(ctx, diskDetails.VolumeID, opts.Tags); err != nil {
+				klog.Warningf("CreateVolume: could not tag disk %s, it is left untagged: %v", diskDetails.VolumeID, err)
+			}
+			return newCreateVolumeResponse(diskDetails, volumeSource), nil
+		}
+
+		var disk *cloud.Disk
+		if len(snapshotID) != 0 {
+			snapshot, getErr := d.cloud.GetSnapshotByID(ctx, snapshotID)
+			if getErr != nil {
+				if errors.Is(getErr, cloud.ErrNotFound) {
+					return nil, status.Errorf(codes.NotFound, "Snapshot %q not found", snapshotID)
+				}
+				return nil, status.Errorf(cloudErrorCode(getErr), "Could not get snapshot ID %q: %v", snapshotID, getErr)
+			}
+			snapshotDisk, getErr := d.cloud.GetDiskByID(ctx, snapshot.SnapshotVolumeID)
+			if getErr != nil {
+				if errors.Is(getErr, cloud.ErrNotFound) {
+					return nil, status.Errorf(codes.NotFound, "Volume of snapshot %q not found", snapshotID)
+				}
+				return nil, status.Errorf(cloudErrorCode(getErr), "Could not get volume of snapshot ID %q: %v", snapshotID, getErr)
+			}
+			// the restored volume is a clone of the snapshot volume and keeps its type
+			if verifyErr := verifyVolumeSize(snapshotDisk.DiskType, volSizeBytes); verifyErr != nil {
+				return nil, verifyErr
+			}
+			if verifyErr := verifySnapshotSourceDetails(opts, snapshotDisk); verifyErr != nil {
+				return nil, verifyErr
+			}
+			disk, err = d.cloud.CreateDiskFromSnapshot(ctx, diskName, snapshotID, opts)
+		} else if len(sourceVolumeID) != 0 {
+			sourceDisk, getErr := d.cloud.GetDiskByID(ctx, sourceVolumeID)
+			if getErr != nil {
+				if errors.Is(getErr, cloud.ErrNotFound) {
+					return nil, status.Errorf(codes.NotFound, "Source volume %q not found", sourceVolumeID)
+				}
+				return nil, status.Errorf(cloudErrorCode(getErr), "Could not get volume with ID %q: %v", sourceVolumeID, getErr)
+			}
+			if len(opts.VolumeType) == 0 {
+				opts.VolumeType = sourceDisk.DiskType
+				if verifyErr := verifyVolumeSize(opts.VolumeType, volSizeBytes); verifyErr != nil {
+					return nil, verifyErr
+				}
+			}
+			if verifyErr := verifyCloneSourceDetails(opts, sourceDisk); verifyErr != nil {
+				return nil, verifyErr
+			}
+			disk, err = d.cloud.CloneDisk(ctx, sourceVolumeID, diskName, opts)
+		} else {
+			disk, err = d.cloud.CreateDisk(ctx, diskName, opts)
 		}
-		err = d.cloud.WaitForVolumeState(diskDetails.VolumeID, cloud.VolumeAvailableState)
 		if err != nil {
-			return nil, status.Errorf(codes.Internal, "Disk already exists and not in expected state")
+			if errors.Is(err, cloud.ErrUnsupportedVolumeType) {
+				return nil, status.Errorf(codes.InvalidArgument, "Could not create volume %q: %v", diskName, err)
+			}
+			return nil, status.Errorf(cloudErrorCode(err), "Could not create volume %q: %v", diskName, err)
 		}
-		return newCreateVolumeResponse(diskDetails), nil
-	}
-
-	disk, err := d.cloud.CreateDisk(volName, opts)
+		return newCreateVolumeResponse(disk, volumeSource), nil
+	})
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "Could not create volume %q: %v", volName, err)
+		return nil, err
 	}
-	return newCreateVolumeResponse(disk), nil
+	return resp.(*csi.CreateVolumeResponse), nil
+}
+
+// createVolumeParams are the parameters a CreateVolume retry has to repeat to join the create in flight.
+type createVolumeParams struct {
+	opts           cloud.DiskOptions
+	snapshotID     string
+	sourceVolumeID string
 }
 
 func (d *controllerService) DeleteVolume(ctx context.Context, req *csi.DeleteVolumeRequest) (*csi.DeleteVolumeResponse, error) {
@@ -215,15 +409,20 @@ func (d *controllerService) DeleteVolume(ctx context.Context, req *csi.DeleteVol
 	}
 	defer d.volumeLocks.Release(volumeID)
 
-	if _, err := d.cloud.GetDiskByID(volumeID); err != nil {
-		if err == cloud.ErrNotFound {
+	if _, err := d.cloud.GetDiskByID(ctx, volumeID); err != nil {
+		if errors.Is(err, cloud.ErrNotFound) {
 			klog.V(4).Info("DeleteVolume: volume not found, returning with success")
 			return &csi.DeleteVolumeResponse{}, nil
 		}
 	}
 
-	if _, err := d.cloud.DeleteDisk(volumeID); err != nil {
-		return nil, status.Errorf(codes.Internal, "Could not delete volume ID %q: %v", volumeID, err)
+	if _, err := d.cloud.DeleteDisk(ctx, volumeID); err != nil {
+		// a retried DELETE that already went through finds the volume gone
+		if errors.Is(err, cloud.ErrNotFound) {
+			klog.V(4).Info("DeleteVolume: volume already deleted, returning with success")
+			return &csi.DeleteVolumeResponse{}, nil
+		}
+		return nil, status.Errorf(cloudErrorCode(err), "Could not delete volume ID %q: %v", volumeID, err)
 	}
 
 	return &csi.DeleteVolumeResponse{}, nil
@@ -255,43 +454,87 @@ func (d *controllerService) ControllerPublishVolume(ctx context.Context, req *cs
 	if !isValidVolumeCapabilities(caps) {
 		modes := util.GetAccessModes(caps)
 		stringModes := strings.Join(*modes, ", ")
-		errString := "Volume capabilities " + stringModes + " not supported. Only AccessModes [ReadWriteOnce], [ReadWriteMany], [ReadOnlyMany] supported."
+		errString := "Volume capabilities " + stringModes + " not supported. Only AccessModes [ReadWriteOnce], [ReadWriteOncePod], [ReadWriteMany], [ReadOnlyMany] supported."
 		return nil, status.Error(codes.InvalidArgument, errString)
 	}
 
-	if _, err := d.cloud.GetPVMInstanceByID(nodeID); err != nil {
-		return nil, status.Errorf(codes.NotFound, "Instance %q not found, err: %v", nodeID, err)
+	if _, err := d.cloud.GetPVMInstanceByID(ctx, nodeID); err != nil {
+		if errors.Is(err, cloud.ErrNotFound) {
+			return nil, status.Errorf(codes.NotFound, "Instance %q not found, err: %v", nodeID, err)
+		}
+		return nil, status.Errorf(cloudErrorCode(err), "Could not get instance %q: %v", nodeID, err)
 	}
 
-	disk, err := d.cloud.GetDiskByID(volumeID)
+	disk, err := d.cloud.GetDiskByID(ctx, volumeID)
 
 	if err != nil {
-		if err == cloud.ErrNotFound {
+		if errors.Is(err, cloud.ErrNotFound) {
 			return nil, status.Error(codes.NotFound, "Volume not found")
 		}
-		return nil, status.Errorf(codes.Internal, "Could not get volume with ID %q: %v", volumeID, err)
+		return nil, status.Errorf(cloudErrorCode(err), "Could not get volume with ID %q: %v", volumeID, err)
 	}
 
 	pvInfo := map[string]string{WWNKey: disk.WWN}
-
-	attached, _ := d.cloud.IsAttached(volumeID, nodeID)
-	if attached {
-		klog.V(5).Infof("ControllerPublishVolume: volume %s already attached to node %s, returning success", volumeID, nodeID)
-		return &csi.ControllerPublishVolumeResponse{PublishContext: pvInfo}, nil
+	// PowerVS attaches the volumes read-write, the node makes the device read-only.
+	readOnly := req.GetReadonly()
+	switch volCap.GetAccessMode().GetMode() {
+	case csi.VolumeCapability_AccessMode_SINGLE_NODE_READER_ONLY, csi.VolumeCapability_AccessMode_MULTI_NODE_READER_ONLY:
+		readOnly = true
+	}
+	if readOnly {
+		pvInfo[ReadOnlyKey] = "true"
 	}
 
-	err = d.cloud.AttachDisk(volumeID, nodeID)
-	if err != nil {
-		if err == cloud.ErrAlreadyExists {
-			return nil, status.Error(codes.AlreadyExists, err.Error())
+	_, err = d.operations.run(ctx, volumeID, "attach/"+nodeID, func(ctx context.Context) (interface{}, error) {
+		attached, err := d.cloud.IsAttached(ctx, volumeID, nodeID)
+		if err != nil {
+			return nil, status.Errorf(cloudErrorCode(err), "Could not check if volume %q is attached to node %q: %v", volumeID, nodeID, err)
+		}
+		if attached {
+			klog.V(5).Infof("ControllerPublishVolume: volume %s already attached to node %s, returning success", volumeID, nodeID)
+			return nil, nil
 		}
-		return nil, status.Errorf(codes.Internal, "Could not attach volume %q to node %q: %v", volumeID, nodeID, err)
+
+		// The limit check and the attach must not interleave with the attach of another volume to the node.
+		unlock, err := d.operations.lockInstance(ctx, nodeID)
+		if err != nil {
+			return nil, status.Errorf(codes.Aborted, "Timed out waiting for the attaches to node %q: %v", nodeID, err)
+		}
+		defer unlock()
+
+		if err := d.checkAttachLimit(ctx, nodeID); err != nil {
+			return nil, err
+		}
+
+		if err := d.cloud.AttachDisk(ctx, volumeID, nodeID); err != nil {
+			// volumes attached out of band may have reached the limit since the check
+			if limitErr := d.checkAttachLimit(ctx, nodeID); status.Code(limitErr) == codes.ResourceExhausted {
+				return nil, limitErr
+			}
+			return nil, status.Errorf(cloudErrorCode(err), "Could not attach volume %q to node %q: %v", volumeID, nodeID, err)
+		}
+		klog.V(5).Infof("ControllerPublishVolume: volume %s attached to node %s", volumeID, nodeID)
+		return nil, nil
+	})
+	if err != nil {
+		return nil, err
 	}
-	klog.V(5).Infof("ControllerPublishVolume: volume %s attached to node %s", volumeID, nodeID)
 
 	return &csi.ControllerPublishVolumeResponse{PublishContext: pvInfo}, nil
 }
 
+// checkAttachLimit returns ResourceExhausted when the node has as many volumes attached as PowerVS allows.
+func (d *controllerService) checkAttachLimit(ctx context.Context, nodeID string) error {
+	attachedDisks, err := d.cloud.ListPVMInstanceDisks(ctx, nodeID)
+	if err != nil {
+		return status.Errorf(cloudErrorCode(err), "Could not list the volumes attached to node %q: %v", nodeID, err)
+	}
+	if int64(len(attachedDisks)) >= cloud.MaxVolumesPerInstance {
+		return status.Errorf(codes.ResourceExhausted, "Node %q already has %d volumes attached, the PowerVS limit", nodeID, len(attachedDisks))
+	}
+	return nil
+}
+
 func (d *controllerService) ControllerUnpublishVolume(ctx context.Context, req *csi.ControllerUnpublishVolumeRequest) (*csi.ControllerUnpublishVolumeResponse, error) {
 	klog.V(4).Infof("ControllerUnpublishVolume: called with args %+v", *req)
 	volumeID := req.GetVolumeId()
@@ -309,22 +552,37 @@ func (d *controllerService) ControllerUnpublishVolume(ctx context.Context, req *
 		return nil, status.Error(codes.InvalidArgument, "Node ID not provided")
 	}
 
-	if _, err := d.cloud.GetDiskByID(volumeID); err != nil {
-		if err == cloud.ErrNotFound {
+	if _, err := d.cloud.GetDiskByID(ctx, volumeID); err != nil {
+		if errors.Is(err, cloud.ErrNotFound) {
 			klog.V(4).Info("ControllerUnpublishVolume: volume not found, returning with success")
 			return &csi.ControllerUnpublishVolumeResponse{}, nil
 		}
 	}
 
-	if attached, err := d.cloud.IsAttached(volumeID, nodeID); !attached {
-		klog.V(4).Infof("ControllerUnpublishVolume: volume %s is not attached to %s, err: %v, returning with success", volumeID, nodeID, err)
-		return &csi.ControllerUnpublishVolumeResponse{}, nil
-	}
+	_, err := d.operations.run(ctx, volumeID, "detach/"+nodeID, func(ctx context.Context) (interface{}, error) {
+		attached, err := d.cloud.IsAttached(ctx, volumeID, nodeID)
+		if err != nil {
+			return nil, status.Errorf(cloudErrorCode(err), "Could not check if volume %q is attached to node %q: %v", volumeID, nodeID, err)
+		}
+		if !attached {
+			klog.V(4).Infof("ControllerUnpublishVolume: volume %s is not attached to %s, returning with success", volumeID, nodeID)
+			return nil, nil
+		}
 
-	if err := d.cloud.DetachDisk(volumeID, nodeID); err != nil {
-		return nil, status.Errorf(codes.Internal, "Could not detach volume %q from node %q: %v", volumeID, nodeID, err)
+		if err := d.cloud.DetachDisk(ctx, volumeID, nodeID); err != nil {
+			// a retried DELETE that already went through finds the volume detached
+			if errors.Is(err, cloud.ErrNotFound) {
+				klog.V(4).Infof("ControllerUnpublishVolume: volume %s already detached from %s, returning with success", volumeID, nodeID)
+				return nil, nil
+			}
+			return nil, status.Errorf(cloudErrorCode(err), "Could not detach volume %q from node %q: %v", volumeID, nodeID, err)
+		}
+		klog.V(5).Infof("ControllerUnpublishVolume: volume %s detached from node %s", volumeID, nodeID)
+		return nil, nil
+	})
+	if err != nil {
+		return nil, err
 	}
-	klog.V(5).Infof("ControllerUnpublishVolume: volume %s detached from node %s", volumeID, nodeID)
 
 	return &csi.ControllerUnpublishVolumeResponse{}, nil
 }
@@ -347,12 +605,75 @@ func (d *controllerService) ControllerGetCapabilities(ctx context.Context, req *
 
 func (d *controllerService) GetCapacity(ctx context.Context, req *csi.GetCapacityRequest) (*csi.GetCapacityResponse, error) {
 	klog.V(4).Infof("GetCapacity: called with args %+v", *req)
-	return nil, status.Error(codes.Unimplemented, "")
+
+	volCaps := req.GetVolumeCapabilities()
+	if len(volCaps) > 0 && !isValidVolumeCapabilities(volCaps) {
+		// No volume with these capabilities can be created, whatever the pools hold.
+		return &csi.GetCapacityResponse{}, nil
+	}
+
+	var volumeType string
+	for key, value := range req.GetParameters() {
+		if strings.ToLower(key) == VolumeTypeKey {
+			volumeType = value
+		}
+	}
+
+	if segments := req.GetAccessibleTopology().GetSegments(); segments != nil {
+		if diskType, ok := segments[DiskTypeKey]; ok {
+			if len(volumeType) != 0 && !strings.EqualFold(volumeType, diskType) {
+				// The requested type cannot be provisioned in this segment.
+				return &csi.GetCapacityResponse{}, nil
+			}
+			volumeType = diskType
+		}
+	}
+
+	capacity, err := d.cloud.GetStorageCapacity(ctx, volumeType)
+	if err != nil {
+		return nil, status.Errorf(cloudErrorCode(err), "Could not get storage capacity for volume type %q: %v", volumeType, err)
+	}
+
+	return &csi.GetCapacityResponse{
+		AvailableCapacity: util.GiBToBytes(capacity.AvailableCapacityGiB),
+		MaximumVolumeSize: wrapperspb.Int64(util.GiBToBytes(capacity.MaximumVolumeSizeGiB)),
+	}, nil
 }
 
 func (d *controllerService) ListVolumes(ctx context.Context, req *csi.ListVolumesRequest) (*csi.ListVolumesResponse, error) {
 	klog.V(4).Infof("ListVolumes: called with args %+v", *req)
-	return nil, status.Error(codes.Unimplemented, "")
+	disks, err := d.cloud.ListDisks(ctx)
+	if err != nil {
+		return nil, status.Errorf(cloudErrorCode(err), "Could not list volumes: %v", err)
+	}
+	// Keep a stable order so that the starting token addresses the same entry between calls.
+	sort.Slice(disks, func(i, j int) bool {
+		return disks[i].VolumeID < disks[j].VolumeID
+	})
+
+	start, end, nextToken, err := paginate(len(disks), req.GetStartingToken(), req.GetMaxEntries())
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []*csi.ListVolumesResponse_Entry
+	for _, disk := range disks[start:end] {
+		entries = append(entries, &csi.ListVolumesResponse_Entry{
+			Volume: &csi.Volume{
+				VolumeId:      disk.VolumeID,
+				CapacityBytes: util.GiBToBytes(disk.CapacityGiB),
+			},
+			Status: &csi.ListVolumesResponse_VolumeStatus{
+				PublishedNodeIds: disk.PVMInstanceIDs,
+				VolumeCondition:  newVolumeCondition(disk),
+			},
+		})
+	}
+
+	return &csi.ListVolumesResponse{
+		Entries:   entries,
+		NextToken: nextToken,
+	}, nil
 }
 
 func (d *controllerService) ValidateVolumeCapabilities(ctx context.Context, req *csi.ValidateVolumeCapabilitiesRequest) (*csi.ValidateVolumeCapabilitiesResponse, error) {
@@ -367,11 +688,11 @@ func (d *controllerService) ValidateVolumeCapabilities(ctx context.Context, req
 		return nil, status.Error(codes.InvalidArgument, "Volume capabilities not provided")
 	}
 
-	if _, err := d.cloud.GetDiskByID(volumeID); err != nil {
-		if err == cloud.ErrNotFound {
+	if _, err := d.cloud.GetDiskByID(ctx, volumeID); err != nil {
+		if errors.Is(err, cloud.ErrNotFound) {
 			return nil, status.Error(codes.NotFound, "Volume not found")
 		}
-		return nil, status.Errorf(codes.Internal, "Could not get volume with ID %q: %v", volumeID, err)
+		return nil, status.Errorf(cloudErrorCode(err), "Could not get volume with ID %q: %v", volumeID, err)
 	}
 
 	var confirmed *csi.ValidateVolumeCapabilitiesResponse_Confirmed
@@ -406,9 +727,9 @@ func (d *controllerService) ControllerExpandVolume(ctx context.Context, req *csi
 		return nil, status.Error(codes.InvalidArgument, "After round-up, volume size exceeds the limit specified")
 	}
 
-	actualSizeGiB, err := d.cloud.ResizeDisk(volumeID, newSize)
+	actualSizeGiB, err := d.cloud.ResizeDisk(ctx, volumeID, newSize)
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "Could not resize volume %q: %v", volumeID, err)
+		return nil, status.Errorf(cloudErrorCode(err), "Could not resize volume %q: %v", volumeID, err)
 	}
 
 	return &csi.ControllerExpandVolumeResponse{
@@ -417,9 +738,108 @@ func (d *controllerService) ControllerExpandVolume(ctx context.Context, req *csi
 	}, nil
 }
 
+// ControllerModifyVolume applies the mutable parameters of a VolumeAttributesClass to an existing volume.
+func (d *controllerService) ControllerModifyVolume(ctx context.Context, req *csi.ControllerModifyVolumeRequest) (*csi.ControllerModifyVolumeResponse, error) {
+	klog.V(4).Infof("ControllerModifyVolume: called with args %+v", req)
+	volumeID := req.GetVolumeId()
+	if len(volumeID) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "Volume ID not provided")
+	}
+
+	modifyOptions := &cloud.ModifyDiskOptions{}
+	for key, value := range req.GetMutableParameters() {
+		switch strings.ToLower(key) {
+		case VolumeTypeKey:
+			modifyOptions.VolumeType = value
+		case ShareableKey:
+			shareable, err := strconv.ParseBool(value)
+			if err != nil {
+				return nil, status.Errorf(codes.InvalidArgument, "Invalid value %q for parameter %s", value, key)
+			}
+			modifyOptions.Shareable = &shareable
+		default:
+			return nil, status.Errorf(codes.InvalidArgument, "Parameter %s cannot be modified", key)
+		}
+	}
+
+	if acquired := d.volumeLocks.TryAcquire(volumeID); !acquired {
+		return nil, status.Errorf(codes.Aborted, util.VolumeOperationAlreadyExistsFmt, volumeID)
+	}
+	defer d.volumeLocks.Release(volumeID)
+
+	disk, err := d.cloud.GetDiskByID(ctx, volumeID)
+	if err != nil {
+		if errors.Is(err, cloud.ErrNotFound) {
+			return nil, status.Errorf(codes.NotFound, "Volume %q not found", volumeID)
+		}
+		return nil, status.Errorf(cloudErrorCode(err), "Could not get volume with ID %q: %v", volumeID, err)
+	}
+
+	if len(modifyOptions.VolumeType) != 0 {
+		if err := verifyVolumeSize(modifyOptions.VolumeType, util.GiBToBytes(disk.CapacityGiB)); err != nil {
+			return nil, err
+		}
+	}
+	if modifyOptions.Shareable != nil && !*modifyOptions.Shareable && len(disk.PVMInstanceIDs) > 1 {
+		return nil, status.Errorf(codes.FailedPrecondition, "Volume %q is attached to %d nodes and cannot be made non-shareable", volumeID, len(disk.PVMInstanceIDs))
+	}
+
+	if _, err := d.cloud.ModifyDisk(ctx, volumeID, modifyOptions); err != nil {
+		if errors.Is(err, cloud.ErrUnsupportedVolumeType) {
+			return nil, status.Errorf(codes.InvalidArgument, "Could not modify volume %q: %v", volumeID, err)
+		}
+		return nil, status.Errorf(cloudErrorCode(err), "Could not modify volume %q: %v", volumeID, err)
+	}
+	return &csi.ControllerModifyVolumeResponse{}, nil
+}
+
 func (d *controllerService) ControllerGetVolume(ctx context.Context, req *csi.ControllerGetVolumeRequest) (*csi.ControllerGetVolumeResponse, error) {
 	klog.V(4).Infof("ControllerGetVolume: called with args %+v", *req)
-	return nil, status.Error(codes.Unimplemented, "")
+	volumeID := req.GetVolumeId()
+	if len(volumeID) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "Volume ID not provided")
+	}
+
+	disk, err := d.cloud.GetDiskByID(ctx, volumeID)
+	if err != nil {
+		if errors.Is(err, cloud.ErrNotFound) {
+			return nil, status.Error(codes.NotFound, "Volume not found")
+		}
+		return nil, status.Errorf(cloudErrorCode(err), "Could not get volume with ID %q: %v", volumeID, err)
+	}
+
+	return &csi.ControllerGetVolumeResponse{
+		Volume: &csi.Volume{
+			VolumeId:      disk.VolumeID,
+			CapacityBytes: util.GiBToBytes(disk.CapacityGiB),
+		},
+		Status: &csi.ControllerGetVolumeResponse_VolumeStatus{
+			PublishedNodeIds: disk.PVMInstanceIDs,
+			VolumeCondition:  newVolumeCondition(disk),
+		},
+	}, nil
+}
+
+// newVolumeCondition reports the health of a volume from the state PowerVS has for it.
+func newVolumeCondition(disk *cloud.Disk) *csi.VolumeCondition {
+	abnormal := func(message string) *csi.VolumeCondition {
+		return &csi.VolumeCondition{Abnormal: true, Message: message}
+	}
+
+	switch {
+	case disk.OutOfBandDeleted:
+		return abnormal("Volume is missing on the storage controller")
+	case disk.State == cloud.VolumeErrorState:
+		return abnormal("Volume is in error state")
+	case disk.State == cloud.VolumeResizingState:
+		return abnormal("Volume is being resized")
+	case disk.State == cloud.VolumeAvailableState, disk.State == cloud.VolumeInUseState:
+		return &csi.VolumeCondition{Abnormal: false, Message: fmt.Sprintf("Volume is %s", disk.State)}
+	case time.Since(disk.LastUpdateTime) > volumeTransitionTimeout:
+		return abnormal(fmt.Sprintf("Volume is stuck in %q state since %s", disk.State, disk.LastUpdateTime.Format(time.RFC3339)))
+	default:
+		return &csi.VolumeCondition{Abnormal: false, Message: fmt.Sprintf("Volume is in transitional state %q", disk.State)}
+	}
 }
 
 func isValidVolumeCapabilities(volCaps []*csi.VolumeCapability) bool {
@@ -462,22 +882,187 @@ func isShareableVolume(volCaps []*csi.VolumeCapability) bool {
 
 func (d *controllerService) CreateSnapshot(ctx context.Context, req *csi.CreateSnapshotRequest) (*csi.CreateSnapshotResponse, error) {
 	klog.V(4).Infof("CreateSnapshot: called with args %+v", req)
-	return nil, status.Error(codes.Unimplemented, "")
+	snapshotName := req.GetName()
+	if len(snapshotName) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "Snapshot name not provided")
+	}
+
+	volumeID := req.GetSourceVolumeId()
+	if len(volumeID) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "Snapshot volume source ID not provided")
+	}
+
+	if acquired := d.volumeLocks.TryAcquire(snapshotName); !acquired {
+		return nil, status.Errorf(codes.Aborted, util.VolumeOperationAlreadyExistsFmt, snapshotName)
+	}
+	defer d.volumeLocks.Release(snapshotName)
+
+	disk, err := d.cloud.GetDiskByID(ctx, volumeID)
+	if err != nil {
+		if errors.Is(err, cloud.ErrNotFound) {
+			return nil, status.Error(codes.NotFound, "Source volume not found")
+		}
+		return nil, status.Errorf(cloudErrorCode(err), "Could not get volume with ID %q: %v", volumeID, err)
+	}
+
+	// check if snapshot exists
+	// snapshot exists only if previous createSnapshot request fails due to any network/tcp error
+	snapshot, err := d.cloud.GetSnapshotByName(ctx, snapshotName)
+	if err != nil && !errors.Is(err, cloud.ErrNotFound) {
+		return nil, status.Errorf(cloudErrorCode(err), "Could not get snapshot %q: %v", snapshotName, err)
+	}
+	if snapshot != nil {
+		if snapshot.SourceVolumeID != volumeID {
+			return nil, status.Errorf(codes.AlreadyExists, "Snapshot %q already exists for a different source volume %q", snapshotName, snapshot.SourceVolumeID)
+		}
+	} else {
+		snapshot, err = d.cloud.CreateSnapshot(ctx, volumeID, snapshotName)
+		if err != nil {
+			return nil, status.Errorf(cloudErrorCode(err), "Could not create snapshot %q: %v", snapshotName, err)
+		}
+	}
+
+	if !snapshot.ReadyToUse {
+		err = d.cloud.WaitForSnapshotState(ctx, snapshot.SnapshotID, cloud.SnapshotAvailableState)
+		if err != nil {
+			return nil, status.Errorf(cloudErrorCode(err), "Snapshot %q not in expected state: %v", snapshotName, err)
+		}
+		snapshot.ReadyToUse = true
+	}
+
+	return &csi.CreateSnapshotResponse{
+		Snapshot: newCSISnapshot(snapshot, disk.CapacityGiB),
+	}, nil
 }
 
 func (d *controllerService) DeleteSnapshot(ctx context.Context, req *csi.DeleteSnapshotRequest) (*csi.DeleteSnapshotResponse, error) {
 	klog.V(4).Infof("DeleteSnapshot: called with args %+v", req)
-	return nil, status.Error(codes.Unimplemented, "")
+	snapshotID := req.GetSnapshotId()
+	if len(snapshotID) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "Snapshot ID not provided")
+	}
+
+	if acquired := d.volumeLocks.TryAcquire(snapshotID); !acquired {
+		return nil, status.Errorf(codes.Aborted, util.VolumeOperationAlreadyExistsFmt, snapshotID)
+	}
+	defer d.volumeLocks.Release(snapshotID)
+
+	if _, err := d.cloud.GetSnapshotByID(ctx, snapshotID); err != nil {
+		if errors.Is(err, cloud.ErrNotFound) {
+			klog.V(4).Info("DeleteSnapshot: snapshot not found, returning with success")
+			return &csi.DeleteSnapshotResponse{}, nil
+		}
+		return nil, status.Errorf(cloudErrorCode(err), "Could not get snapshot ID %q: %v", snapshotID, err)
+	}
+
+	if err := d.cloud.DeleteSnapshot(ctx, snapshotID); err != nil {
+		// a retried DELETE that already went through finds the snapshot gone
+		if errors.Is(err, cloud.ErrNotFound) {
+			klog.V(4).Info("DeleteSnapshot: snapshot already deleted, returning with success")
+			return &csi.DeleteSnapshotResponse{}, nil
+		}
+		return nil, status.Errorf(cloudErrorCode(err), "Could not delete snapshot ID %q: %v", snapshotID, err)
+	}
+
+	return &csi.DeleteSnapshotResponse{}, nil
 }
 
 func (d *controllerService) ListSnapshots(ctx context.Context, req *csi.ListSnapshotsRequest) (*csi.ListSnapshotsResponse, error) {
 	klog.V(4).Infof("ListSnapshots: called with args %+v", req)
-	return nil, status.Error(codes.Unimplemented, "")
+	var snapshots []*cloud.Snapshot
+
+	if snapshotID := req.GetSnapshotId(); len(snapshotID) != 0 {
+		snapshot, err := d.cloud.GetSnapshotByID(ctx, snapshotID)
+		if err != nil {
+			if errors.Is(err, cloud.ErrNotFound) {
+				klog.V(4).Info("ListSnapshots: snapshot not found, returning with success")
+				return &csi.ListSnapshotsResponse{}, nil
+			}
+			return nil, status.Errorf(cloudErrorCode(err), "Could not get snapshot ID %q: %v", snapshotID, err)
+		}
+		snapshots = append(snapshots, snapshot)
+	} else {
+		var err error
+		snapshots, err = d.cloud.ListSnapshots(ctx)
+		if err != nil {
+			return nil, status.Errorf(cloudErrorCode(err), "Could not list snapshots: %v", err)
+		}
+	}
+
+	sourceVolumeID := req.GetSourceVolumeId()
+	var filtered []*cloud.Snapshot
+	for _, s := range snapshots {
+		if len(sourceVolumeID) != 0 && s.SourceVolumeID != sourceVolumeID {
+			continue
+		}
+		filtered = append(filtered, s)
+	}
+	// Keep a stable order so that the starting token addresses the same entry between calls.
+	sort.Slice(filtered, func(i, j int) bool {
+		return filtered[i].SnapshotID < filtered[j].SnapshotID
+	})
+
+	start, end, nextToken, err := paginate(len(filtered), req.GetStartingToken(), req.GetMaxEntries())
+	if err != nil {
+		return nil, err
+	}
+
+	// Cache the source volume sizes, several snapshots usually share the same source.
+	sizes := map[string]int64{}
+	var entries []*csi.ListSnapshotsResponse_Entry
+	for _, s := range filtered[start:end] {
+		capacityGiB, ok := sizes[s.SourceVolumeID]
+		if !ok {
+			if disk, err := d.cloud.GetDiskByID(ctx, s.SourceVolumeID); err == nil {
+				capacityGiB = disk.CapacityGiB
+			}
+			sizes[s.SourceVolumeID] = capacityGiB
+		}
+		entries = append(entries, &csi.ListSnapshotsResponse_Entry{
+			Snapshot: newCSISnapshot(s, capacityGiB),
+		})
+	}
+
+	return &csi.ListSnapshotsResponse{
+		Entries:   entries,
+		NextToken: nextToken,
+	}, nil
+}
+
+// paginate returns the bounds of the page selected by startingToken and maxEntries
+// out of total entries, along with the token of the next page.
+func paginate(total int, startingToken string, maxEntries int32) (start, end int, nextToken string, err error) {
+	if maxEntries < 0 {
+		return 0, 0, "", status.Errorf(codes.InvalidArgument, "Invalid max entries %d", maxEntries)
+	}
+	if len(startingToken) != 0 {
+		start, err = strconv.Atoi(startingToken)
+		if err != nil || start < 0 || start > total {
+			return 0, 0, "", status.Errorf(codes.Aborted, "Invalid starting token %q", startingToken)
+		}
+	}
+	end = total
+	if maxEntries > 0 && start+int(maxEntries) < total {
+		end = start + int(maxEntries)
+		nextToken = strconv.Itoa(end)
+	}
+	return start, end, nextToken, nil
 }
 
-func newCreateVolumeResponse(disk *cloud.Disk) *csi.CreateVolumeResponse {
-	var src *csi.VolumeContentSource
+func newCSISnapshot(snapshot *cloud.Snapshot, capacityGiB int64) *csi.Snapshot {
+	return &csi.Snapshot{
+		SnapshotId:     snapshot.SnapshotID,
+		SourceVolumeId: snapshot.SourceVolumeID,
+		SizeBytes:      util.GiBToBytes(capacityGiB),
+		CreationTime:   timestamppb.New(snapshot.CreationTime),
+		ReadyToUse:     snapshot.ReadyToUse,
+	}
+}
 
+// newCreateVolumeResponse returns the created volume without an accessible topology. The disk type is
+// only used to pick the volume type: ControllerModifyVolume changes it in place, which would leave the
+// node affinity of the PV on the former type.
+func newCreateVolumeResponse(disk *cloud.Disk, src *csi.VolumeContentSource) *csi.CreateVolumeResponse {
 	return &csi.CreateVolumeResponse{
 		Volume: &csi.Volume{
 			VolumeId:      disk.VolumeID,
@@ -488,6 +1073,57 @@ func newCreateVolumeResponse(disk *cloud.Disk) *csi.CreateVolumeResponse {
 	}
 }
 
+// pickVolumeType returns the disk type of the first preferred topology, falling back
+// to the requisite topologies. It returns an empty string when no topology carries one.
+func pickVolumeType(requirement *csi.TopologyRequirement) string {
+	for _, topology := range requirement.GetPreferred() {
+		if diskType, ok := topology.GetSegments()[DiskTypeKey]; ok {
+			return diskType
+		}
+	}
+	for _, topology := range requirement.GetRequisite() {
+		if diskType, ok := topology.GetSegments()[DiskTypeKey]; ok {
+			return diskType
+		}
+	}
+	return ""
+}
+
+// isVolumeTypeAccessible checks that a volume of the given type can be used from one of
+// the requisite topologies. Requisite topologies without a disk type accept any type.
+func isVolumeTypeAccessible(volumeType string, requirement *csi.TopologyRequirement) bool {
+	requisite := requirement.GetRequisite()
+	if len(requisite) == 0 {
+		return true
+	}
+	for _, topology := range requisite {
+		diskType, ok := topology.GetSegments()[DiskTypeKey]
+		if !ok || strings.EqualFold(diskType, volumeType) {
+			return true
+		}
+	}
+	return false
+}
+
+// cloudErrorCode returns the gRPC code of an error of the cloud provider, Internal when it is not one of
+// the cloud sentinel errors. The deletes and the detach take ErrNotFound as a success before they get
+// here, a retried request finding the resource already gone.
+func cloudErrorCode(err error) codes.Code {
+	switch {
+	case errors.Is(err, cloud.ErrNotFound):
+		return codes.NotFound
+	case errors.Is(err, cloud.ErrConflict), errors.Is(err, cloud.ErrInvalidState):
+		return codes.FailedPrecondition
+	case errors.Is(err, cloud.ErrQuotaExceeded):
+		return codes.ResourceExhausted
+	case errors.Is(err, cloud.ErrThrottled):
+		return codes.Unavailable
+	case errors.Is(err, cloud.ErrUnauthorized):
+		return codes.PermissionDenied
+	}
+	return codes.Internal
+}
+
 func getVolSizeBytes(req *csi.CreateVolumeRequest) (int64, error) {
 	var volSizeBytes int64
 	capRange := req.GetCapacityRange()
@@ -503,16 +1139,120 @@ func getVolSizeBytes(req *csi.CreateVolumeRequest) (int64, error) {
 	return volSizeBytes, nil
 }
 
+// hasPlacement returns true when the storage pool or the affinity of the volume is requested.
+func hasPlacement(placement *cloud.DiskOptions) bool {
+	return len(placement.VolumePool) != 0 || len(placement.AffinityPolicy) != 0 ||
+		len(placement.AffinityVolume) != 0 || len(placement.AffinityPVMInstance) != 0 ||
+		len(placement.AntiAffinityVolumes) != 0 || len(placement.AntiAffinityPVMInstances) != 0
+}
+
+// verifyPlacement checks that the affinity parameters form a policy PowerVS accepts.
+func verifyPlacement(placement *cloud.DiskOptions) error {
+	hasAffinity := len(placement.AffinityVolume) != 0 || len(placement.AffinityPVMInstance) != 0
+	hasAntiAffinity := len(placement.AntiAffinityVolumes) != 0 || len(placement.AntiAffinityPVMInstances) != 0
+	switch placement.AffinityPolicy {
+	case "":
+		if hasAffinity || hasAntiAffinity {
+			return status.Errorf(codes.InvalidArgument, "Affinity targets require the %s parameter", AffinityPolicyKey)
+		}
+	case cloud.AffinityPolicyAffinity:
+		if !hasAffinity || hasAntiAffinity {
+			return status.Errorf(codes.InvalidArgument, "Affinity policy %s requires one of %s or %s and no anti-affinity targets", placement.AffinityPolicy, AffinityVolumeKey, AffinityPVMInstanceKey)
+		}
+		if len(placement.AffinityVolume) != 0 && len(placement.AffinityPVMInstance) != 0 {
+			return status.Errorf(codes.InvalidArgument, "Affinity policy %s accepts only one of %s or %s", placement.AffinityPolicy, AffinityVolumeKey, AffinityPVMInstanceKey)
+		}
+	case cloud.AffinityPolicyAntiAffinity:
+		if !hasAntiAffinity || hasAffinity {
+			return status.Errorf(codes.InvalidArgument, "Affinity policy %s requires one of %s or %s and no affinity targets", placement.AffinityPolicy, AntiAffinityVolumesKey, AntiAffinityPVMInstancesKey)
+		}
+	default:
+		return status.Errorf(codes.InvalidArgument, "Invalid affinity policy %s, supported policies are %s and %s", placement.AffinityPolicy, cloud.AffinityPolicyAffinity, cloud.AffinityPolicyAntiAffinity)
+	}
+	return nil
+}
+
+// splitList splits a comma separated parameter value, dropping empty items.
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); len(item) != 0 {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// verifyVolumeSize checks that the size fits the limits of the volume type. Whether the workspace offers
+// the volume type is checked by the cloud provider.
+func verifyVolumeSize(volumeType string, volSizeBytes int64) error {
+	limits, ok := cloud.VolumeTypeSizeLimits[volumeType]
+	if !ok {
+		return nil
+	}
+	capacityGiB := util.BytesToGiB(volSizeBytes)
+	if limits.MinGiB > 0 && capacityGiB < limits.MinGiB {
+		return status.Errorf(codes.InvalidArgument, "Volume size %dGiB is below the minimum of %dGiB for volume type %s", capacityGiB, limits.MinGiB, volumeType)
+	}
+	if limits.MaxGiB > 0 && capacityGiB > limits.MaxGiB {
+		return status.Errorf(codes.InvalidArgument, "Volume size %dGiB exceeds the maximum of %dGiB for volume type %s", capacityGiB, limits.MaxGiB, volumeType)
+	}
+	return nil
+}
+
+// verifyVolumeDetails checks an existing volume against the request, a volume with the same name and
+// other parameters is reported as AlreadyExists.
 func verifyVolumeDetails(payload *cloud.DiskOptions, diskDetails *cloud.Disk) error {
 	if payload.Shareable != diskDetails.Shareable {
-		return status.Errorf(codes.Internal, "shareable in payload and shareable in disk details don't match")
+		return status.Errorf(codes.AlreadyExists, "shareable in payload and shareable in disk details don't match")
 	}
-	if payload.VolumeType != diskDetails.DiskType {
-		return status.Errorf(codes.Internal, "TYPE in payload and disktype in disk details don't match")
+	// the storage pool decides the disk type of volumes created with a placement
+	hasPlacement := len(payload.VolumePool) != 0 || len(payload.AffinityPolicy) != 0
+	if !hasPlacement && payload.VolumeType != diskDetails.DiskType {
+		return status.Errorf(codes.AlreadyExists, "TYPE in payload and disktype in disk details don't match")
 	}
 	capacityGIB := util.BytesToGiB(payload.CapacityBytes)
 	if capacityGIB != diskDetails.CapacityGiB {
-		return status.Errorf(codes.Internal, "capacityBytes in payload and capacityGIB in disk details don't match")
+		return status.Errorf(codes.AlreadyExists, "capacityBytes in payload and capacityGIB in disk details don't match")
+	}
+	return nil
+}
+
+// verifyRestoredVolumeDetails checks an existing volume created from a content source.
+// Such volumes inherit the disk type of their source and may be larger than requested.
+func verifyRestoredVolumeDetails(payload *cloud.DiskOptions, diskDetails *cloud.Disk) error {
+	if payload.Shareable != diskDetails.Shareable {
+		return status.Errorf(codes.AlreadyExists, "shareable in payload and shareable in disk details don't match")
+	}
+	capacityGIB := util.BytesToGiB(payload.CapacityBytes)
+	if capacityGIB > diskDetails.CapacityGiB {
+		return status.Errorf(codes.AlreadyExists, "capacityBytes in payload is larger than capacityGIB in disk details")
+	}
+	return nil
+}
+
+// verifySnapshotSourceDetails checks that a volume restored from the snapshot volume can satisfy the request.
+// Restored volumes are clones of the snapshot volume, so they cannot be smaller than it.
+func verifySnapshotSourceDetails(payload *cloud.DiskOptions, snapshotDisk *cloud.Disk) error {
+	capacityGIB := util.BytesToGiB(payload.CapacityBytes)
+	if capacityGIB < snapshotDisk.CapacityGiB {
+		return status.Errorf(codes.OutOfRange, "capacityBytes in payload is smaller than capacityGIB in snapshot volume details")
+	}
+	return nil
+}
+
+// verifyCloneSourceDetails checks that a clone of the source volume can satisfy the request.
+// PowerVS clones keep the disk type and can only grow, so a mismatch is rejected up front.
+func verifyCloneSourceDetails(payload *cloud.DiskOptions, sourceDisk *cloud.Disk) error {
+	if payload.Shareable != sourceDisk.Shareable {
+		return status.Errorf(codes.InvalidArgument, "shareable in payload and shareable in source volume details don't match")
+	}
+	if payload.VolumeType != sourceDisk.DiskType {
+		return status.Errorf(codes.InvalidArgument, "TYPE in payload and disktype in source volume details don't match")
+	}
+	capacityGIB := util.BytesToGiB(payload.CapacityBytes)
+	if capacityGIB < sourceDisk.CapacityGiB {
+		return status.Errorf(codes.OutOfRange, "capacityBytes in payload is smaller than capacityGIB in source volume details")
 	}
 	return nil
 }
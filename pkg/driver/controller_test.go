@@ -15,9 +15,13 @@ package driver
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"reflect"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	csi "github.com/container-storage-interface/spec/lib/go/csi"
 	"github.com/golang/mock/gomock"
@@ -77,13 +81,14 @@ func TestCreateVolume(t *testing.T) {
 				defer mockCtl.Finish()
 
 				mockCloud := mocks.NewMockCloud(mockCtl)
-				mockCloud.EXPECT().GetDiskByName(gomock.Eq(req.Name)).Return(nil, nil)
-				mockCloud.EXPECT().CreateDisk(gomock.Eq(req.Name), gomock.Any()).Return(mockDisk, nil)
+				mockCloud.EXPECT().GetDiskByName(gomock.Any(), gomock.Eq(req.Name)).Return(nil, nil)
+				mockCloud.EXPECT().CreateDisk(gomock.Any(), gomock.Eq(req.Name), gomock.Any()).Return(mockDisk, nil)
 
 				powervsDriver := controllerService{
 					cloud:         mockCloud,
 					driverOptions: &Options{},
 					volumeLocks:   util.NewVolumeLocks(),
+					operations:    newOperationTracker(),
 				}
 
 				if _, err := powervsDriver.CreateVolume(ctx, req); err != nil {
@@ -131,13 +136,14 @@ func TestCreateVolume(t *testing.T) {
 				defer mockCtl.Finish()
 
 				mockCloud := mocks.NewMockCloud(mockCtl)
-				mockCloud.EXPECT().GetDiskByName(gomock.Eq(req.Name)).Return(nil, nil)
-				mockCloud.EXPECT().CreateDisk(gomock.Eq(req.Name), mockDiskOpts).Return(mockDisk, nil)
+				mockCloud.EXPECT().GetDiskByName(gomock.Any(), gomock.Eq(req.Name)).Return(nil, nil)
+				mockCloud.EXPECT().CreateDisk(gomock.Any(), gomock.Eq(req.Name), mockDiskOpts).Return(mockDisk, nil)
 
 				powervsDriver := controllerService{
 					cloud:         mockCloud,
 					driverOptions: &Options{},
 					volumeLocks:   util.NewVolumeLocks(),
+					operations:    newOperationTracker(),
 				}
 
 				_, err := powervsDriver.CreateVolume(ctx, req)
@@ -187,13 +193,14 @@ func TestCreateVolume(t *testing.T) {
 				defer mockCtl.Finish()
 
 				mockCloud := mocks.NewMockCloud(mockCtl)
-				mockCloud.EXPECT().GetDiskByName(gomock.Eq(req.Name)).Return(nil, nil)
-				mockCloud.EXPECT().CreateDisk(gomock.Eq(req.Name), mockDiskOpts).Return(mockDisk, nil)
+				mockCloud.EXPECT().GetDiskByName(gomock.Any(), gomock.Eq(req.Name)).Return(nil, nil)
+				mockCloud.EXPECT().CreateDisk(gomock.Any(), gomock.Eq(req.Name), mockDiskOpts).Return(mockDisk, nil)
 
 				powervsDriver := controllerService{
 					cloud:         mockCloud,
 					driverOptions: &Options{},
 					volumeLocks:   util.NewVolumeLocks(),
+					operations:    newOperationTracker(),
 				}
 
 				_, err := powervsDriver.CreateVolume(ctx, req)
@@ -243,13 +250,14 @@ func TestCreateVolume(t *testing.T) {
 				defer mockCtl.Finish()
 
 				mockCloud := mocks.NewMockCloud(mockCtl)
-				mockCloud.EXPECT().GetDiskByName(gomock.Eq(req.Name)).Return(nil, nil)
-				mockCloud.EXPECT().CreateDisk(gomock.Eq(req.Name), mockDiskOpts).Return(mockDisk, nil)
+				mockCloud.EXPECT().GetDiskByName(gomock.Any(), gomock.Eq(req.Name)).Return(nil, nil)
+				mockCloud.EXPECT().CreateDisk(gomock.Any(), gomock.Eq(req.Name), mockDiskOpts).Return(mockDisk, nil)
 
 				powervsDriver := controllerService{
 					cloud:         mockCloud,
 					driverOptions: &Options{},
 					volumeLocks:   util.NewVolumeLocks(),
+					operations:    newOperationTracker(),
 				}
 
 				_, err := powervsDriver.CreateVolume(ctx, req)
@@ -285,6 +293,7 @@ func TestCreateVolume(t *testing.T) {
 					cloud:         mockCloud,
 					driverOptions: &Options{},
 					volumeLocks:   util.NewVolumeLocks(),
+					operations:    newOperationTracker(),
 				}
 
 				if _, err := powervsDriver.CreateVolume(ctx, req); err != nil {
@@ -334,13 +343,14 @@ func TestCreateVolume(t *testing.T) {
 				defer mockCtl.Finish()
 
 				mockCloud := mocks.NewMockCloud(mockCtl)
-				mockCloud.EXPECT().GetDiskByName(gomock.Eq(req.Name)).Return(nil, nil)
-				mockCloud.EXPECT().CreateDisk(gomock.Eq(req.Name), gomock.Any()).Return(mockDisk, nil)
+				mockCloud.EXPECT().GetDiskByName(gomock.Any(), gomock.Eq(req.Name)).Return(nil, nil)
+				mockCloud.EXPECT().CreateDisk(gomock.Any(), gomock.Eq(req.Name), gomock.Any()).Return(mockDisk, nil)
 
 				powervsDriver := controllerService{
 					cloud:         mockCloud,
 					driverOptions: &Options{},
 					volumeLocks:   util.NewVolumeLocks(),
+					operations:    newOperationTracker(),
 				}
 
 				if _, err := powervsDriver.CreateVolume(ctx, req); err != nil {
@@ -352,8 +362,10 @@ func TestCreateVolume(t *testing.T) {
 				}
 
 				// Subsequent call returns the created disk
-				mockCloud.EXPECT().GetDiskByName(gomock.Eq(req.Name)).Return(mockDisk, nil)
-				mockCloud.EXPECT().WaitForVolumeState(gomock.Any(), gomock.Any()).Return(nil)
+				mockCloud.EXPECT().GetDiskByName(gomock.Any(), gomock.Eq(req.Name)).Return(mockDisk, nil)
+				mockCloud.EXPECT().WaitForVolumeState(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+				// tagging is best effort, a tag failure does not fail the create
+				mockCloud.EXPECT().TagDisk(gomock.Any(), gomock.Eq(mockDisk.VolumeID), gomock.Any()).Return(cloud.ErrUnauthorized)
 				resp, err := powervsDriver.CreateVolume(ctx, extraReq)
 				if err != nil {
 					srvErr, ok := status.FromError(err)
@@ -417,13 +429,14 @@ func TestCreateVolume(t *testing.T) {
 				defer mockCtl.Finish()
 
 				mockCloud := mocks.NewMockCloud(mockCtl)
-				mockCloud.EXPECT().GetDiskByName(gomock.Eq(req.Name)).Return(nil, nil)
-				mockCloud.EXPECT().CreateDisk(gomock.Eq(req.Name), gomock.Any()).Return(mockDisk, nil)
+				mockCloud.EXPECT().GetDiskByName(gomock.Any(), gomock.Eq(req.Name)).Return(nil, nil)
+				mockCloud.EXPECT().CreateDisk(gomock.Any(), gomock.Eq(req.Name), gomock.Any()).Return(mockDisk, nil)
 
 				powervsDriver := controllerService{
 					cloud:         mockCloud,
 					driverOptions: &Options{},
 					volumeLocks:   util.NewVolumeLocks(),
+					operations:    newOperationTracker(),
 				}
 
 				resp, err := powervsDriver.CreateVolume(ctx, req)
@@ -479,13 +492,14 @@ func TestCreateVolume(t *testing.T) {
 				defer mockCtl.Finish()
 
 				mockCloud := mocks.NewMockCloud(mockCtl)
-				mockCloud.EXPECT().GetDiskByName(gomock.Eq(req.Name)).Return(nil, nil)
-				mockCloud.EXPECT().CreateDisk(gomock.Eq(req.Name), gomock.Any()).Return(mockDisk, nil)
+				mockCloud.EXPECT().GetDiskByName(gomock.Any(), gomock.Eq(req.Name)).Return(nil, nil)
+				mockCloud.EXPECT().CreateDisk(gomock.Any(), gomock.Eq(req.Name), gomock.Any()).Return(mockDisk, nil)
 
 				powervsDriver := controllerService{
 					cloud:         mockCloud,
 					driverOptions: &Options{},
 					volumeLocks:   util.NewVolumeLocks(),
+					operations:    newOperationTracker(),
 				}
 
 				resp, err := powervsDriver.CreateVolume(ctx, req)
@@ -538,13 +552,14 @@ func TestCreateVolume(t *testing.T) {
 				defer mockCtl.Finish()
 
 				mockCloud := mocks.NewMockCloud(mockCtl)
-				mockCloud.EXPECT().GetDiskByName(gomock.Eq(req.Name)).Return(nil, nil)
-				mockCloud.EXPECT().CreateDisk(gomock.Eq(req.Name), mockDiskOpts).Return(mockDisk, nil)
+				mockCloud.EXPECT().GetDiskByName(gomock.Any(), gomock.Eq(req.Name)).Return(nil, nil)
+				mockCloud.EXPECT().CreateDisk(gomock.Any(), gomock.Eq(req.Name), mockDiskOpts).Return(mockDisk, nil)
 
 				powervsDriver := controllerService{
 					cloud:         mockCloud,
 					driverOptions: &Options{},
 					volumeLocks:   util.NewVolumeLocks(),
+					operations:    newOperationTracker(),
 				}
 
 				if _, err := powervsDriver.CreateVolume(ctx, req); err != nil {
@@ -587,13 +602,14 @@ func TestCreateVolume(t *testing.T) {
 				defer mockCtl.Finish()
 
 				mockCloud := mocks.NewMockCloud(mockCtl)
-				mockCloud.EXPECT().GetDiskByName(gomock.Eq(req.Name)).Return(nil, nil)
-				mockCloud.EXPECT().CreateDisk(gomock.Eq(req.Name), mockDiskOpts).Return(mockDisk, nil)
+				mockCloud.EXPECT().GetDiskByName(gomock.Any(), gomock.Eq(req.Name)).Return(nil, nil)
+				mockCloud.EXPECT().CreateDisk(gomock.Any(), gomock.Eq(req.Name), mockDiskOpts).Return(mockDisk, nil)
 
 				powervsDriver := controllerService{
 					cloud:         mockCloud,
 					driverOptions: &Options{},
 					volumeLocks:   util.NewVolumeLocks(),
+					operations:    newOperationTracker(),
 				}
 
 				if _, err := powervsDriver.CreateVolume(ctx, req); err != nil {
@@ -606,101 +622,90 @@ func TestCreateVolume(t *testing.T) {
 			},
 		},
 		{
-			name: "fail with invalid volume parameter",
+			name: "success with volume type tier0",
 			testFunc: func(t *testing.T) {
 				req := &csi.CreateVolumeRequest{
 					Name:               "vol-test",
 					CapacityRange:      stdCapRange,
 					VolumeCapabilities: stdVolCap,
 					Parameters: map[string]string{
-						VolumeTypeKey: cloud.VolumeTypeTier1,
-						"unknownKey":  "unknownValue",
+						VolumeTypeKey: cloud.VolumeTypeTier0,
 					},
 				}
 
 				ctx := context.Background()
 
+				mockDisk := &cloud.Disk{
+					VolumeID:    req.Name,
+					CapacityGiB: util.BytesToGiB(stdVolSize),
+					DiskType:    cloud.VolumeTypeTier0,
+				}
+
+				mockDiskOpts := &cloud.DiskOptions{
+					CapacityBytes: stdVolSize,
+					VolumeType:    cloud.VolumeTypeTier0,
+				}
+
 				mockCtl := gomock.NewController(t)
 				defer mockCtl.Finish()
 
 				mockCloud := mocks.NewMockCloud(mockCtl)
+				mockCloud.EXPECT().GetDiskByName(gomock.Any(), gomock.Eq(req.Name)).Return(nil, nil)
+				mockCloud.EXPECT().CreateDisk(gomock.Any(), gomock.Eq(req.Name), mockDiskOpts).Return(mockDisk, nil)
 
 				powervsDriver := controllerService{
 					cloud:         mockCloud,
 					driverOptions: &Options{},
 					volumeLocks:   util.NewVolumeLocks(),
+					operations:    newOperationTracker(),
 				}
 
-				_, err := powervsDriver.CreateVolume(ctx, req)
-				if err == nil {
-					t.Fatalf("Expected CreateVolume to fail but got no error")
-				}
-
-				srvErr, ok := status.FromError(err)
-				if !ok {
-					t.Fatalf("Could not get error status code from error: %v", srvErr)
-				}
-				if srvErr.Code() != codes.InvalidArgument {
-					t.Fatalf("Expect InvalidArgument but got: %s", srvErr.Code())
+				if _, err := powervsDriver.CreateVolume(ctx, req); err != nil {
+					t.Fatalf("Unexpected error: %v", err)
 				}
 			},
 		},
 		{
-			name: "Pass with no volume type parameter",
+			name: "fail with volume type not offered by the workspace",
 			testFunc: func(t *testing.T) {
-				// iops 5000 requires at least 10GB
-				volSize := int64(20 * 1024 * 1024 * 1024)
-				capRange := &csi.CapacityRange{RequiredBytes: volSize}
 				req := &csi.CreateVolumeRequest{
 					Name:               "vol-test",
-					CapacityRange:      capRange,
+					CapacityRange:      stdCapRange,
 					VolumeCapabilities: stdVolCap,
-					Parameters:         map[string]string{},
+					Parameters: map[string]string{
+						VolumeTypeKey: cloud.VolumeTypeTier0,
+					},
 				}
 
 				ctx := context.Background()
-
-				mockDisk := &cloud.Disk{
-					VolumeID:    req.Name,
-					CapacityGiB: util.BytesToGiB(volSize),
-					DiskType:    cloud.DefaultVolumeType,
-				}
-
-				mockDiskOpts := &cloud.DiskOptions{
-					CapacityBytes: volSize,
-					VolumeType:    cloud.DefaultVolumeType,
-				}
-
 				mockCtl := gomock.NewController(t)
 				defer mockCtl.Finish()
 
 				mockCloud := mocks.NewMockCloud(mockCtl)
-				mockCloud.EXPECT().GetDiskByName(gomock.Eq(req.Name)).Return(nil, nil)
-				mockCloud.EXPECT().CreateDisk(gomock.Eq(req.Name), mockDiskOpts).Return(mockDisk, nil)
+				mockCloud.EXPECT().GetDiskByName(gomock.Any(), gomock.Eq(req.Name)).Return(nil, nil)
+				mockCloud.EXPECT().CreateDisk(gomock.Any(), gomock.Eq(req.Name), gomock.Any()).Return(nil, fmt.Errorf("invalid PowerVS VolumeType %q: %w", cloud.VolumeTypeTier0, cloud.ErrUnsupportedVolumeType))
 
 				powervsDriver := controllerService{
 					cloud:         mockCloud,
 					driverOptions: &Options{},
 					volumeLocks:   util.NewVolumeLocks(),
+					operations:    newOperationTracker(),
 				}
 
-				if _, err := powervsDriver.CreateVolume(ctx, req); err != nil {
-					srvErr, ok := status.FromError(err)
-					if !ok {
-						t.Fatalf("Could not get error status code from error: %v", srvErr)
-					}
-					t.Fatalf("Unexpected error: %v", srvErr.Code())
-				}
+				_, err := powervsDriver.CreateVolume(ctx, req)
+				checkExpectedErrorCode(t, err, codes.InvalidArgument)
 			},
 		},
 		{
-			name: "fail locked volume request",
+			name: "fail with unknown volume type",
 			testFunc: func(t *testing.T) {
 				req := &csi.CreateVolumeRequest{
-					Name:               "random-vol-name",
+					Name:               "vol-test",
 					CapacityRange:      stdCapRange,
 					VolumeCapabilities: stdVolCap,
-					Parameters:         nil,
+					Parameters: map[string]string{
+						VolumeTypeKey: "tier9",
+					},
 				}
 
 				ctx := context.Background()
@@ -708,231 +713,213 @@ func TestCreateVolume(t *testing.T) {
 				defer mockCtl.Finish()
 
 				mockCloud := mocks.NewMockCloud(mockCtl)
+				mockCloud.EXPECT().GetDiskByName(gomock.Any(), gomock.Eq(req.Name)).Return(nil, nil)
+				mockCloud.EXPECT().CreateDisk(gomock.Any(), gomock.Eq(req.Name), gomock.Any()).Return(nil, fmt.Errorf("invalid PowerVS VolumeType %q: %w", "tier9", cloud.ErrUnsupportedVolumeType))
 
 				powervsDriver := controllerService{
 					cloud:         mockCloud,
 					driverOptions: &Options{},
 					volumeLocks:   util.NewVolumeLocks(),
+					operations:    newOperationTracker(),
 				}
 
-				powervsDriver.volumeLocks.TryAcquire(req.Name)
-				defer powervsDriver.volumeLocks.Release(req.Name)
-
 				_, err := powervsDriver.CreateVolume(ctx, req)
-				checkExpectedErrorCode(t, err, codes.Aborted)
+				checkExpectedErrorCode(t, err, codes.InvalidArgument)
 			},
 		},
-	}
-
-	for _, tc := range testCases {
-		t.Run(tc.name, tc.testFunc)
-	}
-}
-
-func TestDeleteVolume(t *testing.T) {
-	testCases := []struct {
-		name     string
-		testFunc func(t *testing.T)
-	}{
 		{
-			name: "success normal",
+			name: "fail with volume size below the fixed IOPS tier minimum",
 			testFunc: func(t *testing.T) {
-				req := &csi.DeleteVolumeRequest{
-					VolumeId: "vol-test",
+				req := &csi.CreateVolumeRequest{
+					Name:               "vol-test",
+					CapacityRange:      stdCapRange,
+					VolumeCapabilities: stdVolCap,
+					Parameters: map[string]string{
+						VolumeTypeKey: cloud.VolumeTypeTier5k,
+					},
 				}
-				expResp := &csi.DeleteVolumeResponse{}
 
 				ctx := context.Background()
 				mockCtl := gomock.NewController(t)
 				defer mockCtl.Finish()
 
 				mockCloud := mocks.NewMockCloud(mockCtl)
-				mockCloud.EXPECT().DeleteDisk(gomock.Eq(req.VolumeId)).Return(true, nil)
-				mockCloud.EXPECT().GetDiskByID(gomock.Eq(req.VolumeId)).Return(nil, nil)
+
 				powervsDriver := controllerService{
 					cloud:         mockCloud,
 					driverOptions: &Options{},
 					volumeLocks:   util.NewVolumeLocks(),
+					operations:    newOperationTracker(),
 				}
-				resp, err := powervsDriver.DeleteVolume(ctx, req)
-				if err != nil {
-					srvErr, ok := status.FromError(err)
-					if !ok {
-						t.Fatalf("Could not get error status code from error: %v", srvErr)
-					}
-					t.Fatalf("Unexpected error: %v", srvErr.Code())
-				}
-				if !reflect.DeepEqual(resp, expResp) {
-					t.Fatalf("Expected resp to be %+v, got: %+v", expResp, resp)
-				}
+
+				_, err := powervsDriver.CreateVolume(ctx, req)
+				checkExpectedErrorCode(t, err, codes.InvalidArgument)
 			},
 		},
 		{
-			name: "success invalid volume id",
+			name: "fail with volume size above the fixed IOPS tier maximum",
 			testFunc: func(t *testing.T) {
-				req := &csi.DeleteVolumeRequest{
-					VolumeId: "invalid-volume-name",
+				req := &csi.CreateVolumeRequest{
+					Name:               "vol-test",
+					CapacityRange:      &csi.CapacityRange{RequiredBytes: util.GiBToBytes(500)},
+					VolumeCapabilities: stdVolCap,
+					Parameters: map[string]string{
+						VolumeTypeKey: cloud.VolumeTypeTier5k,
+					},
 				}
-				expResp := &csi.DeleteVolumeResponse{}
 
 				ctx := context.Background()
 				mockCtl := gomock.NewController(t)
 				defer mockCtl.Finish()
 
 				mockCloud := mocks.NewMockCloud(mockCtl)
-				mockCloud.EXPECT().GetDiskByID(gomock.Eq(req.VolumeId)).Return(nil, cloud.ErrNotFound)
+
 				powervsDriver := controllerService{
 					cloud:         mockCloud,
 					driverOptions: &Options{},
 					volumeLocks:   util.NewVolumeLocks(),
+					operations:    newOperationTracker(),
 				}
-				resp, err := powervsDriver.DeleteVolume(ctx, req)
-				if err != nil {
-					srvErr, ok := status.FromError(err)
-					if !ok {
-						t.Fatalf("Could not get error status code from error: %v", srvErr)
-					}
-					t.Fatalf("Unexpected error: %v", srvErr.Code())
-				}
-				if !reflect.DeepEqual(resp, expResp) {
-					t.Fatalf("Expected resp to be %+v, got: %+v", expResp, resp)
-				}
+
+				_, err := powervsDriver.CreateVolume(ctx, req)
+				checkExpectedErrorCode(t, err, codes.InvalidArgument)
 			},
 		},
 		{
-			name: "fail delete disk",
+			name: "success with volume pool",
 			testFunc: func(t *testing.T) {
-				req := &csi.DeleteVolumeRequest{
-					VolumeId: "test-vol",
+				req := &csi.CreateVolumeRequest{
+					Name:               "vol-test",
+					CapacityRange:      stdCapRange,
+					VolumeCapabilities: stdVolCap,
+					Parameters: map[string]string{
+						"volumePool": "Tier3-Flash-1",
+					},
 				}
 
 				ctx := context.Background()
+
+				mockDisk := &cloud.Disk{
+					VolumeID:    req.Name,
+					CapacityGiB: util.BytesToGiB(stdVolSize),
+					DiskType:    cloud.VolumeTypeTier3,
+				}
+
+				mockDiskOpts := &cloud.DiskOptions{
+					CapacityBytes: stdVolSize,
+					VolumeType:    cloud.DefaultVolumeType,
+					VolumePool:    "Tier3-Flash-1",
+				}
+
 				mockCtl := gomock.NewController(t)
 				defer mockCtl.Finish()
 
 				mockCloud := mocks.NewMockCloud(mockCtl)
-				mockCloud.EXPECT().DeleteDisk(gomock.Eq(req.VolumeId)).Return(false, fmt.Errorf("DeleteDisk could not delete volume"))
-				mockCloud.EXPECT().GetDiskByID(gomock.Eq(req.VolumeId)).Return(nil, nil)
+				mockCloud.EXPECT().GetDiskByName(gomock.Any(), gomock.Eq(req.Name)).Return(nil, nil)
+				mockCloud.EXPECT().CreateDisk(gomock.Any(), gomock.Eq(req.Name), mockDiskOpts).Return(mockDisk, nil)
+
 				powervsDriver := controllerService{
 					cloud:         mockCloud,
 					driverOptions: &Options{},
 					volumeLocks:   util.NewVolumeLocks(),
-				}
-				resp, err := powervsDriver.DeleteVolume(ctx, req)
-				if err != nil {
-					srvErr, ok := status.FromError(err)
-					if !ok {
-						t.Fatalf("Could not get error status code from error: %v", srvErr)
-					}
-					if srvErr.Code() != codes.Internal {
-						t.Fatalf("Unexpected error: %v", srvErr.Code())
-					}
-				} else {
-					t.Fatalf("Expected error, got nil")
+					operations:    newOperationTracker(),
 				}
 
-				if resp != nil {
-					t.Fatalf("Expected resp to be nil, got: %+v", resp)
+				if _, err := powervsDriver.CreateVolume(ctx, req); err != nil {
+					t.Fatalf("Unexpected error: %v", err)
 				}
 			},
 		},
 		{
-			name: "fail if volume is already locked",
+			name: "success with anti-affinity to volumes",
 			testFunc: func(t *testing.T) {
-				req := &csi.DeleteVolumeRequest{
-					VolumeId: "vol-test",
+				req := &csi.CreateVolumeRequest{
+					Name:               "vol-test",
+					CapacityRange:      stdCapRange,
+					VolumeCapabilities: stdVolCap,
+					Parameters: map[string]string{
+						"affinityPolicy":      cloud.AffinityPolicyAntiAffinity,
+						"antiAffinityVolumes": "vol-1, vol-2",
+					},
 				}
 
 				ctx := context.Background()
+
+				mockDisk := &cloud.Disk{
+					VolumeID:    req.Name,
+					CapacityGiB: util.BytesToGiB(stdVolSize),
+					DiskType:    cloud.DefaultVolumeType,
+				}
+
+				mockDiskOpts := &cloud.DiskOptions{
+					CapacityBytes:       stdVolSize,
+					VolumeType:          cloud.DefaultVolumeType,
+					AffinityPolicy:      cloud.AffinityPolicyAntiAffinity,
+					AntiAffinityVolumes: []string{"vol-1", "vol-2"},
+				}
+
 				mockCtl := gomock.NewController(t)
 				defer mockCtl.Finish()
 
 				mockCloud := mocks.NewMockCloud(mockCtl)
+				mockCloud.EXPECT().GetDiskByName(gomock.Any(), gomock.Eq(req.Name)).Return(nil, nil)
+				mockCloud.EXPECT().CreateDisk(gomock.Any(), gomock.Eq(req.Name), mockDiskOpts).Return(mockDisk, nil)
 
 				powervsDriver := controllerService{
 					cloud:         mockCloud,
 					driverOptions: &Options{},
 					volumeLocks:   util.NewVolumeLocks(),
+					operations:    newOperationTracker(),
 				}
 
-				powervsDriver.volumeLocks.TryAcquire(req.VolumeId)
-				defer powervsDriver.volumeLocks.Release(req.VolumeId)
-
-				_, err := powervsDriver.DeleteVolume(ctx, req)
-				checkExpectedErrorCode(t, err, codes.Aborted)
+				if _, err := powervsDriver.CreateVolume(ctx, req); err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
 			},
 		},
-	}
-
-	for _, tc := range testCases {
-		t.Run(tc.name, tc.testFunc)
-	}
-}
-
-func TestControllerPublishVolume(t *testing.T) {
-	stdVolCap := &csi.VolumeCapability{
-		AccessType: &csi.VolumeCapability_Mount{
-			Mount: &csi.VolumeCapability_MountVolume{},
-		},
-		AccessMode: &csi.VolumeCapability_AccessMode{
-			Mode: csi.VolumeCapability_AccessMode_SINGLE_NODE_WRITER,
-		},
-	}
-	expDevicePath := "/dev/xvda"
-	volumeName := "vol-test"
-
-	testCases := []struct {
-		name     string
-		testFunc func(t *testing.T)
-	}{
-
 		{
-			name: "success normal",
+			name: "fail with affinity policy without affinity targets",
 			testFunc: func(t *testing.T) {
-				req := &csi.ControllerPublishVolumeRequest{
-					NodeId:           expInstanceID,
-					VolumeCapability: stdVolCap,
-					VolumeId:         volumeName,
-				}
-				expResp := &csi.ControllerPublishVolumeResponse{
-					PublishContext: map[string]string{WWNKey: expDevicePath},
+				req := &csi.CreateVolumeRequest{
+					Name:               "vol-test",
+					CapacityRange:      stdCapRange,
+					VolumeCapabilities: stdVolCap,
+					Parameters: map[string]string{
+						"affinityPolicy":           cloud.AffinityPolicyAffinity,
+						"antiAffinityPVMInstances": "instance-1",
+					},
 				}
 
 				ctx := context.Background()
-
 				mockCtl := gomock.NewController(t)
 				defer mockCtl.Finish()
 
 				mockCloud := mocks.NewMockCloud(mockCtl)
-				mockCloud.EXPECT().GetPVMInstanceByID(gomock.Eq(expInstanceID)).Return(nil, nil)
-				mockCloud.EXPECT().GetDiskByID(gomock.Eq(volumeName)).Return(&cloud.Disk{WWN: expDevicePath}, nil)
-				mockCloud.EXPECT().IsAttached(gomock.Eq(volumeName), gomock.Eq(expInstanceID)).Return(false, nil)
-				mockCloud.EXPECT().AttachDisk(gomock.Eq(volumeName), gomock.Eq(expInstanceID)).Return(nil)
 
 				powervsDriver := controllerService{
 					cloud:         mockCloud,
 					driverOptions: &Options{},
 					volumeLocks:   util.NewVolumeLocks(),
+					operations:    newOperationTracker(),
 				}
 
-				resp, err := powervsDriver.ControllerPublishVolume(ctx, req)
-				if err != nil {
-					t.Fatalf("Unexpected error: %v", err)
-				}
-
-				if !reflect.DeepEqual(resp, expResp) {
-					t.Fatalf("Expected resp to be %+v, got: %+v", expResp, resp)
-				}
+				_, err := powervsDriver.CreateVolume(ctx, req)
+				checkExpectedErrorCode(t, err, codes.InvalidArgument)
 			},
 		},
-
 		{
-			name: "fail no VolumeId",
+			name: "fail with affinity target without affinity policy",
 			testFunc: func(t *testing.T) {
-				req := &csi.ControllerPublishVolumeRequest{}
+				req := &csi.CreateVolumeRequest{
+					Name:               "vol-test",
+					CapacityRange:      stdCapRange,
+					VolumeCapabilities: stdVolCap,
+					Parameters: map[string]string{
+						"affinityPVMInstance": "instance-1",
+					},
+				}
 
 				ctx := context.Background()
-
 				mockCtl := gomock.NewController(t)
 				defer mockCtl.Finish()
 
@@ -942,213 +929,2424 @@ func TestControllerPublishVolume(t *testing.T) {
 					cloud:         mockCloud,
 					driverOptions: &Options{},
 					volumeLocks:   util.NewVolumeLocks(),
+					operations:    newOperationTracker(),
 				}
 
-				if _, err := powervsDriver.ControllerPublishVolume(ctx, req); err != nil {
-					srvErr, ok := status.FromError(err)
-					if !ok {
-						t.Fatalf("Could not get error status code from error: %v", srvErr)
-					}
-					if srvErr.Code() != codes.InvalidArgument {
-						t.Fatalf("Expected error code %d, got %d message %s", codes.InvalidArgument, srvErr.Code(), srvErr.Message())
-					}
-				} else {
+				_, err := powervsDriver.CreateVolume(ctx, req)
+				checkExpectedErrorCode(t, err, codes.InvalidArgument)
+			},
+		},
+		{
+			name: "fail with both affinity targets",
+			testFunc: func(t *testing.T) {
+				req := &csi.CreateVolumeRequest{
+					Name:               "vol-test",
+					CapacityRange:      stdCapRange,
+					VolumeCapabilities: stdVolCap,
+					Parameters: map[string]string{
+						"affinityPolicy":      cloud.AffinityPolicyAffinity,
+						"affinityVolume":      "vol-1",
+						"affinityPVMInstance": "instance-1",
+					},
+				}
+
+				ctx := context.Background()
+				mockCtl := gomock.NewController(t)
+				defer mockCtl.Finish()
+
+				mockCloud := mocks.NewMockCloud(mockCtl)
+
+				powervsDriver := controllerService{
+					cloud:         mockCloud,
+					driverOptions: &Options{},
+					volumeLocks:   util.NewVolumeLocks(),
+					operations:    newOperationTracker(),
+				}
+
+				_, err := powervsDriver.CreateVolume(ctx, req)
+				checkExpectedErrorCode(t, err, codes.InvalidArgument)
+			},
+		},
+		{
+			name: "fail with volume pool and volume content source",
+			testFunc: func(t *testing.T) {
+				req := &csi.CreateVolumeRequest{
+					Name:               "vol-test",
+					CapacityRange:      stdCapRange,
+					VolumeCapabilities: stdVolCap,
+					Parameters: map[string]string{
+						"volumePool": "Tier3-Flash-1",
+					},
+					VolumeContentSource: &csi.VolumeContentSource{
+						Type: &csi.VolumeContentSource_Volume{
+							Volume: &csi.VolumeContentSource_VolumeSource{
+								VolumeId: "vol-source",
+							},
+						},
+					},
+				}
+
+				ctx := context.Background()
+				mockCtl := gomock.NewController(t)
+				defer mockCtl.Finish()
+
+				mockCloud := mocks.NewMockCloud(mockCtl)
+
+				powervsDriver := controllerService{
+					cloud:         mockCloud,
+					driverOptions: &Options{},
+					volumeLocks:   util.NewVolumeLocks(),
+					operations:    newOperationTracker(),
+				}
+
+				_, err := powervsDriver.CreateVolume(ctx, req)
+				checkExpectedErrorCode(t, err, codes.InvalidArgument)
+			},
+		},
+		{
+			name: "fail with invalid affinity policy",
+			testFunc: func(t *testing.T) {
+				req := &csi.CreateVolumeRequest{
+					Name:               "vol-test",
+					CapacityRange:      stdCapRange,
+					VolumeCapabilities: stdVolCap,
+					Parameters: map[string]string{
+						"affinityPolicy": "nearby",
+						"affinityVolume": "vol-1",
+					},
+				}
+
+				ctx := context.Background()
+				mockCtl := gomock.NewController(t)
+				defer mockCtl.Finish()
+
+				mockCloud := mocks.NewMockCloud(mockCtl)
+
+				powervsDriver := controllerService{
+					cloud:         mockCloud,
+					driverOptions: &Options{},
+					volumeLocks:   util.NewVolumeLocks(),
+					operations:    newOperationTracker(),
+				}
+
+				_, err := powervsDriver.CreateVolume(ctx, req)
+				checkExpectedErrorCode(t, err, codes.InvalidArgument)
+			},
+		},
+		{
+			name: "success with tags",
+			testFunc: func(t *testing.T) {
+				req := &csi.CreateVolumeRequest{
+					Name:               "vol-test",
+					CapacityRange:      stdCapRange,
+					VolumeCapabilities: stdVolCap,
+					Parameters: map[string]string{
+						PVCNameKey:      "pvc-name",
+						PVCNamespaceKey: "pvc-namespace",
+						PVNameKey:       "pv-name",
+					},
+				}
+
+				ctx := context.Background()
+
+				mockDisk := &cloud.Disk{
+					VolumeID:    req.Name,
+					CapacityGiB: util.BytesToGiB(stdVolSize),
+					DiskType:    cloud.DefaultVolumeType,
+				}
+
+				mockDiskOpts := &cloud.DiskOptions{
+					CapacityBytes: stdVolSize,
+					VolumeType:    cloud.DefaultVolumeType,
+					Tags: map[string]string{
+						"owner":                  "team-a",
+						cloud.ClusterIDTagKey:    "cluster-1",
+						cloud.PVCNameTagKey:      "pvc-name",
+						cloud.PVCNamespaceTagKey: "pvc-namespace",
+						cloud.PVNameTagKey:       "pv-name",
+					},
+				}
+
+				mockCtl := gomock.NewController(t)
+				defer mockCtl.Finish()
+
+				mockCloud := mocks.NewMockCloud(mockCtl)
+				mockCloud.EXPECT().GetDiskByName(gomock.Any(), gomock.Eq(req.Name)).Return(nil, nil)
+				mockCloud.EXPECT().CreateDisk(gomock.Any(), gomock.Eq(req.Name), mockDiskOpts).Return(mockDisk, nil)
+
+				powervsDriver := controllerService{
+					cloud: mockCloud,
+					driverOptions: &Options{
+						extraTags:           map[string]string{"owner": "team-a"},
+						kubernetesClusterID: "cluster-1",
+					},
+					volumeLocks: util.NewVolumeLocks(),
+					operations:  newOperationTracker(),
+				}
+
+				if _, err := powervsDriver.CreateVolume(ctx, req); err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+			},
+		},
+		{
+			name: "success with volume name template",
+			testFunc: func(t *testing.T) {
+				req := &csi.CreateVolumeRequest{
+					Name:               "pvc-test",
+					CapacityRange:      stdCapRange,
+					VolumeCapabilities: stdVolCap,
+					Parameters: map[string]string{
+						PVCNameKey:      "pvc-name",
+						PVCNamespaceKey: "pvc-namespace",
+					},
+				}
+
+				ctx := context.Background()
+
+				template := "{{.ClusterID}}-{{.PVCNamespace}}-{{.PVCName}}"
+				diskName, err := generateVolumeName(template, volumeNameData{
+					Name:         req.Name,
+					ClusterID:    "cluster-1",
+					PVCName:      "pvc-name",
+					PVCNamespace: "pvc-namespace",
+				})
+				if err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+
+				mockDisk := &cloud.Disk{
+					VolumeID:    "vol-test",
+					Name:        diskName,
+					CapacityGiB: util.BytesToGiB(stdVolSize),
+					DiskType:    cloud.DefaultVolumeType,
+				}
+
+				mockCtl := gomock.NewController(t)
+				defer mockCtl.Finish()
+
+				mockCloud := mocks.NewMockCloud(mockCtl)
+				mockCloud.EXPECT().GetDiskByName(gomock.Any(), gomock.Eq(diskName)).Return(nil, nil)
+				mockCloud.EXPECT().CreateDisk(gomock.Any(), gomock.Eq(diskName), gomock.Any()).Return(mockDisk, nil)
+
+				powervsDriver := controllerService{
+					cloud: mockCloud,
+					driverOptions: &Options{
+						kubernetesClusterID: "cluster-1",
+						volumeNameTemplate:  template,
+					},
+					volumeLocks: util.NewVolumeLocks(),
+					operations:  newOperationTracker(),
+				}
+
+				if _, err := powervsDriver.CreateVolume(ctx, req); err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+			},
+		},
+		{
+			name: "fail with invalid volume parameter",
+			testFunc: func(t *testing.T) {
+				req := &csi.CreateVolumeRequest{
+					Name:               "vol-test",
+					CapacityRange:      stdCapRange,
+					VolumeCapabilities: stdVolCap,
+					Parameters: map[string]string{
+						VolumeTypeKey: cloud.VolumeTypeTier1,
+						"unknownKey":  "unknownValue",
+					},
+				}
+
+				ctx := context.Background()
+
+				mockCtl := gomock.NewController(t)
+				defer mockCtl.Finish()
+
+				mockCloud := mocks.NewMockCloud(mockCtl)
+
+				powervsDriver := controllerService{
+					cloud:         mockCloud,
+					driverOptions: &Options{},
+					volumeLocks:   util.NewVolumeLocks(),
+					operations:    newOperationTracker(),
+				}
+
+				_, err := powervsDriver.CreateVolume(ctx, req)
+				if err == nil {
+					t.Fatalf("Expected CreateVolume to fail but got no error")
+				}
+
+				srvErr, ok := status.FromError(err)
+				if !ok {
+					t.Fatalf("Could not get error status code from error: %v", srvErr)
+				}
+				if srvErr.Code() != codes.InvalidArgument {
+					t.Fatalf("Expect InvalidArgument but got: %s", srvErr.Code())
+				}
+			},
+		},
+		{
+			name: "Pass with no volume type parameter",
+			testFunc: func(t *testing.T) {
+				// iops 5000 requires at least 10GB
+				volSize := int64(20 * 1024 * 1024 * 1024)
+				capRange := &csi.CapacityRange{RequiredBytes: volSize}
+				req := &csi.CreateVolumeRequest{
+					Name:               "vol-test",
+					CapacityRange:      capRange,
+					VolumeCapabilities: stdVolCap,
+					Parameters:         map[string]string{},
+				}
+
+				ctx := context.Background()
+
+				mockDisk := &cloud.Disk{
+					VolumeID:    req.Name,
+					CapacityGiB: util.BytesToGiB(volSize),
+					DiskType:    cloud.DefaultVolumeType,
+				}
+
+				mockDiskOpts := &cloud.DiskOptions{
+					CapacityBytes: volSize,
+					VolumeType:    cloud.DefaultVolumeType,
+				}
+
+				mockCtl := gomock.NewController(t)
+				defer mockCtl.Finish()
+
+				mockCloud := mocks.NewMockCloud(mockCtl)
+				mockCloud.EXPECT().GetDiskByName(gomock.Any(), gomock.Eq(req.Name)).Return(nil, nil)
+				mockCloud.EXPECT().CreateDisk(gomock.Any(), gomock.Eq(req.Name), mockDiskOpts).Return(mockDisk, nil)
+
+				powervsDriver := controllerService{
+					cloud:         mockCloud,
+					driverOptions: &Options{},
+					volumeLocks:   util.NewVolumeLocks(),
+					operations:    newOperationTracker(),
+				}
+
+				if _, err := powervsDriver.CreateVolume(ctx, req); err != nil {
+					srvErr, ok := status.FromError(err)
+					if !ok {
+						t.Fatalf("Could not get error status code from error: %v", srvErr)
+					}
+					t.Fatalf("Unexpected error: %v", srvErr.Code())
+				}
+			},
+		},
+		{
+			name: "success with snapshot volume content source",
+			testFunc: func(t *testing.T) {
+				req := &csi.CreateVolumeRequest{
+					Name:               "random-vol-name",
+					CapacityRange:      stdCapRange,
+					VolumeCapabilities: stdVolCap,
+					Parameters:         stdParams,
+					VolumeContentSource: &csi.VolumeContentSource{
+						Type: &csi.VolumeContentSource_Snapshot{
+							Snapshot: &csi.VolumeContentSource_SnapshotSource{
+								SnapshotId: "snap-test",
+							},
+						},
+					},
+				}
+
+				ctx := context.Background()
+
+				mockDisk := &cloud.Disk{
+					VolumeID:    req.Name,
+					CapacityGiB: util.BytesToGiB(stdVolSize),
+					DiskType:    cloud.DefaultVolumeType,
+				}
+
+				mockCtl := gomock.NewController(t)
+				defer mockCtl.Finish()
+
+				mockCloud := mocks.NewMockCloud(mockCtl)
+				mockCloud.EXPECT().GetDiskByName(gomock.Any(), gomock.Eq(req.Name)).Return(nil, nil)
+				mockCloud.EXPECT().GetSnapshotByID(gomock.Any(), gomock.Eq("snap-test")).Return(&cloud.Snapshot{SnapshotID: "snap-test", SnapshotVolumeID: "snap-vol", ReadyToUse: true}, nil)
+				mockCloud.EXPECT().GetDiskByID(gomock.Any(), gomock.Eq("snap-vol")).Return(&cloud.Disk{VolumeID: "snap-vol", CapacityGiB: util.BytesToGiB(stdVolSize), DiskType: cloud.DefaultVolumeType}, nil)
+				mockCloud.EXPECT().CreateDiskFromSnapshot(gomock.Any(), gomock.Eq(req.Name), gomock.Eq("snap-test"), gomock.Any()).Return(mockDisk, nil)
+
+				powervsDriver := controllerService{
+					cloud:         mockCloud,
+					driverOptions: &Options{},
+					volumeLocks:   util.NewVolumeLocks(),
+					operations:    newOperationTracker(),
+				}
+
+				resp, err := powervsDriver.CreateVolume(ctx, req)
+				if err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+				if !reflect.DeepEqual(resp.GetVolume().GetContentSource(), req.VolumeContentSource) {
+					t.Fatalf("Expected content source %+v, got %+v", req.VolumeContentSource, resp.GetVolume().GetContentSource())
+				}
+			},
+		},
+		{
+			name: "fail with snapshot volume content source larger than requested",
+			testFunc: func(t *testing.T) {
+				req := &csi.CreateVolumeRequest{
+					Name:               "random-vol-name",
+					CapacityRange:      stdCapRange,
+					VolumeCapabilities: stdVolCap,
+					Parameters:         stdParams,
+					VolumeContentSource: &csi.VolumeContentSource{
+						Type: &csi.VolumeContentSource_Snapshot{
+							Snapshot: &csi.VolumeContentSource_SnapshotSource{
+								SnapshotId: "snap-test",
+							},
+						},
+					},
+				}
+
+				ctx := context.Background()
+
+				mockCtl := gomock.NewController(t)
+				defer mockCtl.Finish()
+
+				mockCloud := mocks.NewMockCloud(mockCtl)
+				mockCloud.EXPECT().GetDiskByName(gomock.Any(), gomock.Eq(req.Name)).Return(nil, nil)
+				mockCloud.EXPECT().GetSnapshotByID(gomock.Any(), gomock.Eq("snap-test")).Return(&cloud.Snapshot{SnapshotID: "snap-test", SnapshotVolumeID: "snap-vol", ReadyToUse: true}, nil)
+				mockCloud.EXPECT().GetDiskByID(gomock.Any(), gomock.Eq("snap-vol")).Return(&cloud.Disk{VolumeID: "snap-vol", CapacityGiB: util.BytesToGiB(stdVolSize) + 1, DiskType: cloud.DefaultVolumeType}, nil)
+				mockCloud.EXPECT().CreateDiskFromSnapshot(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+				powervsDriver := controllerService{
+					cloud:         mockCloud,
+					driverOptions: &Options{},
+					volumeLocks:   util.NewVolumeLocks(),
+					operations:    newOperationTracker(),
+				}
+
+				_, err := powervsDriver.CreateVolume(ctx, req)
+				checkExpectedErrorCode(t, err, codes.OutOfRange)
+			},
+		},
+		{
+			name: "fail with snapshot volume content source below the tier size limit",
+			testFunc: func(t *testing.T) {
+				req := &csi.CreateVolumeRequest{
+					Name:               "random-vol-name",
+					CapacityRange:      stdCapRange,
+					VolumeCapabilities: stdVolCap,
+					VolumeContentSource: &csi.VolumeContentSource{
+						Type: &csi.VolumeContentSource_Snapshot{
+							Snapshot: &csi.VolumeContentSource_SnapshotSource{
+								SnapshotId: "snap-test",
+							},
+						},
+					},
+				}
+
+				ctx := context.Background()
+
+				mockCtl := gomock.NewController(t)
+				defer mockCtl.Finish()
+
+				mockCloud := mocks.NewMockCloud(mockCtl)
+				mockCloud.EXPECT().GetDiskByName(gomock.Any(), gomock.Eq(req.Name)).Return(nil, nil)
+				mockCloud.EXPECT().GetSnapshotByID(gomock.Any(), gomock.Eq("snap-test")).Return(&cloud.Snapshot{SnapshotID: "snap-test", SnapshotVolumeID: "snap-vol", ReadyToUse: true}, nil)
+				mockCloud.EXPECT().GetDiskByID(gomock.Any(), gomock.Eq("snap-vol")).Return(&cloud.Disk{VolumeID: "snap-vol", CapacityGiB: 1, DiskType: cloud.VolumeTypeTier5k}, nil)
+				mockCloud.EXPECT().CreateDiskFromSnapshot(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+				powervsDriver := controllerService{
+					cloud:         mockCloud,
+					driverOptions: &Options{},
+					volumeLocks:   util.NewVolumeLocks(),
+					operations:    newOperationTracker(),
+				}
+
+				_, err := powervsDriver.CreateVolume(ctx, req)
+				checkExpectedErrorCode(t, err, codes.InvalidArgument)
+			},
+		},
+		{
+			name: "fail with snapshot volume content source not found",
+			testFunc: func(t *testing.T) {
+				req := &csi.CreateVolumeRequest{
+					Name:               "random-vol-name",
+					CapacityRange:      stdCapRange,
+					VolumeCapabilities: stdVolCap,
+					Parameters:         stdParams,
+					VolumeContentSource: &csi.VolumeContentSource{
+						Type: &csi.VolumeContentSource_Snapshot{
+							Snapshot: &csi.VolumeContentSource_SnapshotSource{
+								SnapshotId: "snap-test",
+							},
+						},
+					},
+				}
+
+				ctx := context.Background()
+
+				mockCtl := gomock.NewController(t)
+				defer mockCtl.Finish()
+
+				mockCloud := mocks.NewMockCloud(mockCtl)
+				mockCloud.EXPECT().GetDiskByName(gomock.Any(), gomock.Eq(req.Name)).Return(nil, nil)
+				mockCloud.EXPECT().GetSnapshotByID(gomock.Any(), gomock.Eq("snap-test")).Return(nil, cloud.ErrNotFound)
+
+				powervsDriver := controllerService{
+					cloud:         mockCloud,
+					driverOptions: &Options{},
+					volumeLocks:   util.NewVolumeLocks(),
+					operations:    newOperationTracker(),
+				}
+
+				_, err := powervsDriver.CreateVolume(ctx, req)
+				checkExpectedErrorCode(t, err, codes.NotFound)
+			},
+		},
+		{
+			name: "success with volume content source",
+			testFunc: func(t *testing.T) {
+				req := &csi.CreateVolumeRequest{
+					Name:               "random-vol-name",
+					CapacityRange:      stdCapRange,
+					VolumeCapabilities: stdVolCap,
+					VolumeContentSource: &csi.VolumeContentSource{
+						Type: &csi.VolumeContentSource_Volume{
+							Volume: &csi.VolumeContentSource_VolumeSource{
+								VolumeId: "vol-source",
+							},
+						},
+					},
+				}
+
+				ctx := context.Background()
+
+				mockSourceDisk := &cloud.Disk{
+					VolumeID:    "vol-source",
+					CapacityGiB: util.BytesToGiB(stdVolSize),
+					DiskType:    cloud.VolumeTypeTier3,
+				}
+				mockDisk := &cloud.Disk{
+					VolumeID:    req.Name,
+					CapacityGiB: util.BytesToGiB(stdVolSize),
+					DiskType:    cloud.VolumeTypeTier3,
+				}
+				mockDiskOpts := &cloud.DiskOptions{
+					CapacityBytes: stdVolSize,
+					VolumeType:    cloud.VolumeTypeTier3,
+				}
+
+				mockCtl := gomock.NewController(t)
+				defer mockCtl.Finish()
+
+				mockCloud := mocks.NewMockCloud(mockCtl)
+				mockCloud.EXPECT().GetDiskByName(gomock.Any(), gomock.Eq(req.Name)).Return(nil, nil)
+				mockCloud.EXPECT().GetDiskByID(gomock.Any(), gomock.Eq("vol-source")).Return(mockSourceDisk, nil)
+				mockCloud.EXPECT().CloneDisk(gomock.Any(), gomock.Eq("vol-source"), gomock.Eq(req.Name), mockDiskOpts).Return(mockDisk, nil)
+
+				powervsDriver := controllerService{
+					cloud:         mockCloud,
+					driverOptions: &Options{},
+					volumeLocks:   util.NewVolumeLocks(),
+					operations:    newOperationTracker(),
+				}
+
+				resp, err := powervsDriver.CreateVolume(ctx, req)
+				if err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+				if !reflect.DeepEqual(resp.GetVolume().GetContentSource(), req.VolumeContentSource) {
+					t.Fatalf("Expected content source %+v, got %+v", req.VolumeContentSource, resp.GetVolume().GetContentSource())
+				}
+			},
+		},
+		{
+			name: "fail with volume content source of a different volume type",
+			testFunc: func(t *testing.T) {
+				req := &csi.CreateVolumeRequest{
+					Name:               "random-vol-name",
+					CapacityRange:      stdCapRange,
+					VolumeCapabilities: stdVolCap,
+					Parameters:         map[string]string{VolumeTypeKey: cloud.VolumeTypeTier1},
+					VolumeContentSource: &csi.VolumeContentSource{
+						Type: &csi.VolumeContentSource_Volume{
+							Volume: &csi.VolumeContentSource_VolumeSource{
+								VolumeId: "vol-source",
+							},
+						},
+					},
+				}
+
+				ctx := context.Background()
+
+				mockSourceDisk := &cloud.Disk{
+					VolumeID:    "vol-source",
+					CapacityGiB: util.BytesToGiB(stdVolSize),
+					DiskType:    cloud.VolumeTypeTier3,
+				}
+
+				mockCtl := gomock.NewController(t)
+				defer mockCtl.Finish()
+
+				mockCloud := mocks.NewMockCloud(mockCtl)
+				mockCloud.EXPECT().GetDiskByName(gomock.Any(), gomock.Eq(req.Name)).Return(nil, nil)
+				mockCloud.EXPECT().GetDiskByID(gomock.Any(), gomock.Eq("vol-source")).Return(mockSourceDisk, nil)
+
+				powervsDriver := controllerService{
+					cloud:         mockCloud,
+					driverOptions: &Options{},
+					volumeLocks:   util.NewVolumeLocks(),
+					operations:    newOperationTracker(),
+				}
+
+				_, err := powervsDriver.CreateVolume(ctx, req)
+				checkExpectedErrorCode(t, err, codes.InvalidArgument)
+			},
+		},
+		{
+			name: "fail with volume content source not found",
+			testFunc: func(t *testing.T) {
+				req := &csi.CreateVolumeRequest{
+					Name:               "random-vol-name",
+					CapacityRange:      stdCapRange,
+					VolumeCapabilities: stdVolCap,
+					VolumeContentSource: &csi.VolumeContentSource{
+						Type: &csi.VolumeContentSource_Volume{
+							Volume: &csi.VolumeContentSource_VolumeSource{
+								VolumeId: "vol-source",
+							},
+						},
+					},
+				}
+
+				ctx := context.Background()
+
+				mockCtl := gomock.NewController(t)
+				defer mockCtl.Finish()
+
+				mockCloud := mocks.NewMockCloud(mockCtl)
+				mockCloud.EXPECT().GetDiskByName(gomock.Any(), gomock.Eq(req.Name)).Return(nil, nil)
+				mockCloud.EXPECT().GetDiskByID(gomock.Any(), gomock.Eq("vol-source")).Return(nil, cloud.ErrNotFound)
+
+				powervsDriver := controllerService{
+					cloud:         mockCloud,
+					driverOptions: &Options{},
+					volumeLocks:   util.NewVolumeLocks(),
+					operations:    newOperationTracker(),
+				}
+
+				_, err := powervsDriver.CreateVolume(ctx, req)
+				checkExpectedErrorCode(t, err, codes.NotFound)
+			},
+		},
+		{
+			name: "success with volume type from preferred topology",
+			testFunc: func(t *testing.T) {
+				req := &csi.CreateVolumeRequest{
+					Name:               "vol-test",
+					CapacityRange:      stdCapRange,
+					VolumeCapabilities: stdVolCap,
+					AccessibilityRequirements: &csi.TopologyRequirement{
+						Requisite: []*csi.Topology{
+							{Segments: map[string]string{DiskTypeKey: cloud.VolumeTypeTier1}},
+							{Segments: map[string]string{DiskTypeKey: cloud.VolumeTypeTier3}},
+						},
+						Preferred: []*csi.Topology{
+							{Segments: map[string]string{DiskTypeKey: cloud.VolumeTypeTier3}},
+						},
+					},
+				}
+
+				ctx := context.Background()
+
+				mockDisk := &cloud.Disk{
+					VolumeID:    req.Name,
+					CapacityGiB: util.BytesToGiB(stdVolSize),
+					DiskType:    cloud.VolumeTypeTier3,
+				}
+
+				mockDiskOpts := &cloud.DiskOptions{
+					CapacityBytes: stdVolSize,
+					VolumeType:    cloud.VolumeTypeTier3,
+				}
+
+				mockCtl := gomock.NewController(t)
+				defer mockCtl.Finish()
+
+				mockCloud := mocks.NewMockCloud(mockCtl)
+				mockCloud.EXPECT().GetDiskByName(gomock.Any(), gomock.Eq(req.Name)).Return(nil, nil)
+				mockCloud.EXPECT().CreateDisk(gomock.Any(), gomock.Eq(req.Name), mockDiskOpts).Return(mockDisk, nil)
+
+				powervsDriver := controllerService{
+					cloud:         mockCloud,
+					driverOptions: &Options{},
+					volumeLocks:   util.NewVolumeLocks(),
+					operations:    newOperationTracker(),
+				}
+
+				resp, err := powervsDriver.CreateVolume(ctx, req)
+				if err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+				// the disk type can be modified later, so the volume is not pinned to it
+				if topology := resp.GetVolume().GetAccessibleTopology(); topology != nil {
+					t.Fatalf("Expected no accessible topology, got %v", topology)
+				}
+			},
+		},
+		{
+			name: "success with volume type from requisite topology",
+			testFunc: func(t *testing.T) {
+				req := &csi.CreateVolumeRequest{
+					Name:               "vol-test",
+					CapacityRange:      stdCapRange,
+					VolumeCapabilities: stdVolCap,
+					AccessibilityRequirements: &csi.TopologyRequirement{
+						Requisite: []*csi.Topology{
+							{Segments: map[string]string{DiskTypeKey: cloud.VolumeTypeTier3}},
+						},
+					},
+				}
+
+				ctx := context.Background()
+
+				mockDisk := &cloud.Disk{
+					VolumeID:    req.Name,
+					CapacityGiB: util.BytesToGiB(stdVolSize),
+					DiskType:    cloud.VolumeTypeTier3,
+				}
+
+				mockDiskOpts := &cloud.DiskOptions{
+					CapacityBytes: stdVolSize,
+					VolumeType:    cloud.VolumeTypeTier3,
+				}
+
+				mockCtl := gomock.NewController(t)
+				defer mockCtl.Finish()
+
+				mockCloud := mocks.NewMockCloud(mockCtl)
+				mockCloud.EXPECT().GetDiskByName(gomock.Any(), gomock.Eq(req.Name)).Return(nil, nil)
+				mockCloud.EXPECT().CreateDisk(gomock.Any(), gomock.Eq(req.Name), mockDiskOpts).Return(mockDisk, nil)
+
+				powervsDriver := controllerService{
+					cloud:         mockCloud,
+					driverOptions: &Options{},
+					volumeLocks:   util.NewVolumeLocks(),
+					operations:    newOperationTracker(),
+				}
+
+				if _, err := powervsDriver.CreateVolume(ctx, req); err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+			},
+		},
+		{
+			name: "fail with volume type not accessible from requisite topology",
+			testFunc: func(t *testing.T) {
+				req := &csi.CreateVolumeRequest{
+					Name:               "vol-test",
+					CapacityRange:      stdCapRange,
+					VolumeCapabilities: stdVolCap,
+					Parameters:         map[string]string{VolumeTypeKey: cloud.VolumeTypeTier1},
+					AccessibilityRequirements: &csi.TopologyRequirement{
+						Requisite: []*csi.Topology{
+							{Segments: map[string]string{DiskTypeKey: cloud.VolumeTypeTier3}},
+						},
+					},
+				}
+
+				ctx := context.Background()
+				mockCtl := gomock.NewController(t)
+				defer mockCtl.Finish()
+
+				mockCloud := mocks.NewMockCloud(mockCtl)
+
+				powervsDriver := controllerService{
+					cloud:         mockCloud,
+					driverOptions: &Options{},
+					volumeLocks:   util.NewVolumeLocks(),
+					operations:    newOperationTracker(),
+				}
+
+				_, err := powervsDriver.CreateVolume(ctx, req)
+				checkExpectedErrorCode(t, err, codes.ResourceExhausted)
+			},
+		},
+		{
+			name: "fail locked volume request",
+			testFunc: func(t *testing.T) {
+				req := &csi.CreateVolumeRequest{
+					Name:               "random-vol-name",
+					CapacityRange:      stdCapRange,
+					VolumeCapabilities: stdVolCap,
+					Parameters:         nil,
+				}
+
+				ctx := context.Background()
+				mockCtl := gomock.NewController(t)
+				defer mockCtl.Finish()
+
+				mockCloud := mocks.NewMockCloud(mockCtl)
+
+				powervsDriver := controllerService{
+					cloud:         mockCloud,
+					driverOptions: &Options{},
+					volumeLocks:   util.NewVolumeLocks(),
+					operations:    newOperationTracker(),
+				}
+
+				powervsDriver.volumeLocks.TryAcquire(req.Name)
+				defer powervsDriver.volumeLocks.Release(req.Name)
+
+				_, err := powervsDriver.CreateVolume(ctx, req)
+				checkExpectedErrorCode(t, err, codes.Aborted)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, tc.testFunc)
+	}
+}
+
+func TestDeleteVolume(t *testing.T) {
+	testCases := []struct {
+		name     string
+		testFunc func(t *testing.T)
+	}{
+		{
+			name: "success normal",
+			testFunc: func(t *testing.T) {
+				req := &csi.DeleteVolumeRequest{
+					VolumeId: "vol-test",
+				}
+				expResp := &csi.DeleteVolumeResponse{}
+
+				ctx := context.Background()
+				mockCtl := gomock.NewController(t)
+				defer mockCtl.Finish()
+
+				mockCloud := mocks.NewMockCloud(mockCtl)
+				mockCloud.EXPECT().DeleteDisk(gomock.Any(), gomock.Eq(req.VolumeId)).Return(true, nil)
+				mockCloud.EXPECT().GetDiskByID(gomock.Any(), gomock.Eq(req.VolumeId)).Return(nil, nil)
+				powervsDriver := controllerService{
+					cloud:         mockCloud,
+					driverOptions: &Options{},
+					volumeLocks:   util.NewVolumeLocks(),
+					operations:    newOperationTracker(),
+				}
+				resp, err := powervsDriver.DeleteVolume(ctx, req)
+				if err != nil {
+					srvErr, ok := status.FromError(err)
+					if !ok {
+						t.Fatalf("Could not get error status code from error: %v", srvErr)
+					}
+					t.Fatalf("Unexpected error: %v", srvErr.Code())
+				}
+				if !reflect.DeepEqual(resp, expResp) {
+					t.Fatalf("Expected resp to be %+v, got: %+v", expResp, resp)
+				}
+			},
+		},
+		{
+			name: "success invalid volume id",
+			testFunc: func(t *testing.T) {
+				req := &csi.DeleteVolumeRequest{
+					VolumeId: "invalid-volume-name",
+				}
+				expResp := &csi.DeleteVolumeResponse{}
+
+				ctx := context.Background()
+				mockCtl := gomock.NewController(t)
+				defer mockCtl.Finish()
+
+				mockCloud := mocks.NewMockCloud(mockCtl)
+				mockCloud.EXPECT().GetDiskByID(gomock.Any(), gomock.Eq(req.VolumeId)).Return(nil, cloud.ErrNotFound)
+				powervsDriver := controllerService{
+					cloud:         mockCloud,
+					driverOptions: &Options{},
+					volumeLocks:   util.NewVolumeLocks(),
+					operations:    newOperationTracker(),
+				}
+				resp, err := powervsDriver.DeleteVolume(ctx, req)
+				if err != nil {
+					srvErr, ok := status.FromError(err)
+					if !ok {
+						t.Fatalf("Could not get error status code from error: %v", srvErr)
+					}
+					t.Fatalf("Unexpected error: %v", srvErr.Code())
+				}
+				if !reflect.DeepEqual(resp, expResp) {
+					t.Fatalf("Expected resp to be %+v, got: %+v", expResp, resp)
+				}
+			},
+		},
+		{
+			name: "success when a retried delete finds the volume gone",
+			testFunc: func(t *testing.T) {
+				req := &csi.DeleteVolumeRequest{
+					VolumeId: "vol-test",
+				}
+				expResp := &csi.DeleteVolumeResponse{}
+
+				ctx := context.Background()
+				mockCtl := gomock.NewController(t)
+				defer mockCtl.Finish()
+
+				mockCloud := mocks.NewMockCloud(mockCtl)
+				mockCloud.EXPECT().GetDiskByID(gomock.Any(), gomock.Eq(req.VolumeId)).Return(&cloud.Disk{VolumeID: req.VolumeId}, nil)
+				mockCloud.EXPECT().DeleteDisk(gomock.Any(), gomock.Eq(req.VolumeId)).Return(false, fmt.Errorf("volume not found: %w", cloud.ErrNotFound))
+				powervsDriver := controllerService{
+					cloud:         mockCloud,
+					driverOptions: &Options{},
+					volumeLocks:   util.NewVolumeLocks(),
+					operations:    newOperationTracker(),
+				}
+				resp, err := powervsDriver.DeleteVolume(ctx, req)
+				if err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+				if !reflect.DeepEqual(resp, expResp) {
+					t.Fatalf("Expected resp to be %+v, got: %+v", expResp, resp)
+				}
+			},
+		},
+		{
+			name: "fail delete disk",
+			testFunc: func(t *testing.T) {
+				req := &csi.DeleteVolumeRequest{
+					VolumeId: "test-vol",
+				}
+
+				ctx := context.Background()
+				mockCtl := gomock.NewController(t)
+				defer mockCtl.Finish()
+
+				mockCloud := mocks.NewMockCloud(mockCtl)
+				mockCloud.EXPECT().DeleteDisk(gomock.Any(), gomock.Eq(req.VolumeId)).Return(false, fmt.Errorf("DeleteDisk could not delete volume"))
+				mockCloud.EXPECT().GetDiskByID(gomock.Any(), gomock.Eq(req.VolumeId)).Return(nil, nil)
+				powervsDriver := controllerService{
+					cloud:         mockCloud,
+					driverOptions: &Options{},
+					volumeLocks:   util.NewVolumeLocks(),
+					operations:    newOperationTracker(),
+				}
+				resp, err := powervsDriver.DeleteVolume(ctx, req)
+				if err != nil {
+					srvErr, ok := status.FromError(err)
+					if !ok {
+						t.Fatalf("Could not get error status code from error: %v", srvErr)
+					}
+					if srvErr.Code() != codes.Internal {
+						t.Fatalf("Unexpected error: %v", srvErr.Code())
+					}
+				} else {
+					t.Fatalf("Expected error, got nil")
+				}
+
+				if resp != nil {
+					t.Fatalf("Expected resp to be nil, got: %+v", resp)
+				}
+			},
+		},
+		{
+			name: "fail if volume is already locked",
+			testFunc: func(t *testing.T) {
+				req := &csi.DeleteVolumeRequest{
+					VolumeId: "vol-test",
+				}
+
+				ctx := context.Background()
+				mockCtl := gomock.NewController(t)
+				defer mockCtl.Finish()
+
+				mockCloud := mocks.NewMockCloud(mockCtl)
+
+				powervsDriver := controllerService{
+					cloud:         mockCloud,
+					driverOptions: &Options{},
+					volumeLocks:   util.NewVolumeLocks(),
+					operations:    newOperationTracker(),
+				}
+
+				powervsDriver.volumeLocks.TryAcquire(req.VolumeId)
+				defer powervsDriver.volumeLocks.Release(req.VolumeId)
+
+				_, err := powervsDriver.DeleteVolume(ctx, req)
+				checkExpectedErrorCode(t, err, codes.Aborted)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, tc.testFunc)
+	}
+}
+
+func TestControllerPublishVolume(t *testing.T) {
+	stdVolCap := &csi.VolumeCapability{
+		AccessType: &csi.VolumeCapability_Mount{
+			Mount: &csi.VolumeCapability_MountVolume{},
+		},
+		AccessMode: &csi.VolumeCapability_AccessMode{
+			Mode: csi.VolumeCapability_AccessMode_SINGLE_NODE_WRITER,
+		},
+	}
+	expDevicePath := "/dev/xvda"
+	volumeName := "vol-test"
+
+	testCases := []struct {
+		name     string
+		testFunc func(t *testing.T)
+	}{
+
+		{
+			name: "success normal",
+			testFunc: func(t *testing.T) {
+				req := &csi.ControllerPublishVolumeRequest{
+					NodeId:           expInstanceID,
+					VolumeCapability: stdVolCap,
+					VolumeId:         volumeName,
+				}
+				expResp := &csi.ControllerPublishVolumeResponse{
+					PublishContext: map[string]string{WWNKey: expDevicePath},
+				}
+
+				ctx := context.Background()
+
+				mockCtl := gomock.NewController(t)
+				defer mockCtl.Finish()
+
+				mockCloud := mocks.NewMockCloud(mockCtl)
+				mockCloud.EXPECT().GetPVMInstanceByID(gomock.Any(), gomock.Eq(expInstanceID)).Return(nil, nil)
+				mockCloud.EXPECT().GetDiskByID(gomock.Any(), gomock.Eq(volumeName)).Return(&cloud.Disk{WWN: expDevicePath}, nil)
+				mockCloud.EXPECT().IsAttached(gomock.Any(), gomock.Eq(volumeName), gomock.Eq(expInstanceID)).Return(false, nil)
+				mockCloud.EXPECT().ListPVMInstanceDisks(gomock.Any(), gomock.Eq(expInstanceID)).Return([]*cloud.Disk{{VolumeID: "boot", BootVolume: true}}, nil)
+				mockCloud.EXPECT().AttachDisk(gomock.Any(), gomock.Eq(volumeName), gomock.Eq(expInstanceID)).Return(nil)
+
+				powervsDriver := controllerService{
+					cloud:         mockCloud,
+					driverOptions: &Options{},
+					volumeLocks:   util.NewVolumeLocks(),
+					operations:    newOperationTracker(),
+				}
+
+				resp, err := powervsDriver.ControllerPublishVolume(ctx, req)
+				if err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+
+				if !reflect.DeepEqual(resp, expResp) {
+					t.Fatalf("Expected resp to be %+v, got: %+v", expResp, resp)
+				}
+			},
+		},
+
+		{
+			name: "success read-only",
+			testFunc: func(t *testing.T) {
+				req := &csi.ControllerPublishVolumeRequest{
+					NodeId:           expInstanceID,
+					VolumeCapability: stdVolCap,
+					VolumeId:         volumeName,
+					Readonly:         true,
+				}
+				expResp := &csi.ControllerPublishVolumeResponse{
+					PublishContext: map[string]string{WWNKey: expDevicePath, ReadOnlyKey: "true"},
+				}
+
+				ctx := context.Background()
+
+				mockCtl := gomock.NewController(t)
+				defer mockCtl.Finish()
+
+				mockCloud := mocks.NewMockCloud(mockCtl)
+				mockCloud.EXPECT().GetPVMInstanceByID(gomock.Any(), gomock.Eq(expInstanceID)).Return(nil, nil)
+				mockCloud.EXPECT().GetDiskByID(gomock.Any(), gomock.Eq(volumeName)).Return(&cloud.Disk{WWN: expDevicePath}, nil)
+				mockCloud.EXPECT().IsAttached(gomock.Any(), gomock.Eq(volumeName), gomock.Eq(expInstanceID)).Return(true, nil)
+
+				powervsDriver := controllerService{
+					cloud:         mockCloud,
+					driverOptions: &Options{},
+					volumeLocks:   util.NewVolumeLocks(),
+					operations:    newOperationTracker(),
+				}
+
+				resp, err := powervsDriver.ControllerPublishVolume(ctx, req)
+				if err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+
+				if !reflect.DeepEqual(resp, expResp) {
+					t.Fatalf("Expected resp to be %+v, got: %+v", expResp, resp)
+				}
+			},
+		},
+
+		{
+			name: "fail when the node reached the attach limit",
+			testFunc: func(t *testing.T) {
+				req := &csi.ControllerPublishVolumeRequest{
+					NodeId:           expInstanceID,
+					VolumeCapability: stdVolCap,
+					VolumeId:         volumeName,
+				}
+
+				ctx := context.Background()
+
+				mockCtl := gomock.NewController(t)
+				defer mockCtl.Finish()
+
+				attachedDisks := make([]*cloud.Disk, cloud.MaxVolumesPerInstance)
+				for i := range attachedDisks {
+					attachedDisks[i] = &cloud.Disk{VolumeID: fmt.Sprintf("vol-%d", i)}
+				}
+
+				mockCloud := mocks.NewMockCloud(mockCtl)
+				mockCloud.EXPECT().GetPVMInstanceByID(gomock.Any(), gomock.Eq(expInstanceID)).Return(nil, nil)
+				mockCloud.EXPECT().GetDiskByID(gomock.Any(), gomock.Eq(volumeName)).Return(&cloud.Disk{WWN: expDevicePath}, nil)
+				mockCloud.EXPECT().IsAttached(gomock.Any(), gomock.Eq(volumeName), gomock.Eq(expInstanceID)).Return(false, nil)
+				mockCloud.EXPECT().ListPVMInstanceDisks(gomock.Any(), gomock.Eq(expInstanceID)).Return(attachedDisks, nil)
+
+				powervsDriver := controllerService{
+					cloud:         mockCloud,
+					driverOptions: &Options{},
+					volumeLocks:   util.NewVolumeLocks(),
+					operations:    newOperationTracker(),
+				}
+
+				_, err := powervsDriver.ControllerPublishVolume(ctx, req)
+				checkExpectedErrorCode(t, err, codes.ResourceExhausted)
+			},
+		},
+
+		{
+			name: "fail when the attach limit is reached out of band",
+			testFunc: func(t *testing.T) {
+				req := &csi.ControllerPublishVolumeRequest{
+					NodeId:           expInstanceID,
+					VolumeCapability: stdVolCap,
+					VolumeId:         volumeName,
+				}
+
+				ctx := context.Background()
+
+				mockCtl := gomock.NewController(t)
+				defer mockCtl.Finish()
+
+				attachedDisks := make([]*cloud.Disk, cloud.MaxVolumesPerInstance)
+				for i := range attachedDisks {
+					attachedDisks[i] = &cloud.Disk{VolumeID: fmt.Sprintf("vol-%d", i)}
+				}
+
+				mockCloud := mocks.NewMockCloud(mockCtl)
+				mockCloud.EXPECT().GetPVMInstanceByID(gomock.Any(), gomock.Eq(expInstanceID)).Return(nil, nil)
+				mockCloud.EXPECT().GetDiskByID(gomock.Any(), gomock.Eq(volumeName)).Return(&cloud.Disk{WWN: expDevicePath}, nil)
+				mockCloud.EXPECT().IsAttached(gomock.Any(), gomock.Eq(volumeName), gomock.Eq(expInstanceID)).Return(false, nil)
+				gomock.InOrder(
+					mockCloud.EXPECT().ListPVMInstanceDisks(gomock.Any(), gomock.Eq(expInstanceID)).Return(attachedDisks[1:], nil),
+					mockCloud.EXPECT().AttachDisk(gomock.Any(), gomock.Eq(volumeName), gomock.Eq(expInstanceID)).Return(errors.New("bad request")),
+					mockCloud.EXPECT().ListPVMInstanceDisks(gomock.Any(), gomock.Eq(expInstanceID)).Return(attachedDisks, nil),
+				)
+
+				powervsDriver := controllerService{
+					cloud:         mockCloud,
+					driverOptions: &Options{},
+					volumeLocks:   util.NewVolumeLocks(),
+					operations:    newOperationTracker(),
+				}
+
+				_, err := powervsDriver.ControllerPublishVolume(ctx, req)
+				checkExpectedErrorCode(t, err, codes.ResourceExhausted)
+			},
+		},
+
+		{
+			name: "concurrent attaches to a node are serialized",
+			testFunc: func(t *testing.T) {
+				ctx := context.Background()
+
+				mockCtl := gomock.NewController(t)
+				defer mockCtl.Finish()
+
+				var attaching, overlapped int32
+				mockCloud := mocks.NewMockCloud(mockCtl)
+				mockCloud.EXPECT().GetPVMInstanceByID(gomock.Any(), gomock.Eq(expInstanceID)).Return(nil, nil).Times(2)
+				mockCloud.EXPECT().GetDiskByID(gomock.Any(), gomock.Any()).Return(&cloud.Disk{WWN: expDevicePath}, nil).Times(2)
+				mockCloud.EXPECT().IsAttached(gomock.Any(), gomock.Any(), gomock.Eq(expInstanceID)).Return(false, nil).Times(2)
+				mockCloud.EXPECT().ListPVMInstanceDisks(gomock.Any(), gomock.Eq(expInstanceID)).Return(nil, nil).Times(2)
+				mockCloud.EXPECT().AttachDisk(gomock.Any(), gomock.Any(), gomock.Eq(expInstanceID)).DoAndReturn(
+					func(ctx context.Context, volumeID, nodeID string) error {
+						if atomic.AddInt32(&attaching, 1) > 1 {
+							atomic.StoreInt32(&overlapped, 1)
+						}
+						time.Sleep(50 * time.Millisecond)
+						atomic.AddInt32(&attaching, -1)
+						return nil
+					}).Times(2)
+
+				powervsDriver := controllerService{
+					cloud:         mockCloud,
+					driverOptions: &Options{},
+					volumeLocks:   util.NewVolumeLocks(),
+					operations:    newOperationTracker(),
+				}
+
+				var wg sync.WaitGroup
+				for _, volumeID := range []string{"vol-1", "vol-2"} {
+					wg.Add(1)
+					go func(volumeID string) {
+						defer wg.Done()
+						req := &csi.ControllerPublishVolumeRequest{
+							NodeId:           expInstanceID,
+							VolumeCapability: stdVolCap,
+							VolumeId:         volumeID,
+						}
+						if _, err := powervsDriver.ControllerPublishVolume(ctx, req); err != nil {
+							t.Errorf("Unexpected error: %v", err)
+						}
+					}(volumeID)
+				}
+				wg.Wait()
+
+				if overlapped != 0 {
+					t.Fatal("Expected the attaches to the node not to overlap")
+				}
+			},
+		},
+
+		{
+			name: "fail no VolumeId",
+			testFunc: func(t *testing.T) {
+				req := &csi.ControllerPublishVolumeRequest{}
+
+				ctx := context.Background()
+
+				mockCtl := gomock.NewController(t)
+				defer mockCtl.Finish()
+
+				mockCloud := mocks.NewMockCloud(mockCtl)
+
+				powervsDriver := controllerService{
+					cloud:         mockCloud,
+					driverOptions: &Options{},
+					volumeLocks:   util.NewVolumeLocks(),
+					operations:    newOperationTracker(),
+				}
+
+				if _, err := powervsDriver.ControllerPublishVolume(ctx, req); err != nil {
+					srvErr, ok := status.FromError(err)
+					if !ok {
+						t.Fatalf("Could not get error status code from error: %v", srvErr)
+					}
+					if srvErr.Code() != codes.InvalidArgument {
+						t.Fatalf("Expected error code %d, got %d message %s", codes.InvalidArgument, srvErr.Code(), srvErr.Message())
+					}
+				} else {
+					t.Fatalf("Expected error %v, got no error", codes.InvalidArgument)
+				}
+			},
+		},
+
+		{
+			name: "fail no NodeId",
+			testFunc: func(t *testing.T) {
+				req := &csi.ControllerPublishVolumeRequest{
+					VolumeId: "vol-test",
+				}
+
+				ctx := context.Background()
+
+				mockCtl := gomock.NewController(t)
+				defer mockCtl.Finish()
+
+				mockCloud := mocks.NewMockCloud(mockCtl)
+
+				powervsDriver := controllerService{
+					cloud:         mockCloud,
+					driverOptions: &Options{},
+					volumeLocks:   util.NewVolumeLocks(),
+					operations:    newOperationTracker(),
+				}
+
+				if _, err := powervsDriver.ControllerPublishVolume(ctx, req); err != nil {
+					srvErr, ok := status.FromError(err)
+					if !ok {
+						t.Fatalf("Could not get error status code from error: %v", srvErr)
+					}
+					if srvErr.Code() != codes.InvalidArgument {
+						t.Fatalf("Expected error code %d, got %d message %s", codes.InvalidArgument, srvErr.Code(), srvErr.Message())
+					}
+				} else {
+					t.Fatalf("Expected error %v, got no error", codes.InvalidArgument)
+				}
+			},
+		},
+
+		{
+			name: "fail no VolumeCapability",
+			testFunc: func(t *testing.T) {
+				req := &csi.ControllerPublishVolumeRequest{
+					NodeId:   expInstanceID,
+					VolumeId: "vol-test",
+				}
+
+				ctx := context.Background()
+
+				mockCtl := gomock.NewController(t)
+				defer mockCtl.Finish()
+
+				mockCloud := mocks.NewMockCloud(mockCtl)
+
+				powervsDriver := controllerService{
+					cloud:         mockCloud,
+					driverOptions: &Options{},
+					volumeLocks:   util.NewVolumeLocks(),
+					operations:    newOperationTracker(),
+				}
+
+				if _, err := powervsDriver.ControllerPublishVolume(ctx, req); err != nil {
+					srvErr, ok := status.FromError(err)
+					if !ok {
+						t.Fatalf("Could not get error status code from error: %v", srvErr)
+					}
+					if srvErr.Code() != codes.InvalidArgument {
+						t.Fatalf("Expected error code %d, got %d message %s", codes.InvalidArgument, srvErr.Code(), srvErr.Message())
+					}
+				} else {
+					t.Fatalf("Expected error %v, got no error", codes.InvalidArgument)
+				}
+			},
+		},
+
+		{
+			name: "fail invalid VolumeCapability",
+			testFunc: func(t *testing.T) {
+				req := &csi.ControllerPublishVolumeRequest{
+					NodeId: expInstanceID,
+					VolumeCapability: &csi.VolumeCapability{
+						AccessMode: &csi.VolumeCapability_AccessMode{
+							Mode: csi.VolumeCapability_AccessMode_UNKNOWN,
+						},
+					},
+					VolumeId: "vol-test",
+				}
+
+				ctx := context.Background()
+
+				mockCtl := gomock.NewController(t)
+				defer mockCtl.Finish()
+
+				mockCloud := mocks.NewMockCloud(mockCtl)
+
+				powervsDriver := controllerService{
+					cloud:         mockCloud,
+					driverOptions: &Options{},
+					volumeLocks:   util.NewVolumeLocks(),
+					operations:    newOperationTracker(),
+				}
+
+				if _, err := powervsDriver.ControllerPublishVolume(ctx, req); err != nil {
+					srvErr, ok := status.FromError(err)
+					if !ok {
+						t.Fatalf("Could not get error status code from error: %v", srvErr)
+					}
+					if srvErr.Code() != codes.InvalidArgument {
+						t.Fatalf("Expected error code %d, got %d message %s", codes.InvalidArgument, srvErr.Code(), srvErr.Message())
+					}
+				} else {
+					t.Fatalf("Expected error %v, got no error", codes.InvalidArgument)
+				}
+			},
+		},
+
+		{
+			name: "fail instance not found",
+			testFunc: func(t *testing.T) {
+				req := &csi.ControllerPublishVolumeRequest{
+					NodeId:           "does-not-exist",
+					VolumeId:         "vol-test",
+					VolumeCapability: stdVolCap,
+				}
+
+				ctx := context.Background()
+
+				mockCtl := gomock.NewController(t)
+				defer mockCtl.Finish()
+
+				mockCloud := mocks.NewMockCloud(mockCtl)
+				mockCloud.EXPECT().GetPVMInstanceByID(gomock.Any(), gomock.Eq("does-not-exist")).Return(nil, cloud.ErrNotFound)
+				// mockCloud.EXPECT().IsExistInstance(gomock.Eq(ctx), gomock.Eq(req.NodeId)).Return(false)
+
+				powervsDriver := controllerService{
+					cloud:         mockCloud,
+					driverOptions: &Options{},
+					volumeLocks:   util.NewVolumeLocks(),
+					operations:    newOperationTracker(),
+				}
+
+				if _, err := powervsDriver.ControllerPublishVolume(ctx, req); err != nil {
+					srvErr, ok := status.FromError(err)
+					if !ok {
+						t.Fatalf("Could not get error status code from error: %v", srvErr)
+					}
+					if srvErr.Code() != codes.NotFound {
+						t.Fatalf("Expected error code %d, got %d message %s", codes.NotFound, srvErr.Code(), srvErr.Message())
+					}
+				} else {
+					t.Fatalf("Expected error %v, got no error", codes.NotFound)
+				}
+			},
+		},
+
+		{
+			name: "fail volume not found",
+			testFunc: func(t *testing.T) {
+				req := &csi.ControllerPublishVolumeRequest{
+					VolumeId:         "does-not-exist",
+					NodeId:           expInstanceID,
+					VolumeCapability: stdVolCap,
+				}
+
+				ctx := context.Background()
+
+				mockCtl := gomock.NewController(t)
+				defer mockCtl.Finish()
+
+				mockCloud := mocks.NewMockCloud(mockCtl)
+				mockCloud.EXPECT().GetPVMInstanceByID(gomock.Any(), gomock.Eq(expInstanceID)).Return(nil, nil)
+				mockCloud.EXPECT().GetDiskByID(gomock.Any(), gomock.Eq("does-not-exist")).Return(&cloud.Disk{}, cloud.ErrNotFound)
+
+				powervsDriver := controllerService{
+					cloud:         mockCloud,
+					driverOptions: &Options{},
+					volumeLocks:   util.NewVolumeLocks(),
+					operations:    newOperationTracker(),
+				}
+
+				if _, err := powervsDriver.ControllerPublishVolume(ctx, req); err != nil {
+					srvErr, ok := status.FromError(err)
+					if !ok {
+						t.Fatalf("Could not get error status code from error: %v", srvErr)
+					}
+					if srvErr.Code() != codes.NotFound {
+						t.Fatalf("Expected error code %d, got %d message %s", codes.NotFound, srvErr.Code(), srvErr.Message())
+					}
+				} else {
+					t.Fatalf("Expected error %v, got no error", codes.NotFound)
+				}
+			},
+		},
+		{
+			name: "fail if volume already locked",
+			testFunc: func(t *testing.T) {
+				req := &csi.ControllerPublishVolumeRequest{
+					NodeId:           expInstanceID,
+					VolumeCapability: stdVolCap,
+					VolumeId:         volumeName,
+				}
+
+				ctx := context.Background()
+				mockCtl := gomock.NewController(t)
+				defer mockCtl.Finish()
+
+				mockCloud := mocks.NewMockCloud(mockCtl)
+				powervsDriver := controllerService{
+					cloud:         mockCloud,
+					driverOptions: &Options{},
+					volumeLocks:   util.NewVolumeLocks(),
+					operations:    newOperationTracker(),
+				}
+
+				powervsDriver.volumeLocks.TryAcquire(req.VolumeId)
+				defer powervsDriver.volumeLocks.Release(req.VolumeId)
+
+				_, err := powervsDriver.ControllerPublishVolume(ctx, req)
+				checkExpectedErrorCode(t, err, codes.Aborted)
+
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, tc.testFunc)
+	}
+}
+
+func TestControllerUnpublishVolume(t *testing.T) {
+	expDevicePath := "/dev/xvda"
+	testCases := []struct {
+		name     string
+		testFunc func(t *testing.T)
+	}{
+		{
+			name: "success normal",
+			testFunc: func(t *testing.T) {
+				req := &csi.ControllerUnpublishVolumeRequest{
+					NodeId:   expInstanceID,
+					VolumeId: "vol-test",
+				}
+				expResp := &csi.ControllerUnpublishVolumeResponse{}
+
+				ctx := context.Background()
+
+				mockCtl := gomock.NewController(t)
+				defer mockCtl.Finish()
+
+				mockCloud := mocks.NewMockCloud(mockCtl)
+				mockCloud.EXPECT().GetDiskByID(gomock.Any(), gomock.Eq("vol-test")).Return(&cloud.Disk{WWN: expDevicePath}, nil)
+				mockCloud.EXPECT().IsAttached(gomock.Any(), gomock.Eq("vol-test"), gomock.Eq(expInstanceID)).Return(true, nil)
+				mockCloud.EXPECT().DetachDisk(gomock.Any(), req.VolumeId, req.NodeId).Return(nil)
+
+				powervsDriver := controllerService{
+					cloud:         mockCloud,
+					driverOptions: &Options{},
+					volumeLocks:   util.NewVolumeLocks(),
+					operations:    newOperationTracker(),
+				}
+
+				resp, err := powervsDriver.ControllerUnpublishVolume(ctx, req)
+				if err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+
+				if !reflect.DeepEqual(resp, expResp) {
+					t.Fatalf("Expected resp to be %+v, got: %+v", expResp, resp)
+				}
+			},
+		},
+		{
+			name: "success when a retried detach finds the volume detached",
+			testFunc: func(t *testing.T) {
+				req := &csi.ControllerUnpublishVolumeRequest{
+					NodeId:   expInstanceID,
+					VolumeId: "vol-test",
+				}
+				expResp := &csi.ControllerUnpublishVolumeResponse{}
+
+				ctx := context.Background()
+
+				mockCtl := gomock.NewController(t)
+				defer mockCtl.Finish()
+
+				mockCloud := mocks.NewMockCloud(mockCtl)
+				mockCloud.EXPECT().GetDiskByID(gomock.Any(), gomock.Eq("vol-test")).Return(&cloud.Disk{WWN: expDevicePath}, nil)
+				mockCloud.EXPECT().IsAttached(gomock.Any(), gomock.Eq("vol-test"), gomock.Eq(expInstanceID)).Return(true, nil)
+				mockCloud.EXPECT().DetachDisk(gomock.Any(), req.VolumeId, req.NodeId).Return(fmt.Errorf("volume not attached: %w", cloud.ErrNotFound))
+
+				powervsDriver := controllerService{
+					cloud:         mockCloud,
+					driverOptions: &Options{},
+					volumeLocks:   util.NewVolumeLocks(),
+					operations:    newOperationTracker(),
+				}
+
+				resp, err := powervsDriver.ControllerUnpublishVolume(ctx, req)
+				if err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+
+				if !reflect.DeepEqual(resp, expResp) {
+					t.Fatalf("Expected resp to be %+v, got: %+v", expResp, resp)
+				}
+			},
+		},
+		{
+			name: "fail when the attachment check is throttled",
+			testFunc: func(t *testing.T) {
+				req := &csi.ControllerUnpublishVolumeRequest{
+					NodeId:   expInstanceID,
+					VolumeId: "vol-test",
+				}
+
+				ctx := context.Background()
+
+				mockCtl := gomock.NewController(t)
+				defer mockCtl.Finish()
+
+				mockCloud := mocks.NewMockCloud(mockCtl)
+				mockCloud.EXPECT().GetDiskByID(gomock.Any(), gomock.Eq("vol-test")).Return(&cloud.Disk{WWN: expDevicePath}, nil)
+				mockCloud.EXPECT().IsAttached(gomock.Any(), gomock.Eq("vol-test"), gomock.Eq(expInstanceID)).Return(false, cloud.ErrThrottled)
+
+				powervsDriver := controllerService{
+					cloud:         mockCloud,
+					driverOptions: &Options{},
+					volumeLocks:   util.NewVolumeLocks(),
+					operations:    newOperationTracker(),
+				}
+
+				_, err := powervsDriver.ControllerUnpublishVolume(ctx, req)
+				checkExpectedErrorCode(t, err, codes.Unavailable)
+			},
+		},
+		{
+			name: "success when resource is not found",
+			testFunc: func(t *testing.T) {
+				req := &csi.ControllerUnpublishVolumeRequest{
+					NodeId:   expInstanceID,
+					VolumeId: "vol-test",
+				}
+				expResp := &csi.ControllerUnpublishVolumeResponse{}
+
+				ctx := context.Background()
+
+				mockCtl := gomock.NewController(t)
+				defer mockCtl.Finish()
+
+				mockCloud := mocks.NewMockCloud(mockCtl)
+				mockCloud.EXPECT().GetDiskByID(gomock.Any(), gomock.Eq("vol-test")).Return(&cloud.Disk{WWN: expDevicePath}, cloud.ErrNotFound)
+
+				powervsDriver := controllerService{
+					cloud:         mockCloud,
+					driverOptions: &Options{},
+					volumeLocks:   util.NewVolumeLocks(),
+					operations:    newOperationTracker(),
+				}
+				resp, err := powervsDriver.ControllerUnpublishVolume(ctx, req)
+				if err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+
+				if !reflect.DeepEqual(resp, expResp) {
+					t.Fatalf("Expected resp to be %+v, got: %+v", expResp, resp)
+				}
+			},
+		},
+
+		{
+			name: "fail no VolumeId",
+			testFunc: func(t *testing.T) {
+				req := &csi.ControllerUnpublishVolumeRequest{}
+
+				ctx := context.Background()
+
+				mockCtl := gomock.NewController(t)
+				defer mockCtl.Finish()
+
+				mockCloud := mocks.NewMockCloud(mockCtl)
+
+				powervsDriver := controllerService{
+					cloud:         mockCloud,
+					driverOptions: &Options{},
+					volumeLocks:   util.NewVolumeLocks(),
+					operations:    newOperationTracker(),
+				}
+
+				if _, err := powervsDriver.ControllerUnpublishVolume(ctx, req); err != nil {
+					srvErr, ok := status.FromError(err)
+					if !ok {
+						t.Fatalf("Could not get error status code from error: %v", srvErr)
+					}
+					if srvErr.Code() != codes.InvalidArgument {
+						t.Fatalf("Expected error code %d, got %d message %s", codes.InvalidArgument, srvErr.Code(), srvErr.Message())
+					}
+				} else {
+					t.Fatalf("Expected error %v, got no error", codes.InvalidArgument)
+				}
+			},
+		},
+		{
+			name: "fail no NodeId",
+			testFunc: func(t *testing.T) {
+				req := &csi.ControllerUnpublishVolumeRequest{
+					VolumeId: "vol-test",
+				}
+
+				ctx := context.Background()
+
+				mockCtl := gomock.NewController(t)
+				defer mockCtl.Finish()
+
+				mockCloud := mocks.NewMockCloud(mockCtl)
+
+				powervsDriver := controllerService{
+					cloud:         mockCloud,
+					driverOptions: &Options{},
+					volumeLocks:   util.NewVolumeLocks(),
+					operations:    newOperationTracker(),
+				}
+
+				if _, err := powervsDriver.ControllerUnpublishVolume(ctx, req); err != nil {
+					srvErr, ok := status.FromError(err)
+					if !ok {
+						t.Fatalf("Could not get error status code from error: %v", srvErr)
+					}
+					if srvErr.Code() != codes.InvalidArgument {
+						t.Fatalf("Expected error code %d, got %d message %s", codes.InvalidArgument, srvErr.Code(), srvErr.Message())
+					}
+				} else {
 					t.Fatalf("Expected error %v, got no error", codes.InvalidArgument)
 				}
 			},
 		},
+		{
+			name: "fail if volume already locked",
+			testFunc: func(t *testing.T) {
+				req := &csi.ControllerUnpublishVolumeRequest{
+					NodeId:   expInstanceID,
+					VolumeId: "vol-test",
+				}
+
+				ctx := context.Background()
+				mockCtl := gomock.NewController(t)
+				defer mockCtl.Finish()
+
+				mockCloud := mocks.NewMockCloud(mockCtl)
+				powervsDriver := controllerService{
+					cloud:         mockCloud,
+					driverOptions: &Options{},
+					volumeLocks:   util.NewVolumeLocks(),
+					operations:    newOperationTracker(),
+				}
+
+				powervsDriver.volumeLocks.TryAcquire(req.VolumeId)
+				defer powervsDriver.volumeLocks.Release(req.VolumeId)
+
+				_, err := powervsDriver.ControllerUnpublishVolume(ctx, req)
+				checkExpectedErrorCode(t, err, codes.Aborted)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, tc.testFunc)
+	}
+}
+
+func TestControllerExpandVolume(t *testing.T) {
+	testCases := []struct {
+		name       string
+		req        *csi.ControllerExpandVolumeRequest
+		newSize    int64
+		expResp    *csi.ControllerExpandVolumeResponse
+		expError   bool
+		volumeLock bool
+	}{
+		{
+			name: "success normal",
+			req: &csi.ControllerExpandVolumeRequest{
+				VolumeId: "vol-test",
+				CapacityRange: &csi.CapacityRange{
+					RequiredBytes: 5 * util.GiB,
+				},
+			},
+			expResp: &csi.ControllerExpandVolumeResponse{
+				CapacityBytes: 5 * util.GiB,
+			},
+		},
+		{
+			name:     "fail empty request",
+			req:      &csi.ControllerExpandVolumeRequest{},
+			expError: true,
+		},
+		{
+			name: "fail exceeds limit after round up",
+			req: &csi.ControllerExpandVolumeRequest{
+				VolumeId: "vol-test",
+				CapacityRange: &csi.CapacityRange{
+					RequiredBytes: 5*util.GiB + 1, // should round up to 6 GiB
+					LimitBytes:    5 * util.GiB,
+				},
+			},
+			expError: true,
+		},
+		{
+			name: "fail if volume already locked",
+			req: &csi.ControllerExpandVolumeRequest{
+				VolumeId: "vol-test",
+				CapacityRange: &csi.CapacityRange{
+					RequiredBytes: 5 * util.GiB,
+				},
+			},
+			volumeLock: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			mockCtl := gomock.NewController(t)
+			defer mockCtl.Finish()
+
+			var retSizeGiB int64
+			if tc.newSize != 0 {
+				retSizeGiB = tc.newSize
+			} else {
+				retSizeGiB = util.BytesToGiB(tc.req.CapacityRange.GetRequiredBytes())
+			}
+
+			mockCloud := mocks.NewMockCloud(mockCtl)
+			powervsDriver := controllerService{
+				cloud:         mockCloud,
+				driverOptions: &Options{},
+				volumeLocks:   util.NewVolumeLocks(),
+				operations:    newOperationTracker(),
+			}
+
+			if tc.volumeLock {
+				powervsDriver.volumeLocks.TryAcquire(tc.req.VolumeId)
+				defer powervsDriver.volumeLocks.Release(tc.req.VolumeId)
+
+				_, err := powervsDriver.ControllerExpandVolume(ctx, tc.req)
+				checkExpectedErrorCode(t, err, codes.Aborted)
+
+			} else {
+				mockCloud.EXPECT().ResizeDisk(gomock.Any(), gomock.Eq(tc.req.VolumeId), gomock.Any()).Return(retSizeGiB, nil).AnyTimes()
+				resp, err := powervsDriver.ControllerExpandVolume(ctx, tc.req)
+				if err != nil {
+					srvErr, ok := status.FromError(err)
+					if !ok {
+						t.Fatalf("Could not get error status code from error: %v", srvErr)
+					}
+					if !tc.expError {
+						t.Fatalf("Unexpected error: %v", err)
+					}
+				} else {
+					if tc.expError {
+						t.Fatalf("Expected error from ControllerExpandVolume, got nothing")
+					}
+				}
+
+				sizeGiB := util.BytesToGiB(resp.GetCapacityBytes())
+				expSizeGiB := util.BytesToGiB(tc.expResp.GetCapacityBytes())
+				if sizeGiB != expSizeGiB {
+					t.Fatalf("Expected size %d GiB, got %d GiB", expSizeGiB, sizeGiB)
+				}
+			}
+
+		})
+	}
+}
+
+func TestListVolumes(t *testing.T) {
+	mockDisks := []*cloud.Disk{
+		{VolumeID: "vol-3", CapacityGiB: 1},
+		{VolumeID: "vol-1", CapacityGiB: 1, PVMInstanceIDs: []string{"node-1"}},
+		{VolumeID: "vol-2", CapacityGiB: 1, PVMInstanceIDs: []string{"node-1", "node-2"}},
+	}
+
+	testCases := []struct {
+		name         string
+		req          *csi.ListVolumesRequest
+		expIDs       []string
+		expNodeIDs   [][]string
+		expNextToken string
+		expErrCode   codes.Code
+	}{
+		{
+			name:       "success list all",
+			req:        &csi.ListVolumesRequest{},
+			expIDs:     []string{"vol-1", "vol-2", "vol-3"},
+			expNodeIDs: [][]string{{"node-1"}, {"node-1", "node-2"}, nil},
+		},
+		{
+			name:         "success with max entries",
+			req:          &csi.ListVolumesRequest{MaxEntries: 2},
+			expIDs:       []string{"vol-1", "vol-2"},
+			expNodeIDs:   [][]string{{"node-1"}, {"node-1", "node-2"}},
+			expNextToken: "2",
+		},
+		{
+			name:       "success with starting token",
+			req:        &csi.ListVolumesRequest{MaxEntries: 2, StartingToken: "2"},
+			expIDs:     []string{"vol-3"},
+			expNodeIDs: [][]string{nil},
+		},
+		{
+			name:       "fail invalid starting token",
+			req:        &csi.ListVolumesRequest{StartingToken: "invalid-token"},
+			expErrCode: codes.Aborted,
+		},
+		{
+			name:       "fail starting token out of range",
+			req:        &csi.ListVolumesRequest{StartingToken: "4"},
+			expErrCode: codes.Aborted,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			mockCtl := gomock.NewController(t)
+			defer mockCtl.Finish()
+
+			mockCloud := mocks.NewMockCloud(mockCtl)
+			mockCloud.EXPECT().ListDisks(gomock.Any()).Return(append([]*cloud.Disk{}, mockDisks...), nil)
+
+			powervsDriver := controllerService{
+				cloud:         mockCloud,
+				driverOptions: &Options{},
+				volumeLocks:   util.NewVolumeLocks(),
+				operations:    newOperationTracker(),
+			}
+
+			resp, err := powervsDriver.ListVolumes(ctx, tc.req)
+			if tc.expErrCode != codes.OK {
+				checkExpectedErrorCode(t, err, tc.expErrCode)
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			var ids []string
+			var nodeIDs [][]string
+			for _, e := range resp.GetEntries() {
+				ids = append(ids, e.GetVolume().GetVolumeId())
+				nodeIDs = append(nodeIDs, e.GetStatus().GetPublishedNodeIds())
+			}
+			if !reflect.DeepEqual(ids, tc.expIDs) {
+				t.Fatalf("Expected volumes %v, got %v", tc.expIDs, ids)
+			}
+			if !reflect.DeepEqual(nodeIDs, tc.expNodeIDs) {
+				t.Fatalf("Expected published nodes %v, got %v", tc.expNodeIDs, nodeIDs)
+			}
+			if resp.GetNextToken() != tc.expNextToken {
+				t.Fatalf("Expected next token %q, got %q", tc.expNextToken, resp.GetNextToken())
+			}
+		})
+	}
+}
+
+func TestControllerModifyVolume(t *testing.T) {
+	shareable := true
+	testCases := []struct {
+		name              string
+		volumeID          string
+		mutableParameters map[string]string
+		mockDisk          *cloud.Disk
+		mockGetErr        error
+		expModifyOptions  *cloud.ModifyDiskOptions
+		mockModifyErr     error
+		expErrCode        codes.Code
+	}{
+		{
+			name:              "success change volume type",
+			volumeID:          "vol-test",
+			mutableParameters: map[string]string{VolumeTypeKey: cloud.VolumeTypeTier1},
+			mockDisk:          &cloud.Disk{VolumeID: "vol-test", DiskType: cloud.VolumeTypeTier3, CapacityGiB: 5},
+			expModifyOptions:  &cloud.ModifyDiskOptions{VolumeType: cloud.VolumeTypeTier1},
+		},
+		{
+			name:              "success make volume shareable",
+			volumeID:          "vol-test",
+			mutableParameters: map[string]string{ShareableKey: "true"},
+			mockDisk:          &cloud.Disk{VolumeID: "vol-test", DiskType: cloud.VolumeTypeTier3, CapacityGiB: 5},
+			expModifyOptions:  &cloud.ModifyDiskOptions{Shareable: &shareable},
+		},
+		{
+			name:       "fail no VolumeId",
+			expErrCode: codes.InvalidArgument,
+		},
+		{
+			name:              "fail immutable parameter",
+			volumeID:          "vol-test",
+			mutableParameters: map[string]string{"csi.storage.k8s.io/fstype": "xfs"},
+			expErrCode:        codes.InvalidArgument,
+		},
+		{
+			name:              "fail invalid shareable value",
+			volumeID:          "vol-test",
+			mutableParameters: map[string]string{ShareableKey: "maybe"},
+			expErrCode:        codes.InvalidArgument,
+		},
+		{
+			name:              "fail unknown volume type",
+			volumeID:          "vol-test",
+			mutableParameters: map[string]string{VolumeTypeKey: "tier9"},
+			mockDisk:          &cloud.Disk{VolumeID: "vol-test", DiskType: cloud.VolumeTypeTier3, CapacityGiB: 5},
+			expModifyOptions:  &cloud.ModifyDiskOptions{VolumeType: "tier9"},
+			mockModifyErr:     fmt.Errorf("invalid PowerVS VolumeType %q: %w", "tier9", cloud.ErrUnsupportedVolumeType),
+			expErrCode:        codes.InvalidArgument,
+		},
+		{
+			name:              "fail volume not found",
+			volumeID:          "vol-test",
+			mutableParameters: map[string]string{VolumeTypeKey: cloud.VolumeTypeTier1},
+			mockGetErr:        cloud.ErrNotFound,
+			expErrCode:        codes.NotFound,
+		},
+		{
+			name:              "fail non-shareable volume attached to several nodes",
+			volumeID:          "vol-test",
+			mutableParameters: map[string]string{ShareableKey: "false"},
+			mockDisk:          &cloud.Disk{VolumeID: "vol-test", Shareable: true, PVMInstanceIDs: []string{"node-1", "node-2"}},
+			expErrCode:        codes.FailedPrecondition,
+		},
+		{
+			name:              "fail modify disk",
+			volumeID:          "vol-test",
+			mutableParameters: map[string]string{VolumeTypeKey: cloud.VolumeTypeTier1},
+			mockDisk:          &cloud.Disk{VolumeID: "vol-test", DiskType: cloud.VolumeTypeTier3, CapacityGiB: 5},
+			expModifyOptions:  &cloud.ModifyDiskOptions{VolumeType: cloud.VolumeTypeTier1},
+			mockModifyErr:     fmt.Errorf("ModifyDisk failed"),
+			expErrCode:        codes.Internal,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			mockCtl := gomock.NewController(t)
+			defer mockCtl.Finish()
+
+			mockCloud := mocks.NewMockCloud(mockCtl)
+			if tc.mockDisk != nil || tc.mockGetErr != nil {
+				mockCloud.EXPECT().GetDiskByID(gomock.Any(), gomock.Eq(tc.volumeID)).Return(tc.mockDisk, tc.mockGetErr)
+			}
+			if tc.expModifyOptions != nil {
+				mockCloud.EXPECT().ModifyDisk(gomock.Any(), gomock.Eq(tc.volumeID), gomock.Eq(tc.expModifyOptions)).Return(tc.mockDisk, tc.mockModifyErr)
+			}
+
+			powervsDriver := controllerService{
+				cloud:         mockCloud,
+				driverOptions: &Options{},
+				volumeLocks:   util.NewVolumeLocks(),
+				operations:    newOperationTracker(),
+			}
+
+			req := &csi.ControllerModifyVolumeRequest{
+				VolumeId:          tc.volumeID,
+				MutableParameters: tc.mutableParameters,
+			}
+			_, err := powervsDriver.ControllerModifyVolume(ctx, req)
+			if tc.expErrCode != codes.OK {
+				checkExpectedErrorCode(t, err, tc.expErrCode)
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestControllerGetVolume(t *testing.T) {
+	testCases := []struct {
+		name       string
+		req        *csi.ControllerGetVolumeRequest
+		mockDisk   *cloud.Disk
+		mockErr    error
+		expErrCode codes.Code
+	}{
+		{
+			name: "success normal",
+			req:  &csi.ControllerGetVolumeRequest{VolumeId: "vol-test"},
+			mockDisk: &cloud.Disk{
+				VolumeID:       "vol-test",
+				CapacityGiB:    5,
+				PVMInstanceIDs: []string{"node-1"},
+				State:          cloud.VolumeInUseState,
+			},
+		},
+		{
+			name:       "fail volume not found",
+			req:        &csi.ControllerGetVolumeRequest{VolumeId: "vol-test"},
+			mockErr:    cloud.ErrNotFound,
+			expErrCode: codes.NotFound,
+		},
+		{
+			name:       "fail get disk",
+			req:        &csi.ControllerGetVolumeRequest{VolumeId: "vol-test"},
+			mockErr:    fmt.Errorf("GetDiskByID failed"),
+			expErrCode: codes.Internal,
+		},
+		{
+			name:       "fail no VolumeId",
+			req:        &csi.ControllerGetVolumeRequest{},
+			expErrCode: codes.InvalidArgument,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			mockCtl := gomock.NewController(t)
+			defer mockCtl.Finish()
+
+			mockCloud := mocks.NewMockCloud(mockCtl)
+			if len(tc.req.VolumeId) != 0 {
+				mockCloud.EXPECT().GetDiskByID(gomock.Any(), gomock.Eq(tc.req.VolumeId)).Return(tc.mockDisk, tc.mockErr)
+			}
+
+			powervsDriver := controllerService{
+				cloud:         mockCloud,
+				driverOptions: &Options{},
+				volumeLocks:   util.NewVolumeLocks(),
+				operations:    newOperationTracker(),
+			}
+
+			resp, err := powervsDriver.ControllerGetVolume(ctx, tc.req)
+			if tc.expErrCode != codes.OK {
+				checkExpectedErrorCode(t, err, tc.expErrCode)
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if resp.GetVolume().GetCapacityBytes() != util.GiBToBytes(tc.mockDisk.CapacityGiB) {
+				t.Fatalf("Expected capacity %d, got %d", util.GiBToBytes(tc.mockDisk.CapacityGiB), resp.GetVolume().GetCapacityBytes())
+			}
+			if !reflect.DeepEqual(resp.GetStatus().GetPublishedNodeIds(), tc.mockDisk.PVMInstanceIDs) {
+				t.Fatalf("Expected published nodes %v, got %v", tc.mockDisk.PVMInstanceIDs, resp.GetStatus().GetPublishedNodeIds())
+			}
+			if resp.GetStatus().GetVolumeCondition().GetAbnormal() {
+				t.Fatalf("Expected normal volume condition, got %+v", resp.GetStatus().GetVolumeCondition())
+			}
+		})
+	}
+}
+
+func TestNewVolumeCondition(t *testing.T) {
+	testCases := []struct {
+		name        string
+		disk        *cloud.Disk
+		expAbnormal bool
+	}{
+		{
+			name: "available volume",
+			disk: &cloud.Disk{State: cloud.VolumeAvailableState},
+		},
+		{
+			name: "in-use volume",
+			disk: &cloud.Disk{State: cloud.VolumeInUseState},
+		},
+		{
+			name:        "volume in error state",
+			disk:        &cloud.Disk{State: cloud.VolumeErrorState},
+			expAbnormal: true,
+		},
+		{
+			name:        "volume being resized",
+			disk:        &cloud.Disk{State: cloud.VolumeResizingState},
+			expAbnormal: true,
+		},
+		{
+			name:        "volume missing on the storage controller",
+			disk:        &cloud.Disk{State: cloud.VolumeAvailableState, OutOfBandDeleted: true},
+			expAbnormal: true,
+		},
+		{
+			name: "volume recently in transitional state",
+			disk: &cloud.Disk{State: "updating", LastUpdateTime: time.Now()},
+		},
+		{
+			name:        "volume stuck in transitional state",
+			disk:        &cloud.Disk{State: "updating", LastUpdateTime: time.Now().Add(-2 * volumeTransitionTimeout)},
+			expAbnormal: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			condition := newVolumeCondition(tc.disk)
+			if condition.GetAbnormal() != tc.expAbnormal {
+				t.Fatalf("Expected abnormal to be %t, got %t: %s", tc.expAbnormal, condition.GetAbnormal(), condition.GetMessage())
+			}
+		})
+	}
+}
+
+func TestGetCapacity(t *testing.T) {
+	stdVolCap := &csi.VolumeCapability{
+		AccessType: &csi.VolumeCapability_Mount{
+			Mount: &csi.VolumeCapability_MountVolume{},
+		},
+		AccessMode: &csi.VolumeCapability_AccessMode{
+			Mode: csi.VolumeCapability_AccessMode_SINGLE_NODE_WRITER,
+		},
+	}
+	mockCapacity := &cloud.StorageCapacity{
+		AvailableCapacityGiB: 100,
+		MaximumVolumeSizeGiB: 40,
+	}
+	testCases := []struct {
+		name          string
+		req           *csi.GetCapacityRequest
+		expVolumeType string
+		expCapacity   bool
+		mockErr       error
+		expErrCode    codes.Code
+	}{
+		{
+			name:        "success all volume types",
+			req:         &csi.GetCapacityRequest{},
+			expCapacity: true,
+		},
+		{
+			name: "success volume type from parameters",
+			req: &csi.GetCapacityRequest{
+				VolumeCapabilities: []*csi.VolumeCapability{stdVolCap},
+				Parameters:         map[string]string{VolumeTypeKey: cloud.VolumeTypeTier3},
+			},
+			expVolumeType: cloud.VolumeTypeTier3,
+			expCapacity:   true,
+		},
+		{
+			name: "success volume type from topology",
+			req: &csi.GetCapacityRequest{
+				AccessibleTopology: &csi.Topology{Segments: map[string]string{DiskTypeKey: cloud.VolumeTypeTier1}},
+			},
+			expVolumeType: cloud.VolumeTypeTier1,
+			expCapacity:   true,
+		},
+		{
+			name: "success no capacity when volume type does not match topology",
+			req: &csi.GetCapacityRequest{
+				Parameters:         map[string]string{VolumeTypeKey: cloud.VolumeTypeTier3},
+				AccessibleTopology: &csi.Topology{Segments: map[string]string{DiskTypeKey: cloud.VolumeTypeTier1}},
+			},
+		},
+		{
+			name: "success no capacity for unsupported volume capabilities",
+			req: &csi.GetCapacityRequest{
+				VolumeCapabilities: []*csi.VolumeCapability{
+					{
+						AccessType: stdVolCap.AccessType,
+						AccessMode: &csi.VolumeCapability_AccessMode{
+							Mode: csi.VolumeCapability_AccessMode_MULTI_NODE_SINGLE_WRITER,
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "fail get storage capacity",
+			req: &csi.GetCapacityRequest{
+				Parameters: map[string]string{VolumeTypeKey: cloud.VolumeTypeTier1},
+			},
+			expVolumeType: cloud.VolumeTypeTier1,
+			expCapacity:   true,
+			mockErr:       fmt.Errorf("GetStorageCapacity failed"),
+			expErrCode:    codes.Internal,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			mockCtl := gomock.NewController(t)
+			defer mockCtl.Finish()
+
+			mockCloud := mocks.NewMockCloud(mockCtl)
+			if tc.expCapacity {
+				mockCloud.EXPECT().GetStorageCapacity(gomock.Any(), gomock.Eq(tc.expVolumeType)).Return(mockCapacity, tc.mockErr)
+			}
+
+			powervsDriver := controllerService{
+				cloud:         mockCloud,
+				driverOptions: &Options{},
+				volumeLocks:   util.NewVolumeLocks(),
+				operations:    newOperationTracker(),
+			}
+
+			resp, err := powervsDriver.GetCapacity(ctx, tc.req)
+			if tc.expErrCode != codes.OK {
+				checkExpectedErrorCode(t, err, tc.expErrCode)
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			var expAvailable, expMaximum int64
+			if tc.expCapacity {
+				expAvailable = util.GiBToBytes(mockCapacity.AvailableCapacityGiB)
+				expMaximum = util.GiBToBytes(mockCapacity.MaximumVolumeSizeGiB)
+			}
+			if resp.GetAvailableCapacity() != expAvailable {
+				t.Fatalf("Expected available capacity %d, got %d", expAvailable, resp.GetAvailableCapacity())
+			}
+			if resp.GetMaximumVolumeSize().GetValue() != expMaximum {
+				t.Fatalf("Expected maximum volume size %d, got %d", expMaximum, resp.GetMaximumVolumeSize().GetValue())
+			}
+		})
+	}
+}
 
+func TestCreateSnapshot(t *testing.T) {
+	testCases := []struct {
+		name     string
+		testFunc func(t *testing.T)
+	}{
 		{
-			name: "fail no NodeId",
+			name: "success normal",
 			testFunc: func(t *testing.T) {
-				req := &csi.ControllerPublishVolumeRequest{
-					VolumeId: "vol-test",
+				req := &csi.CreateSnapshotRequest{
+					Name:           "test-snapshot",
+					SourceVolumeId: "vol-test",
 				}
 
 				ctx := context.Background()
+				mockDisk := &cloud.Disk{
+					VolumeID:    req.SourceVolumeId,
+					CapacityGiB: 5,
+				}
+				mockSnapshot := &cloud.Snapshot{
+					SnapshotID:     "snap-test",
+					SourceVolumeID: req.SourceVolumeId,
+					Name:           req.Name,
+				}
 
 				mockCtl := gomock.NewController(t)
 				defer mockCtl.Finish()
 
 				mockCloud := mocks.NewMockCloud(mockCtl)
+				mockCloud.EXPECT().GetDiskByID(gomock.Any(), gomock.Eq(req.SourceVolumeId)).Return(mockDisk, nil)
+				mockCloud.EXPECT().GetSnapshotByName(gomock.Any(), gomock.Eq(req.Name)).Return(nil, cloud.ErrNotFound)
+				mockCloud.EXPECT().CreateSnapshot(gomock.Any(), gomock.Eq(req.SourceVolumeId), gomock.Eq(req.Name)).Return(mockSnapshot, nil)
+				mockCloud.EXPECT().WaitForSnapshotState(gomock.Any(), gomock.Eq(mockSnapshot.SnapshotID), gomock.Eq(cloud.SnapshotAvailableState)).Return(nil)
 
 				powervsDriver := controllerService{
 					cloud:         mockCloud,
 					driverOptions: &Options{},
 					volumeLocks:   util.NewVolumeLocks(),
+					operations:    newOperationTracker(),
 				}
 
-				if _, err := powervsDriver.ControllerPublishVolume(ctx, req); err != nil {
-					srvErr, ok := status.FromError(err)
-					if !ok {
-						t.Fatalf("Could not get error status code from error: %v", srvErr)
-					}
-					if srvErr.Code() != codes.InvalidArgument {
-						t.Fatalf("Expected error code %d, got %d message %s", codes.InvalidArgument, srvErr.Code(), srvErr.Message())
-					}
-				} else {
-					t.Fatalf("Expected error %v, got no error", codes.InvalidArgument)
+				resp, err := powervsDriver.CreateSnapshot(ctx, req)
+				if err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+				snap := resp.GetSnapshot()
+				if snap.GetSnapshotId() != mockSnapshot.SnapshotID {
+					t.Fatalf("Expected snapshot ID %q, got %q", mockSnapshot.SnapshotID, snap.GetSnapshotId())
+				}
+				if !snap.GetReadyToUse() {
+					t.Fatalf("Expected snapshot to be ready to use")
+				}
+				if snap.GetSizeBytes() != util.GiBToBytes(mockDisk.CapacityGiB) {
+					t.Fatalf("Expected size %d, got %d", util.GiBToBytes(mockDisk.CapacityGiB), snap.GetSizeBytes())
 				}
 			},
 		},
-
 		{
-			name: "fail no VolumeCapability",
+			name: "success same name and same source volume",
 			testFunc: func(t *testing.T) {
-				req := &csi.ControllerPublishVolumeRequest{
-					NodeId:   expInstanceID,
-					VolumeId: "vol-test",
+				req := &csi.CreateSnapshotRequest{
+					Name:           "test-snapshot",
+					SourceVolumeId: "vol-test",
 				}
 
 				ctx := context.Background()
+				mockSnapshot := &cloud.Snapshot{
+					SnapshotID:     "snap-test",
+					SourceVolumeID: req.SourceVolumeId,
+					Name:           req.Name,
+					ReadyToUse:     true,
+				}
 
 				mockCtl := gomock.NewController(t)
 				defer mockCtl.Finish()
 
 				mockCloud := mocks.NewMockCloud(mockCtl)
+				mockCloud.EXPECT().GetDiskByID(gomock.Any(), gomock.Eq(req.SourceVolumeId)).Return(&cloud.Disk{VolumeID: req.SourceVolumeId}, nil)
+				mockCloud.EXPECT().GetSnapshotByName(gomock.Any(), gomock.Eq(req.Name)).Return(mockSnapshot, nil)
 
 				powervsDriver := controllerService{
 					cloud:         mockCloud,
 					driverOptions: &Options{},
 					volumeLocks:   util.NewVolumeLocks(),
+					operations:    newOperationTracker(),
 				}
 
-				if _, err := powervsDriver.ControllerPublishVolume(ctx, req); err != nil {
-					srvErr, ok := status.FromError(err)
-					if !ok {
-						t.Fatalf("Could not get error status code from error: %v", srvErr)
-					}
-					if srvErr.Code() != codes.InvalidArgument {
-						t.Fatalf("Expected error code %d, got %d message %s", codes.InvalidArgument, srvErr.Code(), srvErr.Message())
-					}
-				} else {
-					t.Fatalf("Expected error %v, got no error", codes.InvalidArgument)
+				if _, err := powervsDriver.CreateSnapshot(ctx, req); err != nil {
+					t.Fatalf("Unexpected error: %v", err)
 				}
 			},
 		},
-
 		{
-			name: "fail invalid VolumeCapability",
+			name: "fail same name and different source volume",
 			testFunc: func(t *testing.T) {
-				req := &csi.ControllerPublishVolumeRequest{
-					NodeId: expInstanceID,
-					VolumeCapability: &csi.VolumeCapability{
-						AccessMode: &csi.VolumeCapability_AccessMode{
-							Mode: csi.VolumeCapability_AccessMode_UNKNOWN,
-						},
-					},
-					VolumeId: "vol-test",
+				req := &csi.CreateSnapshotRequest{
+					Name:           "test-snapshot",
+					SourceVolumeId: "vol-test",
 				}
 
 				ctx := context.Background()
+				mockSnapshot := &cloud.Snapshot{
+					SnapshotID:     "snap-test",
+					SourceVolumeID: "vol-other",
+					Name:           req.Name,
+				}
 
 				mockCtl := gomock.NewController(t)
 				defer mockCtl.Finish()
 
 				mockCloud := mocks.NewMockCloud(mockCtl)
+				mockCloud.EXPECT().GetDiskByID(gomock.Any(), gomock.Eq(req.SourceVolumeId)).Return(&cloud.Disk{VolumeID: req.SourceVolumeId}, nil)
+				mockCloud.EXPECT().GetSnapshotByName(gomock.Any(), gomock.Eq(req.Name)).Return(mockSnapshot, nil)
 
 				powervsDriver := controllerService{
 					cloud:         mockCloud,
 					driverOptions: &Options{},
 					volumeLocks:   util.NewVolumeLocks(),
+					operations:    newOperationTracker(),
 				}
 
-				if _, err := powervsDriver.ControllerPublishVolume(ctx, req); err != nil {
-					srvErr, ok := status.FromError(err)
-					if !ok {
-						t.Fatalf("Could not get error status code from error: %v", srvErr)
-					}
-					if srvErr.Code() != codes.InvalidArgument {
-						t.Fatalf("Expected error code %d, got %d message %s", codes.InvalidArgument, srvErr.Code(), srvErr.Message())
-					}
-				} else {
-					t.Fatalf("Expected error %v, got no error", codes.InvalidArgument)
-				}
+				_, err := powervsDriver.CreateSnapshot(ctx, req)
+				checkExpectedErrorCode(t, err, codes.AlreadyExists)
 			},
 		},
-
 		{
-			name: "fail instance not found",
+			name: "fail source volume not found",
 			testFunc: func(t *testing.T) {
-				req := &csi.ControllerPublishVolumeRequest{
-					NodeId:           "does-not-exist",
-					VolumeId:         "vol-test",
-					VolumeCapability: stdVolCap,
+				req := &csi.CreateSnapshotRequest{
+					Name:           "test-snapshot",
+					SourceVolumeId: "vol-test",
 				}
 
 				ctx := context.Background()
-
 				mockCtl := gomock.NewController(t)
 				defer mockCtl.Finish()
 
 				mockCloud := mocks.NewMockCloud(mockCtl)
-				mockCloud.EXPECT().GetPVMInstanceByID(gomock.Eq("does-not-exist")).Return(nil, cloud.ErrNotFound)
-				// mockCloud.EXPECT().IsExistInstance(gomock.Eq(ctx), gomock.Eq(req.NodeId)).Return(false)
+				mockCloud.EXPECT().GetDiskByID(gomock.Any(), gomock.Eq(req.SourceVolumeId)).Return(nil, cloud.ErrNotFound)
 
 				powervsDriver := controllerService{
 					cloud:         mockCloud,
 					driverOptions: &Options{},
 					volumeLocks:   util.NewVolumeLocks(),
+					operations:    newOperationTracker(),
 				}
 
-				if _, err := powervsDriver.ControllerPublishVolume(ctx, req); err != nil {
-					srvErr, ok := status.FromError(err)
-					if !ok {
-						t.Fatalf("Could not get error status code from error: %v", srvErr)
-					}
-					if srvErr.Code() != codes.NotFound {
-						t.Fatalf("Expected error code %d, got %d message %s", codes.NotFound, srvErr.Code(), srvErr.Message())
-					}
-				} else {
-					t.Fatalf("Expected error %v, got no error", codes.NotFound)
-				}
+				_, err := powervsDriver.CreateSnapshot(ctx, req)
+				checkExpectedErrorCode(t, err, codes.NotFound)
 			},
 		},
-
 		{
-			name: "fail volume not found",
+			name: "fail source volume not attached",
 			testFunc: func(t *testing.T) {
-				req := &csi.ControllerPublishVolumeRequest{
-					VolumeId:         "does-not-exist",
-					NodeId:           expInstanceID,
-					VolumeCapability: stdVolCap,
+				req := &csi.CreateSnapshotRequest{
+					Name:           "test-snapshot",
+					SourceVolumeId: "vol-test",
 				}
 
 				ctx := context.Background()
+				mockDisk := &cloud.Disk{
+					VolumeID:    req.SourceVolumeId,
+					CapacityGiB: 5,
+				}
 
 				mockCtl := gomock.NewController(t)
 				defer mockCtl.Finish()
 
 				mockCloud := mocks.NewMockCloud(mockCtl)
-				mockCloud.EXPECT().GetPVMInstanceByID(gomock.Eq(expInstanceID)).Return(nil, nil)
-				mockCloud.EXPECT().GetDiskByID(gomock.Eq("does-not-exist")).Return(&cloud.Disk{}, cloud.ErrNotFound)
+				mockCloud.EXPECT().GetDiskByID(gomock.Any(), gomock.Eq(req.SourceVolumeId)).Return(mockDisk, nil)
+				mockCloud.EXPECT().GetSnapshotByName(gomock.Any(), gomock.Eq(req.Name)).Return(nil, cloud.ErrNotFound)
+				mockCloud.EXPECT().CreateSnapshot(gomock.Any(), gomock.Eq(req.SourceVolumeId), gomock.Eq(req.Name)).Return(
+					nil, fmt.Errorf("volume %s must be attached to a PVM instance to take a snapshot: %w", req.SourceVolumeId, cloud.ErrInvalidState))
 
 				powervsDriver := controllerService{
 					cloud:         mockCloud,
 					driverOptions: &Options{},
 					volumeLocks:   util.NewVolumeLocks(),
+					operations:    newOperationTracker(),
 				}
 
-				if _, err := powervsDriver.ControllerPublishVolume(ctx, req); err != nil {
-					srvErr, ok := status.FromError(err)
-					if !ok {
-						t.Fatalf("Could not get error status code from error: %v", srvErr)
-					}
-					if srvErr.Code() != codes.NotFound {
-						t.Fatalf("Expected error code %d, got %d message %s", codes.NotFound, srvErr.Code(), srvErr.Message())
-					}
-				} else {
-					t.Fatalf("Expected error %v, got no error", codes.NotFound)
-				}
+				_, err := powervsDriver.CreateSnapshot(ctx, req)
+				checkExpectedErrorCode(t, err, codes.FailedPrecondition)
 			},
 		},
 		{
-			name: "fail if volume already locked",
+			name: "fail no name",
 			testFunc: func(t *testing.T) {
-				req := &csi.ControllerPublishVolumeRequest{
-					NodeId:           expInstanceID,
-					VolumeCapability: stdVolCap,
-					VolumeId:         volumeName,
+				req := &csi.CreateSnapshotRequest{
+					SourceVolumeId: "vol-test",
 				}
 
 				ctx := context.Background()
@@ -1156,171 +3354,142 @@ func TestControllerPublishVolume(t *testing.T) {
 				defer mockCtl.Finish()
 
 				mockCloud := mocks.NewMockCloud(mockCtl)
+
 				powervsDriver := controllerService{
 					cloud:         mockCloud,
 					driverOptions: &Options{},
 					volumeLocks:   util.NewVolumeLocks(),
+					operations:    newOperationTracker(),
 				}
 
-				powervsDriver.volumeLocks.TryAcquire(req.VolumeId)
-				defer powervsDriver.volumeLocks.Release(req.VolumeId)
-
-				_, err := powervsDriver.ControllerPublishVolume(ctx, req)
-				checkExpectedErrorCode(t, err, codes.Aborted)
-
+				_, err := powervsDriver.CreateSnapshot(ctx, req)
+				checkExpectedErrorCode(t, err, codes.InvalidArgument)
 			},
 		},
-	}
-
-	for _, tc := range testCases {
-		t.Run(tc.name, tc.testFunc)
-	}
-}
-
-func TestControllerUnpublishVolume(t *testing.T) {
-	expDevicePath := "/dev/xvda"
-	testCases := []struct {
-		name     string
-		testFunc func(t *testing.T)
-	}{
 		{
-			name: "success normal",
+			name: "fail if snapshot is already locked",
 			testFunc: func(t *testing.T) {
-				req := &csi.ControllerUnpublishVolumeRequest{
-					NodeId:   expInstanceID,
-					VolumeId: "vol-test",
+				req := &csi.CreateSnapshotRequest{
+					Name:           "test-snapshot",
+					SourceVolumeId: "vol-test",
 				}
-				expResp := &csi.ControllerUnpublishVolumeResponse{}
 
 				ctx := context.Background()
-
 				mockCtl := gomock.NewController(t)
 				defer mockCtl.Finish()
 
 				mockCloud := mocks.NewMockCloud(mockCtl)
-				mockCloud.EXPECT().GetDiskByID(gomock.Eq("vol-test")).Return(&cloud.Disk{WWN: expDevicePath}, nil)
-				mockCloud.EXPECT().IsAttached(gomock.Eq("vol-test"), gomock.Eq(expInstanceID)).Return(true, nil)
-				mockCloud.EXPECT().DetachDisk(req.VolumeId, req.NodeId).Return(nil)
 
 				powervsDriver := controllerService{
 					cloud:         mockCloud,
 					driverOptions: &Options{},
 					volumeLocks:   util.NewVolumeLocks(),
+					operations:    newOperationTracker(),
 				}
 
-				resp, err := powervsDriver.ControllerUnpublishVolume(ctx, req)
-				if err != nil {
-					t.Fatalf("Unexpected error: %v", err)
-				}
+				powervsDriver.volumeLocks.TryAcquire(req.Name)
+				defer powervsDriver.volumeLocks.Release(req.Name)
 
-				if !reflect.DeepEqual(resp, expResp) {
-					t.Fatalf("Expected resp to be %+v, got: %+v", expResp, resp)
-				}
+				_, err := powervsDriver.CreateSnapshot(ctx, req)
+				checkExpectedErrorCode(t, err, codes.Aborted)
 			},
 		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, tc.testFunc)
+	}
+}
+
+func TestDeleteSnapshot(t *testing.T) {
+	testCases := []struct {
+		name     string
+		testFunc func(t *testing.T)
+	}{
 		{
-			name: "success when resource is not found",
+			name: "success normal",
 			testFunc: func(t *testing.T) {
-				req := &csi.ControllerUnpublishVolumeRequest{
-					NodeId:   expInstanceID,
-					VolumeId: "vol-test",
+				req := &csi.DeleteSnapshotRequest{
+					SnapshotId: "snap-test",
 				}
-				expResp := &csi.ControllerUnpublishVolumeResponse{}
 
 				ctx := context.Background()
-
 				mockCtl := gomock.NewController(t)
 				defer mockCtl.Finish()
 
 				mockCloud := mocks.NewMockCloud(mockCtl)
-				mockCloud.EXPECT().GetDiskByID(gomock.Eq("vol-test")).Return(&cloud.Disk{WWN: expDevicePath}, cloud.ErrNotFound)
+				mockCloud.EXPECT().GetSnapshotByID(gomock.Any(), gomock.Eq(req.SnapshotId)).Return(&cloud.Snapshot{SnapshotID: req.SnapshotId}, nil)
+				mockCloud.EXPECT().DeleteSnapshot(gomock.Any(), gomock.Eq(req.SnapshotId)).Return(nil)
 
 				powervsDriver := controllerService{
 					cloud:         mockCloud,
 					driverOptions: &Options{},
 					volumeLocks:   util.NewVolumeLocks(),
-				}
-				resp, err := powervsDriver.ControllerUnpublishVolume(ctx, req)
-				if err != nil {
-					t.Fatalf("Unexpected error: %v", err)
+					operations:    newOperationTracker(),
 				}
 
-				if !reflect.DeepEqual(resp, expResp) {
-					t.Fatalf("Expected resp to be %+v, got: %+v", expResp, resp)
+				if _, err := powervsDriver.DeleteSnapshot(ctx, req); err != nil {
+					t.Fatalf("Unexpected error: %v", err)
 				}
 			},
 		},
-
 		{
-			name: "fail no VolumeId",
+			name: "success snapshot not found",
 			testFunc: func(t *testing.T) {
-				req := &csi.ControllerUnpublishVolumeRequest{}
+				req := &csi.DeleteSnapshotRequest{
+					SnapshotId: "snap-test",
+				}
 
 				ctx := context.Background()
-
 				mockCtl := gomock.NewController(t)
 				defer mockCtl.Finish()
 
 				mockCloud := mocks.NewMockCloud(mockCtl)
+				mockCloud.EXPECT().GetSnapshotByID(gomock.Any(), gomock.Eq(req.SnapshotId)).Return(nil, cloud.ErrNotFound)
 
 				powervsDriver := controllerService{
 					cloud:         mockCloud,
 					driverOptions: &Options{},
 					volumeLocks:   util.NewVolumeLocks(),
+					operations:    newOperationTracker(),
 				}
 
-				if _, err := powervsDriver.ControllerUnpublishVolume(ctx, req); err != nil {
-					srvErr, ok := status.FromError(err)
-					if !ok {
-						t.Fatalf("Could not get error status code from error: %v", srvErr)
-					}
-					if srvErr.Code() != codes.InvalidArgument {
-						t.Fatalf("Expected error code %d, got %d message %s", codes.InvalidArgument, srvErr.Code(), srvErr.Message())
-					}
-				} else {
-					t.Fatalf("Expected error %v, got no error", codes.InvalidArgument)
+				if _, err := powervsDriver.DeleteSnapshot(ctx, req); err != nil {
+					t.Fatalf("Unexpected error: %v", err)
 				}
 			},
 		},
 		{
-			name: "fail no NodeId",
+			name: "fail get snapshot",
 			testFunc: func(t *testing.T) {
-				req := &csi.ControllerUnpublishVolumeRequest{
-					VolumeId: "vol-test",
+				req := &csi.DeleteSnapshotRequest{
+					SnapshotId: "snap-test",
 				}
 
 				ctx := context.Background()
-
 				mockCtl := gomock.NewController(t)
 				defer mockCtl.Finish()
 
 				mockCloud := mocks.NewMockCloud(mockCtl)
+				mockCloud.EXPECT().GetSnapshotByID(gomock.Any(), gomock.Eq(req.SnapshotId)).Return(nil, cloud.ErrThrottled)
+				mockCloud.EXPECT().DeleteSnapshot(gomock.Any(), gomock.Any()).Times(0)
 
 				powervsDriver := controllerService{
 					cloud:         mockCloud,
 					driverOptions: &Options{},
 					volumeLocks:   util.NewVolumeLocks(),
+					operations:    newOperationTracker(),
 				}
 
-				if _, err := powervsDriver.ControllerUnpublishVolume(ctx, req); err != nil {
-					srvErr, ok := status.FromError(err)
-					if !ok {
-						t.Fatalf("Could not get error status code from error: %v", srvErr)
-					}
-					if srvErr.Code() != codes.InvalidArgument {
-						t.Fatalf("Expected error code %d, got %d message %s", codes.InvalidArgument, srvErr.Code(), srvErr.Message())
-					}
-				} else {
-					t.Fatalf("Expected error %v, got no error", codes.InvalidArgument)
-				}
+				_, err := powervsDriver.DeleteSnapshot(ctx, req)
+				checkExpectedErrorCode(t, err, codes.Unavailable)
 			},
 		},
 		{
-			name: "fail if volume already locked",
+			name: "fail delete snapshot",
 			testFunc: func(t *testing.T) {
-				req := &csi.ControllerUnpublishVolumeRequest{
-					NodeId:   expInstanceID,
-					VolumeId: "vol-test",
+				req := &csi.DeleteSnapshotRequest{
+					SnapshotId: "snap-test",
 				}
 
 				ctx := context.Background()
@@ -1328,17 +3497,18 @@ func TestControllerUnpublishVolume(t *testing.T) {
 				defer mockCtl.Finish()
 
 				mockCloud := mocks.NewMockCloud(mockCtl)
+				mockCloud.EXPECT().GetSnapshotByID(gomock.Any(), gomock.Eq(req.SnapshotId)).Return(&cloud.Snapshot{SnapshotID: req.SnapshotId}, nil)
+				mockCloud.EXPECT().DeleteSnapshot(gomock.Any(), gomock.Eq(req.SnapshotId)).Return(fmt.Errorf("DeleteSnapshot could not delete snapshot"))
+
 				powervsDriver := controllerService{
 					cloud:         mockCloud,
 					driverOptions: &Options{},
 					volumeLocks:   util.NewVolumeLocks(),
+					operations:    newOperationTracker(),
 				}
 
-				powervsDriver.volumeLocks.TryAcquire(req.VolumeId)
-				defer powervsDriver.volumeLocks.Release(req.VolumeId)
-
-				_, err := powervsDriver.ControllerUnpublishVolume(ctx, req)
-				checkExpectedErrorCode(t, err, codes.Aborted)
+				_, err := powervsDriver.DeleteSnapshot(ctx, req)
+				checkExpectedErrorCode(t, err, codes.Internal)
 			},
 		},
 	}
@@ -1348,52 +3518,45 @@ func TestControllerUnpublishVolume(t *testing.T) {
 	}
 }
 
-func TestControllerExpandVolume(t *testing.T) {
+func TestListSnapshots(t *testing.T) {
+	mockSnapshots := []*cloud.Snapshot{
+		{SnapshotID: "snap-1", SourceVolumeID: "vol-1", ReadyToUse: true},
+		{SnapshotID: "snap-2", SourceVolumeID: "vol-2", ReadyToUse: true},
+		{SnapshotID: "snap-3", SourceVolumeID: "vol-1", ReadyToUse: true},
+	}
+
 	testCases := []struct {
-		name       string
-		req        *csi.ControllerExpandVolumeRequest
-		newSize    int64
-		expResp    *csi.ControllerExpandVolumeResponse
-		expError   bool
-		volumeLock bool
+		name         string
+		req          *csi.ListSnapshotsRequest
+		expIDs       []string
+		expNextToken string
+		expErrCode   codes.Code
 	}{
 		{
-			name: "success normal",
-			req: &csi.ControllerExpandVolumeRequest{
-				VolumeId: "vol-test",
-				CapacityRange: &csi.CapacityRange{
-					RequiredBytes: 5 * util.GiB,
-				},
-			},
-			expResp: &csi.ControllerExpandVolumeResponse{
-				CapacityBytes: 5 * util.GiB,
-			},
+			name:   "success list all",
+			req:    &csi.ListSnapshotsRequest{},
+			expIDs: []string{"snap-1", "snap-2", "snap-3"},
 		},
 		{
-			name:     "fail empty request",
-			req:      &csi.ControllerExpandVolumeRequest{},
-			expError: true,
+			name:   "success filter by source volume",
+			req:    &csi.ListSnapshotsRequest{SourceVolumeId: "vol-1"},
+			expIDs: []string{"snap-1", "snap-3"},
 		},
 		{
-			name: "fail exceeds limit after round up",
-			req: &csi.ControllerExpandVolumeRequest{
-				VolumeId: "vol-test",
-				CapacityRange: &csi.CapacityRange{
-					RequiredBytes: 5*util.GiB + 1, // should round up to 6 GiB
-					LimitBytes:    5 * util.GiB,
-				},
-			},
-			expError: true,
+			name:         "success with max entries",
+			req:          &csi.ListSnapshotsRequest{MaxEntries: 2},
+			expIDs:       []string{"snap-1", "snap-2"},
+			expNextToken: "2",
 		},
 		{
-			name: "fail if volume already locked",
-			req: &csi.ControllerExpandVolumeRequest{
-				VolumeId: "vol-test",
-				CapacityRange: &csi.CapacityRange{
-					RequiredBytes: 5 * util.GiB,
-				},
-			},
-			volumeLock: true,
+			name:   "success with starting token",
+			req:    &csi.ListSnapshotsRequest{MaxEntries: 2, StartingToken: "2"},
+			expIDs: []string{"snap-3"},
+		},
+		{
+			name:       "fail invalid starting token",
+			req:        &csi.ListSnapshotsRequest{StartingToken: "invalid"},
+			expErrCode: codes.Aborted,
 		},
 	}
 
@@ -1403,51 +3566,88 @@ func TestControllerExpandVolume(t *testing.T) {
 			mockCtl := gomock.NewController(t)
 			defer mockCtl.Finish()
 
-			var retSizeGiB int64
-			if tc.newSize != 0 {
-				retSizeGiB = tc.newSize
-			} else {
-				retSizeGiB = util.BytesToGiB(tc.req.CapacityRange.GetRequiredBytes())
-			}
-
 			mockCloud := mocks.NewMockCloud(mockCtl)
+			mockCloud.EXPECT().ListSnapshots(gomock.Any()).Return(mockSnapshots, nil)
+			mockCloud.EXPECT().GetDiskByID(gomock.Any(), gomock.Any()).Return(&cloud.Disk{CapacityGiB: 1}, nil).AnyTimes()
+
 			powervsDriver := controllerService{
 				cloud:         mockCloud,
 				driverOptions: &Options{},
 				volumeLocks:   util.NewVolumeLocks(),
+				operations:    newOperationTracker(),
 			}
 
-			if tc.volumeLock {
-				powervsDriver.volumeLocks.TryAcquire(tc.req.VolumeId)
-				defer powervsDriver.volumeLocks.Release(tc.req.VolumeId)
+			resp, err := powervsDriver.ListSnapshots(ctx, tc.req)
+			if tc.expErrCode != codes.OK {
+				checkExpectedErrorCode(t, err, tc.expErrCode)
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
 
-				_, err := powervsDriver.ControllerExpandVolume(ctx, tc.req)
-				checkExpectedErrorCode(t, err, codes.Aborted)
+			var ids []string
+			for _, e := range resp.GetEntries() {
+				ids = append(ids, e.GetSnapshot().GetSnapshotId())
+			}
+			if !reflect.DeepEqual(ids, tc.expIDs) {
+				t.Fatalf("Expected snapshots %v, got %v", tc.expIDs, ids)
+			}
+			if resp.GetNextToken() != tc.expNextToken {
+				t.Fatalf("Expected next token %q, got %q", tc.expNextToken, resp.GetNextToken())
+			}
+		})
+	}
+}
 
-			} else {
-				mockCloud.EXPECT().ResizeDisk(gomock.Eq(tc.req.VolumeId), gomock.Any()).Return(retSizeGiB, nil).AnyTimes()
-				resp, err := powervsDriver.ControllerExpandVolume(ctx, tc.req)
-				if err != nil {
-					srvErr, ok := status.FromError(err)
-					if !ok {
-						t.Fatalf("Could not get error status code from error: %v", srvErr)
-					}
-					if !tc.expError {
-						t.Fatalf("Unexpected error: %v", err)
-					}
-				} else {
-					if tc.expError {
-						t.Fatalf("Expected error from ControllerExpandVolume, got nothing")
-					}
-				}
+func TestIsValidVolumeCapabilities(t *testing.T) {
+	testCases := []struct {
+		name          string
+		mode          csi.VolumeCapability_AccessMode_Mode
+		expectedValid bool
+	}{
+		{
+			name:          "Test csi.VolumeCapability_AccessMode_SINGLE_NODE_WRITER",
+			mode:          csi.VolumeCapability_AccessMode_SINGLE_NODE_WRITER,
+			expectedValid: true,
+		},
+		{
+			name:          "Test csi.VolumeCapability_AccessMode_SINGLE_NODE_SINGLE_WRITER",
+			mode:          csi.VolumeCapability_AccessMode_SINGLE_NODE_SINGLE_WRITER,
+			expectedValid: true,
+		},
+		{
+			name:          "Test csi.VolumeCapability_AccessMode_SINGLE_NODE_MULTI_WRITER",
+			mode:          csi.VolumeCapability_AccessMode_SINGLE_NODE_MULTI_WRITER,
+			expectedValid: true,
+		},
+		{
+			name:          "Test csi.VolumeCapability_AccessMode_SINGLE_NODE_READER_ONLY",
+			mode:          csi.VolumeCapability_AccessMode_SINGLE_NODE_READER_ONLY,
+			expectedValid: true,
+		},
+		{
+			name:          "Test csi.VolumeCapability_AccessMode_MULTI_NODE_SINGLE_WRITER",
+			mode:          csi.VolumeCapability_AccessMode_MULTI_NODE_SINGLE_WRITER,
+			expectedValid: false,
+		},
+	}
 
-				sizeGiB := util.BytesToGiB(resp.GetCapacityBytes())
-				expSizeGiB := util.BytesToGiB(tc.expResp.GetCapacityBytes())
-				if sizeGiB != expSizeGiB {
-					t.Fatalf("Expected size %d GiB, got %d GiB", expSizeGiB, sizeGiB)
-				}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			volCaps := []*csi.VolumeCapability{
+				{
+					AccessType: &csi.VolumeCapability_Mount{
+						Mount: &csi.VolumeCapability_MountVolume{},
+					},
+					AccessMode: &csi.VolumeCapability_AccessMode{
+						Mode: tc.mode,
+					},
+				},
+			}
+			if res := isValidVolumeCapabilities(volCaps); res != tc.expectedValid {
+				t.Fatalf("Volume capability: %s expected value for valid : %t got: %t", tc.mode.String(), tc.expectedValid, res)
 			}
-
 		})
 	}
 }
@@ -1472,6 +3672,48 @@ func TestIsShareableVolume(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:              "Test csi.VolumeCapability_AccessMode_SINGLE_NODE_SINGLE_WRITER",
+			expectedShareable: false,
+			testVolCap: []*csi.VolumeCapability{
+				{
+					AccessType: &csi.VolumeCapability_Mount{
+						Mount: &csi.VolumeCapability_MountVolume{},
+					},
+					AccessMode: &csi.VolumeCapability_AccessMode{
+						Mode: csi.VolumeCapability_AccessMode_SINGLE_NODE_SINGLE_WRITER,
+					},
+				},
+			},
+		},
+		{
+			name:              "Test csi.VolumeCapability_AccessMode_SINGLE_NODE_MULTI_WRITER",
+			expectedShareable: false,
+			testVolCap: []*csi.VolumeCapability{
+				{
+					AccessType: &csi.VolumeCapability_Mount{
+						Mount: &csi.VolumeCapability_MountVolume{},
+					},
+					AccessMode: &csi.VolumeCapability_AccessMode{
+						Mode: csi.VolumeCapability_AccessMode_SINGLE_NODE_MULTI_WRITER,
+					},
+				},
+			},
+		},
+		{
+			name:              "Test csi.VolumeCapability_AccessMode_SINGLE_NODE_READER_ONLY",
+			expectedShareable: false,
+			testVolCap: []*csi.VolumeCapability{
+				{
+					AccessType: &csi.VolumeCapability_Mount{
+						Mount: &csi.VolumeCapability_MountVolume{},
+					},
+					AccessMode: &csi.VolumeCapability_AccessMode{
+						Mode: csi.VolumeCapability_AccessMode_SINGLE_NODE_READER_ONLY,
+					},
+				},
+			},
+		},
 		{
 			name:              "Test csi.VolumeCapability_AccessMode_MULTI_NODE_MULTI_WRITER",
 			expectedShareable: true,
@@ -1512,6 +3754,114 @@ func TestIsShareableVolume(t *testing.T) {
 
 }
 
+func TestCloudErrorCode(t *testing.T) {
+	testCases := []struct {
+		name         string
+		err          error
+		expectedCode codes.Code
+	}{
+		{name: "not found", err: cloud.ErrNotFound, expectedCode: codes.NotFound},
+		{name: "conflict", err: fmt.Errorf("attach failed: %w", cloud.ErrConflict), expectedCode: codes.FailedPrecondition},
+		{name: "invalid state", err: fmt.Errorf("volume is in error state: %w", cloud.ErrInvalidState), expectedCode: codes.FailedPrecondition},
+		{name: "quota exceeded", err: cloud.ErrQuotaExceeded, expectedCode: codes.ResourceExhausted},
+		{name: "throttled", err: cloud.ErrThrottled, expectedCode: codes.Unavailable},
+		{name: "unauthorized", err: cloud.ErrUnauthorized, expectedCode: codes.PermissionDenied},
+		{name: "unknown", err: fmt.Errorf("connection refused"), expectedCode: codes.Internal},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if code := cloudErrorCode(tc.err); code != tc.expectedCode {
+				t.Fatalf("Expected code %s for error %v, got %s", tc.expectedCode, tc.err, code)
+			}
+		})
+	}
+}
+
+func TestCloudErrorCodeNotFound(t *testing.T) {
+	const volumeID = "vol-test"
+	notFound := fmt.Errorf("resource not found: %w", cloud.ErrNotFound)
+	testCases := []struct {
+		name         string
+		setup        func(mockCloud *mocks.MockCloud)
+		call         func(ctx context.Context, d *controllerService) error
+		expectedCode codes.Code
+	}{
+		{
+			name: "delete volume already deleted",
+			setup: func(mockCloud *mocks.MockCloud) {
+				mockCloud.EXPECT().GetDiskByID(gomock.Any(), volumeID).Return(&cloud.Disk{VolumeID: volumeID}, nil)
+				mockCloud.EXPECT().DeleteDisk(gomock.Any(), volumeID).Return(false, notFound)
+			},
+			call: func(ctx context.Context, d *controllerService) error {
+				_, err := d.DeleteVolume(ctx, &csi.DeleteVolumeRequest{VolumeId: volumeID})
+				return err
+			},
+			expectedCode: codes.OK,
+		},
+		{
+			name: "unpublish volume already detached",
+			setup: func(mockCloud *mocks.MockCloud) {
+				mockCloud.EXPECT().GetDiskByID(gomock.Any(), volumeID).Return(&cloud.Disk{VolumeID: volumeID}, nil)
+				mockCloud.EXPECT().IsAttached(gomock.Any(), volumeID, expInstanceID).Return(true, nil)
+				mockCloud.EXPECT().DetachDisk(gomock.Any(), volumeID, expInstanceID).Return(notFound)
+			},
+			call: func(ctx context.Context, d *controllerService) error {
+				_, err := d.ControllerUnpublishVolume(ctx, &csi.ControllerUnpublishVolumeRequest{VolumeId: volumeID, NodeId: expInstanceID})
+				return err
+			},
+			expectedCode: codes.OK,
+		},
+		{
+			name: "delete snapshot already deleted",
+			setup: func(mockCloud *mocks.MockCloud) {
+				mockCloud.EXPECT().GetSnapshotByID(gomock.Any(), "snap-test").Return(&cloud.Snapshot{SnapshotID: "snap-test"}, nil)
+				mockCloud.EXPECT().DeleteSnapshot(gomock.Any(), "snap-test").Return(notFound)
+			},
+			call: func(ctx context.Context, d *controllerService) error {
+				_, err := d.DeleteSnapshot(ctx, &csi.DeleteSnapshotRequest{SnapshotId: "snap-test"})
+				return err
+			},
+			expectedCode: codes.OK,
+		},
+		{
+			name: "expand missing volume",
+			setup: func(mockCloud *mocks.MockCloud) {
+				mockCloud.EXPECT().ResizeDisk(gomock.Any(), volumeID, gomock.Any()).Return(int64(0), notFound)
+			},
+			call: func(ctx context.Context, d *controllerService) error {
+				_, err := d.ControllerExpandVolume(ctx, &csi.ControllerExpandVolumeRequest{
+					VolumeId:      volumeID,
+					CapacityRange: &csi.CapacityRange{RequiredBytes: util.GiBToBytes(10)},
+				})
+				return err
+			},
+			expectedCode: codes.NotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockCtl := gomock.NewController(t)
+			defer mockCtl.Finish()
+
+			mockCloud := mocks.NewMockCloud(mockCtl)
+			tc.setup(mockCloud)
+			powervsDriver := &controllerService{
+				cloud:         mockCloud,
+				driverOptions: &Options{},
+				volumeLocks:   util.NewVolumeLocks(),
+				operations:    newOperationTracker(),
+			}
+
+			err := tc.call(context.Background(), powervsDriver)
+			if code := status.Code(err); code != tc.expectedCode {
+				t.Fatalf("Expected code %s, got %v", tc.expectedCode, err)
+			}
+		})
+	}
+}
+
 func checkExpectedErrorCode(t *testing.T, err error, expectedCode codes.Code) {
 	if err == nil {
 		t.Fatalf("Expected operation to fail but got no error")
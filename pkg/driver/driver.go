@@ -26,6 +26,7 @@ import (
 	"github.com/container-storage-interface/spec/lib/go/csi"
 	"google.golang.org/grpc"
 	"k8s.io/klog/v2"
+	"sigs.k8s.io/ibm-powervs-block-csi-driver/pkg/cloud"
 	"sigs.k8s.io/ibm-powervs-block-csi-driver/pkg/util"
 )
 
@@ -42,10 +43,8 @@ const (
 )
 
 const (
-	DriverName  = "powervs.csi.ibm.com"
-	DiskTypeKey = "topology." + DriverName + "/disk-type"
-
-	TopologyKey = "topology." + DriverName + "/region"
+	DriverName  = util.DriverName
+	DiskTypeKey = util.DiskTypeKey
 )
 
 type Driver struct {
@@ -57,21 +56,25 @@ type Driver struct {
 }
 
 type Options struct {
-	endpoint          string
-	extraTags         map[string]string
-	mode              Mode
-	volumeAttachLimit int64
-	debug             bool
-	kubeconfig        string
-	cloudconfig       string
+	endpoint            string
+	extraTags           map[string]string
+	kubernetesClusterID string
+	volumeNameTemplate  string
+	retryPolicy         cloud.RetryPolicy
+	mode                Mode
+	volumeAttachLimit   int64
+	debug               bool
+	kubeconfig          string
+	cloudconfig         string
 }
 
 func NewDriver(options ...func(*Options)) (*Driver, error) {
 	klog.Infof("Driver: %v Version: %v", DriverName, driverVersion)
 
 	driverOptions := Options{
-		endpoint: DefaultCSIEndpoint,
-		mode:     AllMode,
+		endpoint:    DefaultCSIEndpoint,
+		mode:        AllMode,
+		retryPolicy: cloud.DefaultRetryPolicy,
 	}
 	for _, option := range options {
 		option(&driverOptions)
@@ -144,6 +147,11 @@ func (d *Driver) Run() error {
 func (d *Driver) Stop() {
 	klog.Infof("Stopping server")
 	d.srv.Stop()
+	for _, c := range []cloud.Cloud{d.controllerService.cloud, d.nodeService.cloud} {
+		if c != nil {
+			c.Close()
+		}
+	}
 }
 
 func WithEndpoint(endpoint string) func(*Options) {
@@ -181,3 +189,27 @@ func WithCloudConfig(cloudconfig string) func(*Options) {
 		o.cloudconfig = cloudconfig
 	}
 }
+
+func WithExtraTags(extraTags map[string]string) func(*Options) {
+	return func(o *Options) {
+		o.extraTags = extraTags
+	}
+}
+
+func WithKubernetesClusterID(clusterID string) func(*Options) {
+	return func(o *Options) {
+		o.kubernetesClusterID = clusterID
+	}
+}
+
+func WithVolumeNameTemplate(volumeNameTemplate string) func(*Options) {
+	return func(o *Options) {
+		o.volumeNameTemplate = volumeNameTemplate
+	}
+}
+
+func WithRetryPolicy(retryPolicy cloud.RetryPolicy) func(*Options) {
+	return func(o *Options) {
+		o.retryPolicy = retryPolicy
+	}
+}
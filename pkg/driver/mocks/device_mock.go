@@ -88,3 +88,17 @@ func (mr *MockLinuxDeviceMockRecorder) Populate(arg0 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Populate", reflect.TypeOf((*MockLinuxDevice)(nil).Populate), arg0)
 }
+
+// SetReadOnly mocks base method.
+func (m *MockLinuxDevice) SetReadOnly() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetReadOnly")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetReadOnly indicates an expected call of SetReadOnly.
+func (mr *MockLinuxDeviceMockRecorder) SetReadOnly() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetReadOnly", reflect.TypeOf((*MockLinuxDevice)(nil).SetReadOnly))
+}
@@ -0,0 +1,88 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package driver
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"regexp"
+	"strings"
+	"text/template"
+)
+
+const (
+	// maxVolumeNameLength is the maximum length of the PowerVS volume names generated from the naming template
+	maxVolumeNameLength = 63
+	// volumeNameHashLength is the length of the suffix derived from the CSI volume name
+	volumeNameHashLength = 8
+)
+
+var invalidVolumeNameChars = regexp.MustCompile(`[^a-zA-Z0-9_.-]+`)
+
+// volumeNameData holds the fields available to the volume naming template.
+type volumeNameData struct {
+	// Name is the volume name given by the CO, pvc-<uuid> for the external-provisioner
+	Name         string
+	ClusterID    string
+	PVCName      string
+	PVCNamespace string
+	PVName       string
+}
+
+// parseVolumeNameTemplate parses the naming template given to the controller.
+func parseVolumeNameTemplate(text string) (*template.Template, error) {
+	return template.New("volume-name").Option("missingkey=error").Parse(text)
+}
+
+// generateVolumeName renders the naming template and sanitizes the result into a PowerVS volume name.
+// A hash of the CSI volume name is appended, so the name is unique for every CreateVolume request and
+// identical across its retries, which keeps the GetDiskByName lookup idempotent.
+// Without a template the CSI volume name is used as is.
+func generateVolumeName(text string, data volumeNameData) (string, error) {
+	if len(text) == 0 {
+		return data.Name, nil
+	}
+	tmpl, err := parseVolumeNameTemplate(text)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, data); err != nil {
+		return "", err
+	}
+
+	hash := sha256.Sum256([]byte(data.Name))
+	suffix := hex.EncodeToString(hash[:])[:volumeNameHashLength]
+
+	name := sanitizeVolumeName(b.String())
+	if maxLength := maxVolumeNameLength - len(suffix) - 1; len(name) > maxLength {
+		name = strings.TrimRight(name[:maxLength], "-_.")
+	}
+	if len(name) == 0 {
+		return suffix, nil
+	}
+	return name + "-" + suffix, nil
+}
+
+// sanitizeVolumeName replaces the characters PowerVS does not accept in volume names with dashes.
+func sanitizeVolumeName(name string) string {
+	name = invalidVolumeNameChars.ReplaceAllString(name, "-")
+	for strings.Contains(name, "--") {
+		name = strings.ReplaceAll(name, "--", "-")
+	}
+	return strings.Trim(name, "-_.")
+}
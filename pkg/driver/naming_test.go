@@ -0,0 +1,125 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package driver
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGenerateVolumeName(t *testing.T) {
+	data := volumeNameData{
+		Name:         "pvc-0d6c1c1c-3a0e-4b7e-9d43-6a7a0d2f4b11",
+		ClusterID:    "cluster-1",
+		PVCName:      "data-web-0",
+		PVCNamespace: "default",
+		PVName:       "pvc-0d6c1c1c-3a0e-4b7e-9d43-6a7a0d2f4b11",
+	}
+	testCases := []struct {
+		name      string
+		template  string
+		data      volumeNameData
+		expPrefix string
+		expErr    bool
+	}{
+		{
+			name:      "no template uses the CSI volume name",
+			data:      data,
+			expPrefix: data.Name,
+		},
+		{
+			name:      "cluster namespace and PVC name",
+			template:  "{{.ClusterID}}-{{.PVCNamespace}}-{{.PVCName}}",
+			data:      data,
+			expPrefix: "cluster-1-default-data-web-0-",
+		},
+		{
+			name:      "invalid characters are replaced",
+			template:  "{{.ClusterID}}/{{.PVCNamespace}}:{{.PVCName}}",
+			data:      data,
+			expPrefix: "cluster-1-default-data-web-0-",
+		},
+		{
+			name:      "missing metadata is collapsed",
+			template:  "{{.ClusterID}}-{{.PVCNamespace}}-{{.PVCName}}",
+			data:      volumeNameData{Name: data.Name, ClusterID: "cluster-1"},
+			expPrefix: "cluster-1-",
+		},
+		{
+			name:     "unknown field",
+			template: "{{.Cluster}}",
+			data:     data,
+			expErr:   true,
+		},
+		{
+			name:     "invalid template",
+			template: "{{.ClusterID",
+			data:     data,
+			expErr:   true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			name, err := generateVolumeName(tc.template, tc.data)
+			if tc.expErr {
+				if err == nil {
+					t.Fatalf("Expected error, got name %q", name)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !strings.HasPrefix(name, tc.expPrefix) {
+				t.Fatalf("Expected name with prefix %q, got %q", tc.expPrefix, name)
+			}
+			if len(name) > maxVolumeNameLength && len(tc.template) != 0 {
+				t.Fatalf("Expected name of at most %d characters, got %q", maxVolumeNameLength, name)
+			}
+			again, _ := generateVolumeName(tc.template, tc.data)
+			if again != name {
+				t.Fatalf("Expected the same name across calls, got %q and %q", name, again)
+			}
+		})
+	}
+}
+
+func TestGenerateVolumeNameUniqueness(t *testing.T) {
+	template := "{{.ClusterID}}-{{.PVCNamespace}}-{{.PVCName}}"
+	first, err := generateVolumeName(template, volumeNameData{Name: "pvc-1", ClusterID: "cluster-1", PVCNamespace: "default", PVCName: "data"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	second, err := generateVolumeName(template, volumeNameData{Name: "pvc-2", ClusterID: "cluster-1", PVCNamespace: "default", PVCName: "data"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if first == second {
+		t.Fatalf("Expected different names for different CSI volumes, got %q twice", first)
+	}
+}
+
+func TestGenerateVolumeNameTruncation(t *testing.T) {
+	name, err := generateVolumeName("{{.PVCName}}", volumeNameData{Name: "pvc-1", PVCName: strings.Repeat("a", 2*maxVolumeNameLength)})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(name) != maxVolumeNameLength {
+		t.Fatalf("Expected name of %d characters, got %d: %q", maxVolumeNameLength, len(name), name)
+	}
+}
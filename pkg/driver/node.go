@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
 
 	"github.com/container-storage-interface/spec/lib/go/csi"
 	"google.golang.org/grpc/codes"
@@ -45,9 +46,9 @@ const (
 	// default file system type to be used when it is not provided
 	defaultFsType = "ext4"
 
-	// defaultMaxVolumesPerInstance is the limit of volumes can be attached in the PowerVS environment
-	// TODO: rightnow 99 is just a placeholder, this needs to be changed post discussion with PowerVS team
-	defaultMaxVolumesPerInstance = 127 - 1
+	// defaultMaxVolumesPerInstance is the volume limit of the node when its boot volumes cannot be counted,
+	// the instance is then assumed to have a single boot volume
+	defaultMaxVolumesPerInstance = cloud.MaxVolumesPerInstance - 1
 )
 
 var (
@@ -59,6 +60,7 @@ var (
 		csi.NodeServiceCapability_RPC_STAGE_UNSTAGE_VOLUME,
 		csi.NodeServiceCapability_RPC_EXPAND_VOLUME,
 		csi.NodeServiceCapability_RPC_GET_VOLUME_STATS,
+		csi.NodeServiceCapability_RPC_SINGLE_NODE_MULTI_WRITER,
 	}
 )
 
@@ -81,7 +83,7 @@ func newNodeService(driverOptions *Options) nodeService {
 		panic(err)
 	}
 
-	pvsCloud, err := NewPowerVSCloudFunc(metadata.GetCloudInstanceId(), metadata.GetZone(), driverOptions.debug)
+	pvsCloud, err := NewPowerVSCloudFunc(metadata.GetCloudInstanceId(), metadata.GetZone(), driverOptions.debug, driverOptions.retryPolicy)
 	if err != nil {
 		panic(err)
 	}
@@ -178,7 +180,8 @@ func (d *nodeService) stageVolume(wwn string, req *csi.NodeStageVolumeRequest) e
 		return nil
 	}
 
-	dev, err := d.setupDevice(wwn)
+	readOnly := isReadOnlyPublish(req.PublishContext)
+	dev, err := d.setupDevice(wwn, readOnly)
 	if err != nil {
 		return status.Error(codes.Internal, fmt.Sprintf("error creating device for volumeID %s, err: %v", req.VolumeId, err))
 	}
@@ -198,6 +201,10 @@ func (d *nodeService) stageVolume(wwn string, req *csi.NodeStageVolumeRequest) e
 			mountOptions = append(mountOptions, f)
 		}
 	}
+	// A read-only volume that is not formatted yet fails to mount instead of being formatted.
+	if readOnly && !hasMountOption(mountOptions, "ro") {
+		mountOptions = append(mountOptions, "ro")
+	}
 
 	// Check if a device is mounted in target directory
 	deviceFromMount, _, err := d.mounter.GetDeviceName(target)
@@ -262,22 +269,22 @@ func (d *nodeService) nodeUnstageVolume(req *csi.NodeUnstageVolumeRequest) error
 		return status.Errorf(codes.Internal, "failed to get device for vol %s target %q: %v", volumeID, stagingTarget, err)
 	}
 	if count == 0 {
-		klog.V(5).Infof("volume %s not in staged state as the device is not mounted", volumeID, stagingTarget)
+		klog.V(5).Infof("volume %s not in staged state as the device is not mounted at %s", volumeID, stagingTarget)
 		return nil
 	}
 
 	klog.V(5).Infof("found staged device: %s", deviceName)
 
 	// If mounted, then unmount the filesystem
-	klog.V(5).Infof("starting unmounting %s", stagingTarget, "volumeID", volumeID)
+	klog.V(5).Infof("starting unmounting %s for volume %s", stagingTarget, volumeID)
 	err = d.mounter.Unmount(stagingTarget)
 	if err != nil {
 		return status.Errorf(codes.Internal, "failed to unmount for vol %s target %q: %v", volumeID, stagingTarget, err)
 	}
-	klog.V(5).Infof("completed unmounting %s", stagingTarget, "volumeID", volumeID)
+	klog.V(5).Infof("completed unmounting %s for volume %s", stagingTarget, volumeID)
 
 	// Delete device
-	klog.V(5).Infof("deleting device %s", deviceName, "volumeID", volumeID)
+	klog.V(5).Infof("deleting device %s for volume %s", deviceName, volumeID)
 	//check if device is mounted or has holders
 	isDirMounted, err := d.mounter.IsMountPoint(stagingTarget)
 	if err != nil {
@@ -332,6 +339,10 @@ func (d *nodeService) NodeExpandVolume(ctx context.Context, req *csi.NodeExpandV
 	}
 	defer d.volumeLocks.Release(volumeID)
 
+	if d.stats.IsPathNotExist(volumePath) {
+		return nil, status.Errorf(codes.NotFound, "volume path %s of volume %s does not exist", volumePath, volumeID)
+	}
+
 	volumeCapability := req.GetVolumeCapability()
 	isBlock := false
 
@@ -352,7 +363,7 @@ func (d *nodeService) NodeExpandVolume(ctx context.Context, req *csi.NodeExpandV
 
 	// Noop for block NodeExpandVolume.
 	if isBlock {
-		klog.V(4).Infof("NodeExpandVolume: ignoring as given volume path is a block device", "volumeID", volumeID, "volumePath", volumePath)
+		klog.V(4).Infof("NodeExpandVolume: ignoring volume %s as given volume path %s is a block device", volumeID, volumePath)
 		return &csi.NodeExpandVolumeResponse{}, nil
 	}
 
@@ -423,7 +434,7 @@ func (d *nodeService) NodePublishVolume(ctx context.Context, req *csi.NodePublis
 	}
 
 	mountOptions := []string{"bind"}
-	if req.GetReadonly() {
+	if req.GetReadonly() || isReadOnlyPublish(req.PublishContext) {
 		mountOptions = append(mountOptions, "ro")
 	}
 
@@ -559,12 +570,12 @@ func (d *nodeService) NodeGetCapabilities(ctx context.Context, req *csi.NodeGetC
 func (d *nodeService) NodeGetInfo(ctx context.Context, req *csi.NodeGetInfoRequest) (*csi.NodeGetInfoResponse, error) {
 	klog.V(4).Infof("NodeGetInfo: called with args %+v", *req)
 
-	in, err := d.cloud.GetPVMInstanceByID(d.pvmInstanceId)
+	in, err := d.cloud.GetPVMInstanceByID(ctx, d.pvmInstanceId)
 	if err != nil {
 		klog.Errorf("failed to get the instance for pvmInstanceId %s, err: %s", d.pvmInstanceId, err)
 		return nil, fmt.Errorf("failed to get the instance for pvmInstanceId %s, err: %s", d.pvmInstanceId, err)
 	}
-	image, err := d.cloud.GetImageByID(in.ImageID)
+	image, err := d.cloud.GetImageByID(ctx, in.ImageID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get the image details for %s, err: %s", in.ImageID, err)
 	}
@@ -577,7 +588,7 @@ func (d *nodeService) NodeGetInfo(ctx context.Context, req *csi.NodeGetInfoReque
 
 	return &csi.NodeGetInfoResponse{
 		NodeId:             d.pvmInstanceId,
-		MaxVolumesPerNode:  d.getVolumesLimit(),
+		MaxVolumesPerNode:  d.getVolumesLimit(ctx),
 		AccessibleTopology: topology,
 	}, nil
 }
@@ -588,7 +599,7 @@ func (d *nodeService) nodePublishVolumeForBlock(req *csi.NodePublishVolumeReques
 
 	// already validated
 	wwn := req.PublishContext[WWNKey]
-	dev, err := d.setupDevice(wwn)
+	dev, err := d.setupDevice(wwn, isReadOnlyPublish(req.PublishContext))
 	if err != nil {
 		return status.Error(codes.Internal, fmt.Sprintf("error creating device for volumeID %s, err: %v", req.VolumeId, err))
 	}
@@ -675,12 +686,24 @@ func (d *nodeService) nodePublishVolumeForFileSystem(req *csi.NodePublishVolumeR
 	return nil
 }
 
-// getVolumesLimit returns the limit of volumes that the node supports
-func (d *nodeService) getVolumesLimit() int64 {
+// getVolumesLimit returns the limit of volumes that the node supports, the volume attach limit option when
+// set, or else the PowerVS limit of the instance less its boot volumes.
+func (d *nodeService) getVolumesLimit(ctx context.Context) int64 {
 	if d.driverOptions.volumeAttachLimit >= 0 {
 		return d.driverOptions.volumeAttachLimit
 	}
-	return defaultMaxVolumesPerInstance
+	disks, err := d.cloud.ListPVMInstanceDisks(ctx, d.pvmInstanceId)
+	if err != nil {
+		klog.Warningf("failed to list the volumes attached to instance %s, assuming a single boot volume: %v", d.pvmInstanceId, err)
+		return defaultMaxVolumesPerInstance
+	}
+	limit := cloud.MaxVolumesPerInstance
+	for _, disk := range disks {
+		if disk.BootVolume {
+			limit--
+		}
+	}
+	return limit
 }
 
 // hasMountOption returns a boolean indicating whether the given
@@ -695,7 +718,13 @@ func hasMountOption(options []string, opt string) bool {
 	return false
 }
 
-func (d *nodeService) setupDevice(wwn string) (*device.LinuxDevice, error) {
+// isReadOnlyPublish returns true when the controller published the volume read-only.
+func isReadOnlyPublish(publishContext map[string]string) bool {
+	readOnly, err := strconv.ParseBool(publishContext[ReadOnlyKey])
+	return err == nil && readOnly
+}
+
+func (d *nodeService) setupDevice(wwn string, readOnly bool) (*device.LinuxDevice, error) {
 	dev := NewDevice(wwn)
 	if err := dev.Populate(false); err != nil {
 		return nil, err
@@ -712,5 +741,10 @@ func (d *nodeService) setupDevice(wwn string) (*device.LinuxDevice, error) {
 	if err != nil {
 		return nil, err
 	}
+	if readOnly {
+		if err := dev.SetReadOnly(); err != nil {
+			return nil, err
+		}
+	}
 	return &dev, err
 }
@@ -16,6 +16,7 @@ package driver
 import (
 	"context"
 	"errors"
+	"fmt"
 	"reflect"
 	"testing"
 
@@ -156,6 +157,23 @@ func TestNodeStageVolume(t *testing.T) {
 			},
 		},
 
+		{
+			name: "success read-only",
+			request: &csi.NodeStageVolumeRequest{
+				PublishContext:    map[string]string{WWNKey: volumeWWN, ReadOnlyKey: "true"},
+				StagingTargetPath: targetPath,
+				VolumeCapability:  stdVolCap,
+				VolumeId:          volumeID,
+			},
+			expectMock: func(mockMounter *mocks.MockMounter, mockDevice *mocks.MockLinuxDevice) {
+				commonExpectMock(mockMounter, mockDevice)
+				mockDevice.EXPECT().SetReadOnly().Return(nil)
+				mockMounter.EXPECT().FormatAndMount(gomock.Eq(devicePath), gomock.Eq(targetPath), gomock.Eq(FSTypeExt4), gomock.Eq([]string{"ro"}))
+				mockMounter.EXPECT().GetDeviceName(gomock.Eq(targetPath)).Return(targetPath, 1, nil)
+
+			},
+		},
+
 		{
 			name: "success fsType ext3",
 			request: &csi.NodeStageVolumeRequest{
@@ -1057,6 +1075,13 @@ func TestNodeGetCapabilities(t *testing.T) {
 				},
 			},
 		},
+		{
+			Type: &csi.NodeServiceCapability_Rpc{
+				Rpc: &csi.NodeServiceCapability_RPC{
+					Type: csi.NodeServiceCapability_RPC_SINGLE_NODE_MULTI_WRITER,
+				},
+			},
+		},
 	}
 	expResp := &csi.NodeGetCapabilitiesResponse{Capabilities: caps}
 
@@ -1081,6 +1106,7 @@ func TestNodeGetInfo(t *testing.T) {
 		instanceType      string
 		availabilityZone  string
 		volumeAttachLimit int64
+		bootVolumes       int
 		expMaxVolumes     int64
 	}{
 		{
@@ -1091,6 +1117,13 @@ func TestNodeGetInfo(t *testing.T) {
 			volumeAttachLimit: 30,
 			expMaxVolumes:     30,
 		},
+		{
+			name:              "success limit from the instance boot volumes",
+			instanceID:        "i-123456789abcdef01",
+			volumeAttachLimit: -1,
+			bootVolumes:       2,
+			expMaxVolumes:     cloud.MaxVolumesPerInstance - 2,
+		},
 	}
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
@@ -1104,18 +1137,26 @@ func TestNodeGetInfo(t *testing.T) {
 			mockMounter := mocks.NewMockMounter(mockCtl)
 			mockCloud := cloudmocks.NewMockCloud(mockCtl)
 
-			mockCloud.EXPECT().GetPVMInstanceByID(tc.instanceID).Return(&cloud.PVMInstance{
+			mockCloud.EXPECT().GetPVMInstanceByID(gomock.Any(), tc.instanceID).Return(&cloud.PVMInstance{
 				ID:      tc.instanceID,
 				Name:    tc.name,
 				ImageID: "test-image",
 			}, nil)
 
-			mockCloud.EXPECT().GetImageByID(gomock.Eq("test-image")).Return(&cloud.PVMImage{
+			mockCloud.EXPECT().GetImageByID(gomock.Any(), gomock.Eq("test-image")).Return(&cloud.PVMImage{
 				ID:       "test-image",
 				Name:     "test-image",
 				DiskType: "tier3",
 			}, nil)
 
+			if tc.volumeAttachLimit < 0 {
+				disks := []*cloud.Disk{{VolumeID: "data"}}
+				for i := 0; i < tc.bootVolumes; i++ {
+					disks = append(disks, &cloud.Disk{VolumeID: fmt.Sprintf("boot-%d", i), BootVolume: true})
+				}
+				mockCloud.EXPECT().ListPVMInstanceDisks(gomock.Any(), tc.instanceID).Return(disks, nil)
+			}
+
 			powervsDriver := &nodeService{
 				mounter:       mockMounter,
 				driverOptions: driverOptions,
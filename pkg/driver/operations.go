@@ -0,0 +1,221 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package driver
+
+import (
+	"context"
+	"reflect"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// operationTimeout bounds an operation once the call that started it has gone, it covers the clone polling
+	operationTimeout = 15 * time.Minute
+	// operationResultTTL is how long the result of a create nobody waited for is kept for a retry
+	operationResultTTL = 5 * time.Minute
+
+	operationPendingFmt = "Operation %s on volume %s is still in progress"
+)
+
+// operationFunc runs a PowerVS operation. The context is not the one of the CSI call, so the operation
+// keeps running when the sidecar gives up on the call.
+type operationFunc func(ctx context.Context) (interface{}, error)
+
+// operation is a PowerVS operation running or finished on a volume.
+type operation struct {
+	// name identifies the operation on the volume, like create or attach/<node>
+	name string
+	// params are the parameters of the call that started the operation, the calls joining it must
+	// have the same ones
+	params   interface{}
+	done     chan struct{}
+	result   interface{}
+	err      error
+	finished time.Time
+	// keepResult keeps the result of the operation once it finished for the retry of a call that
+	// stopped waiting, the other results are dropped so the retry checks the volume state again
+	keepResult bool
+}
+
+// operationTracker records the PowerVS operations in flight on each volume. The sidecars retry a call
+// when it times out, the retry then waits for the operation started by the first call instead of
+// issuing the same request to PowerVS again.
+type operationTracker struct {
+	mu sync.Mutex
+	// creates are keyed by the name of the volume being created, it has no ID before the create ends
+	creates map[string]*operation
+	// operations are keyed by volume ID
+	operations map[string]*operation
+	// instances holds a lock per PVM instance, serializing the attaches to the instance
+	instances map[string]*instanceLock
+}
+
+// instanceLock is dropped from the tracker once nobody holds it or waits for it.
+type instanceLock struct {
+	ch chan struct{}
+	// refs counts the calls holding or waiting for the lock
+	refs int
+}
+
+func newOperationTracker() *operationTracker {
+	return &operationTracker{
+		creates:    map[string]*operation{},
+		operations: map[string]*operation{},
+		instances:  map[string]*instanceLock{},
+	}
+}
+
+// lockInstance waits until the attaches to instanceID are done, then locks the instance. It returns
+// the function unlocking the instance, or the error of ctx when ctx is done first.
+func (t *operationTracker) lockInstance(ctx context.Context, instanceID string) (func(), error) {
+	t.mu.Lock()
+	lock, found := t.instances[instanceID]
+	if !found {
+		lock = &instanceLock{ch: make(chan struct{}, 1)}
+		t.instances[instanceID] = lock
+	}
+	lock.refs++
+	t.mu.Unlock()
+
+	select {
+	case lock.ch <- struct{}{}:
+		return func() {
+			<-lock.ch
+			t.releaseInstance(instanceID, lock)
+		}, nil
+	case <-ctx.Done():
+		t.releaseInstance(instanceID, lock)
+		return nil, ctx.Err()
+	}
+}
+
+func (t *operationTracker) releaseInstance(instanceID string, lock *instanceLock) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	lock.refs--
+	if lock.refs == 0 && t.instances[instanceID] == lock {
+		delete(t.instances, instanceID)
+	}
+}
+
+// run starts fn as the operation name on volumeID, or joins it when the operation is already running, and
+// waits for it until ctx is done. It returns Aborted when ctx is done first, or when a different operation
+// is in flight on the volume. The result of an operation nobody waited for is dropped, the volume may
+// have changed since, so the retry runs fn again.
+func (t *operationTracker) run(ctx context.Context, volumeID, name string, fn operationFunc) (interface{}, error) {
+	return t.runIn(ctx, t.operations, volumeID, name, nil, false, fn)
+}
+
+// runCreate runs fn as the create of the volume volName like run does. A call joins the create in
+// flight only when it has the same params, it gets AlreadyExists otherwise. The result of a create
+// nobody waited for is kept for operationResultTTL, so the retry gets the volume instead of creating
+// it again.
+func (t *operationTracker) runCreate(ctx context.Context, volName string, params interface{}, fn operationFunc) (interface{}, error) {
+	return t.runIn(ctx, t.creates, volName, "create", params, true, fn)
+}
+
+func (t *operationTracker) runIn(ctx context.Context, operations map[string]*operation, key, name string, params interface{}, keepResult bool, fn operationFunc) (interface{}, error) {
+	t.mu.Lock()
+	t.purge()
+	op, found := operations[key]
+	if found && op.name != name {
+		if !op.isFinished() {
+			t.mu.Unlock()
+			return nil, status.Errorf(codes.Aborted, operationPendingFmt, op.name, key)
+		}
+		// The result of a previous operation is stale once the volume is operated on again.
+		found = false
+	}
+	if found && !reflect.DeepEqual(op.params, params) {
+		if !op.isFinished() {
+			t.mu.Unlock()
+			return nil, status.Errorf(codes.AlreadyExists, "Operation %s on volume %s is in progress with different parameters", name, key)
+		}
+		// The new call checks the result of the finished operation against its own parameters.
+		found = false
+	}
+	if found {
+		klog.V(4).Infof("Joining operation %s on volume %s", name, key)
+	} else {
+		op = &operation{name: name, params: params, done: make(chan struct{}), keepResult: keepResult}
+		operations[key] = op
+		go t.execute(operations, key, op, fn)
+	}
+	t.mu.Unlock()
+
+	select {
+	case <-op.done:
+	case <-ctx.Done():
+		klog.V(4).Infof("Stopped waiting for operation %s on volume %s: %v", name, key, ctx.Err())
+		return nil, status.Errorf(codes.Aborted, operationPendingFmt, name, key)
+	}
+
+	t.mu.Lock()
+	if operations[key] == op {
+		delete(operations, key)
+	}
+	t.mu.Unlock()
+	return op.result, op.err
+}
+
+func (t *operationTracker) execute(operations map[string]*operation, key string, op *operation, fn operationFunc) {
+	ctx, cancel := context.WithTimeout(context.Background(), operationTimeout)
+	defer cancel()
+	result, err := call(ctx, op, fn)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	op.result, op.err = result, err
+	op.finished = time.Now()
+	if !op.keepResult && operations[key] == op {
+		// the calls waiting for the operation got op, the later ones run it again
+		delete(operations, key)
+	}
+	close(op.done)
+}
+
+// call runs fn, a panic of fn is returned as an Internal error so the waiters of the operation are
+// released and the process keeps serving the other calls.
+func call(ctx context.Context, op *operation, fn operationFunc) (result interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			klog.Errorf("Operation %s panicked: %v\n%s", op.name, r, debug.Stack())
+			result, err = nil, status.Errorf(codes.Internal, "Operation %s failed: %v", op.name, r)
+		}
+	}()
+	return fn(ctx)
+}
+
+// purge drops the create results kept for longer than operationResultTTL, it must be called with mu held.
+func (t *operationTracker) purge() {
+	for key, op := range t.creates {
+		if op.isFinished() && time.Since(op.finished) > operationResultTTL {
+			delete(t.creates, key)
+		}
+	}
+}
+
+// isFinished must be called with the tracker mu held.
+func (op *operation) isFinished() bool {
+	return !op.finished.IsZero()
+}
@@ -0,0 +1,293 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package driver
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"sigs.k8s.io/ibm-powervs-block-csi-driver/pkg/cloud"
+)
+
+func TestOperationTracker(t *testing.T) {
+	testCases := []struct {
+		name     string
+		testFunc func(t *testing.T)
+	}{
+		{
+			name: "concurrent calls run the operation once",
+			testFunc: func(t *testing.T) {
+				tracker := newOperationTracker()
+				release := make(chan struct{})
+				var calls int32
+				fn := func(ctx context.Context) (interface{}, error) {
+					atomic.AddInt32(&calls, 1)
+					<-release
+					return "vol-1", nil
+				}
+
+				var wg sync.WaitGroup
+				results := make([]interface{}, 3)
+				for i := range results {
+					wg.Add(1)
+					go func(i int) {
+						defer wg.Done()
+						results[i], _ = tracker.run(context.Background(), "vol-1", "create", fn)
+					}(i)
+				}
+				// Let the calls join the operation before it finishes.
+				time.Sleep(100 * time.Millisecond)
+				close(release)
+				wg.Wait()
+
+				if calls != 1 {
+					t.Fatalf("Expected the operation to run once, ran %d times", calls)
+				}
+				for _, result := range results {
+					if result != "vol-1" {
+						t.Fatalf("Expected result vol-1, got %v", result)
+					}
+				}
+			},
+		},
+		{
+			name: "create retry gets the result of the create the timed out call started",
+			testFunc: func(t *testing.T) {
+				tracker := newOperationTracker()
+				release := make(chan struct{})
+				var calls int32
+				fn := func(ctx context.Context) (interface{}, error) {
+					atomic.AddInt32(&calls, 1)
+					<-release
+					return "vol-1", nil
+				}
+
+				ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+				defer cancel()
+				_, err := tracker.runCreate(ctx, "pvc-1", createVolumeParams{}, fn)
+				if status.Code(err) != codes.Aborted {
+					t.Fatalf("Expected Aborted while the operation is in progress, got %v", err)
+				}
+
+				close(release)
+				// Wait for the create nobody waited for to finish.
+				for finished := false; !finished; time.Sleep(time.Millisecond) {
+					tracker.mu.Lock()
+					finished = tracker.creates["pvc-1"].isFinished()
+					tracker.mu.Unlock()
+				}
+				result, err := tracker.runCreate(context.Background(), "pvc-1", createVolumeParams{}, fn)
+				if err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+				if result != "vol-1" || calls != 1 {
+					t.Fatalf("Expected result vol-1 from a single run, got %v after %d runs", result, calls)
+				}
+			},
+		},
+		{
+			name: "retry of a finished attach nobody waited for runs again",
+			testFunc: func(t *testing.T) {
+				tracker := newOperationTracker()
+				var calls int32
+				fn := func(ctx context.Context) (interface{}, error) {
+					return atomic.AddInt32(&calls, 1), nil
+				}
+				ctx, cancel := context.WithCancel(context.Background())
+				cancel()
+				_, _ = tracker.run(ctx, "vol-1", "attach/node-1", fn)
+				// Wait for the attach nobody waited for to finish.
+				for finished := false; !finished; time.Sleep(time.Millisecond) {
+					tracker.mu.Lock()
+					_, found := tracker.operations["vol-1"]
+					finished = !found
+					tracker.mu.Unlock()
+				}
+
+				result, err := tracker.run(context.Background(), "vol-1", "attach/node-1", fn)
+				if err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+				if result != int32(2) {
+					t.Fatalf("Expected the attach to run again, got result %v", result)
+				}
+			},
+		},
+		{
+			name: "instance lock is dropped once released",
+			testFunc: func(t *testing.T) {
+				tracker := newOperationTracker()
+				unlock, err := tracker.lockInstance(context.Background(), "instance-1")
+				if err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+
+				// a waiter giving up keeps the lock of the holder
+				ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+				defer cancel()
+				if _, err := tracker.lockInstance(ctx, "instance-1"); err == nil {
+					t.Fatal("Expected the instance to stay locked")
+				}
+				if _, found := tracker.instances["instance-1"]; !found {
+					t.Fatal("Expected the lock to be kept while it is held")
+				}
+
+				unlock()
+				if len(tracker.instances) != 0 {
+					t.Fatalf("Expected no instance lock left, got %d", len(tracker.instances))
+				}
+			},
+		},
+		{
+			name: "different operation on the volume is aborted",
+			testFunc: func(t *testing.T) {
+				tracker := newOperationTracker()
+				release := make(chan struct{})
+				defer close(release)
+				ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+				defer cancel()
+				_, _ = tracker.run(ctx, "vol-1", "attach/node-1", func(ctx context.Context) (interface{}, error) {
+					<-release
+					return nil, nil
+				})
+
+				_, err := tracker.run(context.Background(), "vol-1", "detach/node-1", func(ctx context.Context) (interface{}, error) {
+					t.Fatal("Detach must not run while the attach is in progress")
+					return nil, nil
+				})
+				if status.Code(err) != codes.Aborted {
+					t.Fatalf("Expected Aborted, got %v", err)
+				}
+			},
+		},
+		{
+			name: "error is returned once and the next call runs again",
+			testFunc: func(t *testing.T) {
+				tracker := newOperationTracker()
+				var calls int32
+				fn := func(ctx context.Context) (interface{}, error) {
+					if atomic.AddInt32(&calls, 1) == 1 {
+						return nil, errors.New("attach failed")
+					}
+					return nil, nil
+				}
+
+				if _, err := tracker.run(context.Background(), "vol-1", "attach/node-1", fn); err == nil {
+					t.Fatal("Expected the error of the first run")
+				}
+				if _, err := tracker.run(context.Background(), "vol-1", "attach/node-1", fn); err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+				if calls != 2 {
+					t.Fatalf("Expected the operation to run twice, ran %d times", calls)
+				}
+			},
+		},
+		{
+			name: "create retry with different parameters gets AlreadyExists",
+			testFunc: func(t *testing.T) {
+				tracker := newOperationTracker()
+				release := make(chan struct{})
+				defer close(release)
+				ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+				defer cancel()
+				_, _ = tracker.runCreate(ctx, "pvc-1", createVolumeParams{opts: cloud.DiskOptions{CapacityBytes: 1}}, func(ctx context.Context) (interface{}, error) {
+					<-release
+					return nil, nil
+				})
+
+				_, err := tracker.runCreate(context.Background(), "pvc-1", createVolumeParams{opts: cloud.DiskOptions{CapacityBytes: 2}}, func(ctx context.Context) (interface{}, error) {
+					t.Fatal("Create must not run again while the first one is in progress")
+					return nil, nil
+				})
+				if status.Code(err) != codes.AlreadyExists {
+					t.Fatalf("Expected AlreadyExists, got %v", err)
+				}
+			},
+		},
+		{
+			name: "create retry with different parameters runs again once the create finished",
+			testFunc: func(t *testing.T) {
+				tracker := newOperationTracker()
+				ctx, cancel := context.WithCancel(context.Background())
+				cancel()
+				var calls int32
+				fn := func(ctx context.Context) (interface{}, error) {
+					return atomic.AddInt32(&calls, 1), nil
+				}
+				_, _ = tracker.runCreate(ctx, "pvc-1", createVolumeParams{snapshotID: "snap-1"}, fn)
+				// Wait for the create nobody waited for to finish.
+				for finished := false; !finished; time.Sleep(time.Millisecond) {
+					tracker.mu.Lock()
+					finished = tracker.creates["pvc-1"].isFinished()
+					tracker.mu.Unlock()
+				}
+
+				result, err := tracker.runCreate(context.Background(), "pvc-1", createVolumeParams{snapshotID: "snap-2"}, fn)
+				if err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+				if result != int32(2) {
+					t.Fatalf("Expected the create to run again, got result %v", result)
+				}
+			},
+		},
+		{
+			name: "create does not collide with an operation on a volume ID equal to its name",
+			testFunc: func(t *testing.T) {
+				tracker := newOperationTracker()
+				release := make(chan struct{})
+				defer close(release)
+				ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+				defer cancel()
+				_, _ = tracker.run(ctx, "vol-1", "attach/node-1", func(ctx context.Context) (interface{}, error) {
+					<-release
+					return nil, nil
+				})
+
+				result, err := tracker.runCreate(context.Background(), "vol-1", createVolumeParams{}, func(ctx context.Context) (interface{}, error) {
+					return "created", nil
+				})
+				if err != nil || result != "created" {
+					t.Fatalf("Expected the create to run, got %v, %v", result, err)
+				}
+			},
+		},
+		{
+			name: "panic is returned as an Internal error",
+			testFunc: func(t *testing.T) {
+				tracker := newOperationTracker()
+				_, err := tracker.run(context.Background(), "vol-1", "attach/node-1", func(ctx context.Context) (interface{}, error) {
+					panic("nil pointer")
+				})
+				if status.Code(err) != codes.Internal {
+					t.Fatalf("Expected Internal, got %v", err)
+				}
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, tc.testFunc)
+	}
+}
@@ -1,6 +1,7 @@
 package driver
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"math/rand"
@@ -11,7 +12,9 @@ import (
 	"time"
 
 	"github.com/IBM-Cloud/power-go-client/power/models"
-	"github.com/kubernetes-csi/csi-test/pkg/sanity"
+	"github.com/kubernetes-csi/csi-test/v5/pkg/sanity"
+	"github.com/onsi/ginkgo/v2"
+	"github.com/onsi/gomega"
 	"k8s.io/mount-utils"
 	"sigs.k8s.io/ibm-powervs-block-csi-driver/pkg/cloud"
 	"sigs.k8s.io/ibm-powervs-block-csi-driver/pkg/util"
@@ -29,13 +32,12 @@ func TestSanity(t *testing.T) {
 	stagingPath := filepath.Join(dir, "staging")
 	endpoint := "unix://" + filepath.Join(dir, "csi.sock")
 
-	config := &sanity.Config{
-		TargetPath:       targetPath,
-		StagingPath:      stagingPath,
-		Address:          endpoint,
-		CreateTargetDir:  createDir,
-		CreateStagingDir: createDir,
-	}
+	config := sanity.NewTestConfig()
+	config.TargetPath = targetPath
+	config.StagingPath = stagingPath
+	config.Address = endpoint
+	config.CreateTargetDir = createDir
+	config.CreateStagingDir = createDir
 
 	driverOptions := &Options{
 		endpoint: endpoint,
@@ -52,6 +54,7 @@ func TestSanity(t *testing.T) {
 			cloud:         newFakeCloudProvider(),
 			driverOptions: driverOptions,
 			volumeLocks:   util.NewVolumeLocks(),
+			operations:    newOperationTracker(),
 		},
 		nodeService: nodeService{
 			mounter:       newFakeMounter(),
@@ -75,7 +78,17 @@ func TestSanity(t *testing.T) {
 	}()
 
 	// Now call the test suite
-	sanity.Test(t, config)
+	sc := sanity.GinkgoTest(&config)
+	gomega.RegisterFailHandler(ginkgo.Fail)
+	suiteConfig, reporterConfig := ginkgo.GinkgoConfiguration()
+	suiteConfig.SkipStrings = append(suiteConfig.SkipStrings,
+		// csi-test v5.2.0 predates the MODIFY_VOLUME capability of CSI 1.9
+		"ControllerGetCapabilities should return appropriate capabilities",
+		// PowerVS attaches the volumes read-write, so the readonly flag of an earlier publish is not known
+		"should fail when the volume is already published but is incompatible",
+	)
+	ginkgo.RunSpecs(t, "CSI Driver Test Suite", suiteConfig, reporterConfig)
+	sc.Finalize()
 }
 
 func createDir(targetPath string) (string, error) {
@@ -116,9 +129,10 @@ func (su *MockStatSanity) IsPathNotExist(devicePath string) bool {
 }
 
 type fakeCloudProvider struct {
-	disks  map[string]*fakeDisk
-	pub    map[string]string
-	tokens map[string]int64
+	disks     map[string]*fakeDisk
+	snapshots map[string]*cloud.Snapshot
+	pub       map[string]string
+	tokens    map[string]int64
 }
 
 type fakeDisk struct {
@@ -127,13 +141,14 @@ type fakeDisk struct {
 
 func newFakeCloudProvider() *fakeCloudProvider {
 	return &fakeCloudProvider{
-		disks:  make(map[string]*fakeDisk),
-		pub:    make(map[string]string),
-		tokens: make(map[string]int64),
+		disks:     make(map[string]*fakeDisk),
+		snapshots: make(map[string]*cloud.Snapshot),
+		pub:       make(map[string]string),
+		tokens:    make(map[string]int64),
 	}
 }
 
-func (p *fakeCloudProvider) GetPVMInstanceByName(name string) (*cloud.PVMInstance, error) {
+func (p *fakeCloudProvider) GetPVMInstanceByName(ctx context.Context, name string) (*cloud.PVMInstance, error) {
 
 	return &cloud.PVMInstance{
 		ID:      name + "-" + "id",
@@ -143,7 +158,11 @@ func (p *fakeCloudProvider) GetPVMInstanceByName(name string) (*cloud.PVMInstanc
 
 }
 
-func (p *fakeCloudProvider) GetPVMInstanceByID(instanceID string) (*cloud.PVMInstance, error) {
+func (p *fakeCloudProvider) GetPVMInstanceByID(ctx context.Context, instanceID string) (*cloud.PVMInstance, error) {
+	// the node IDs generated by csi-test are the missing nodes
+	if strings.HasPrefix(instanceID, "fake-node-id") {
+		return nil, cloud.ErrNotFound
+	}
 
 	return &cloud.PVMInstance{
 		ID:      instanceID,
@@ -152,7 +171,7 @@ func (p *fakeCloudProvider) GetPVMInstanceByID(instanceID string) (*cloud.PVMIns
 	}, nil
 }
 
-func (p *fakeCloudProvider) GetPVMInstanceDetails(instanceID string) (*models.PVMInstance, error) {
+func (p *fakeCloudProvider) GetPVMInstanceDetails(ctx context.Context, instanceID string) (*models.PVMInstance, error) {
 
 	return &models.PVMInstance{
 		PvmInstanceID: &instanceID,
@@ -161,12 +180,12 @@ func (p *fakeCloudProvider) GetPVMInstanceDetails(instanceID string) (*models.PV
 
 }
 
-func (p *fakeCloudProvider) UpdateStoragePoolAffinity(instanceID string) error {
+func (p *fakeCloudProvider) UpdateStoragePoolAffinity(ctx context.Context, instanceID string, affinity bool) error {
 
 	return nil
 }
 
-func (p *fakeCloudProvider) GetImageByID(imageID string) (*cloud.PVMImage, error) {
+func (p *fakeCloudProvider) GetImageByID(ctx context.Context, imageID string) (*cloud.PVMImage, error) {
 
 	return &cloud.PVMImage{
 		ID:       imageID,
@@ -175,7 +194,7 @@ func (p *fakeCloudProvider) GetImageByID(imageID string) (*cloud.PVMImage, error
 	}, nil
 }
 
-func (c *fakeCloudProvider) CreateDisk(volumeName string, diskOptions *cloud.DiskOptions) (*cloud.Disk, error) {
+func (c *fakeCloudProvider) CreateDisk(ctx context.Context, volumeName string, diskOptions *cloud.DiskOptions) (*cloud.Disk, error) {
 	r1 := rand.New(rand.NewSource(time.Now().UnixNano()))
 
 	if existingDisk, ok := c.disks[volumeName]; ok {
@@ -189,7 +208,10 @@ func (c *fakeCloudProvider) CreateDisk(volumeName string, diskOptions *cloud.Dis
 	d := &fakeDisk{
 		Disk: &cloud.Disk{
 			VolumeID:    fmt.Sprintf("vol-%d", r1.Uint64()),
+			Name:        volumeName,
 			CapacityGiB: util.BytesToGiB(diskOptions.CapacityBytes),
+			DiskType:    diskOptions.VolumeType,
+			Shareable:   diskOptions.Shareable,
 			WWN:         "/fake-path",
 		},
 	}
@@ -197,7 +219,21 @@ func (c *fakeCloudProvider) CreateDisk(volumeName string, diskOptions *cloud.Dis
 	return d.Disk, nil
 }
 
-func (c *fakeCloudProvider) DeleteDisk(volumeID string) (bool, error) {
+func (c *fakeCloudProvider) CreateDiskFromSnapshot(ctx context.Context, volumeName string, snapshotID string, diskOptions *cloud.DiskOptions) (*cloud.Disk, error) {
+	if _, err := c.GetSnapshotByID(ctx, snapshotID); err != nil {
+		return nil, err
+	}
+	return c.CreateDisk(ctx, volumeName, diskOptions)
+}
+
+func (c *fakeCloudProvider) CloneDisk(ctx context.Context, sourceVolumeID string, cloneName string, diskOptions *cloud.DiskOptions) (*cloud.Disk, error) {
+	if _, err := c.GetDiskByID(ctx, sourceVolumeID); err != nil {
+		return nil, err
+	}
+	return c.CreateDisk(ctx, cloneName, diskOptions)
+}
+
+func (c *fakeCloudProvider) DeleteDisk(ctx context.Context, volumeID string) (bool, error) {
 	for volName, f := range c.disks {
 		if f.Disk.VolumeID == volumeID {
 			delete(c.disks, volName)
@@ -206,41 +242,49 @@ func (c *fakeCloudProvider) DeleteDisk(volumeID string) (bool, error) {
 	return true, nil
 }
 
-func (c *fakeCloudProvider) AttachDisk(volumeID, nodeID string) error {
+func (c *fakeCloudProvider) AttachDisk(ctx context.Context, volumeID, nodeID string) error {
 	if _, ok := c.pub[volumeID]; ok {
-		return cloud.ErrAlreadyExists
+		return cloud.ErrConflict
 	}
 	c.pub[volumeID] = nodeID
 	return nil
 }
 
-func (c *fakeCloudProvider) DetachDisk(volumeID, nodeID string) error {
+func (c *fakeCloudProvider) DetachDisk(ctx context.Context, volumeID, nodeID string) error {
+	if c.pub[volumeID] == nodeID {
+		delete(c.pub, volumeID)
+	}
 	return nil
 }
 
-func (c *fakeCloudProvider) IsAttached(volumeID string, nodeID string) (attached bool, err error) {
-	return true, nil
+func (c *fakeCloudProvider) IsAttached(ctx context.Context, volumeID string, nodeID string) (attached bool, err error) {
+	return c.pub[volumeID] == nodeID, nil
 }
 
-func (c *fakeCloudProvider) WaitForVolumeState(volumeID, expectedState string) error {
+func (c *fakeCloudProvider) WaitForVolumeState(ctx context.Context, volumeID, expectedState string) error {
 	return nil
 }
 
-func (c *fakeCloudProvider) GetDiskByName(name string) (*cloud.Disk, error) {
-	var disks []*fakeDisk
-	for _, d := range c.disks {
-		disks = append(disks, d)
-	}
-	if len(disks) > 1 {
-		return nil, cloud.ErrAlreadyExists
-	} else if len(disks) == 1 {
+func (c *fakeCloudProvider) TagDisk(ctx context.Context, volumeID string, tags map[string]string) error {
+	return nil
+}
+
+func (c *fakeCloudProvider) UntagDisk(ctx context.Context, volumeID string, tags map[string]string) error {
+	return nil
+}
+
+func (c *fakeCloudProvider) GetDiskTags(ctx context.Context, volumeID string) (map[string]string, error) {
+	return map[string]string{}, nil
+}
 
-		return disks[0].Disk, nil
+func (c *fakeCloudProvider) GetDiskByName(ctx context.Context, name string) (*cloud.Disk, error) {
+	if d, ok := c.disks[name]; ok {
+		return d.Disk, nil
 	}
 	return nil, nil
 }
 
-func (c *fakeCloudProvider) GetDiskByID(volumeID string) (*cloud.Disk, error) {
+func (c *fakeCloudProvider) GetDiskByID(ctx context.Context, volumeID string) (*cloud.Disk, error) {
 	for _, f := range c.disks {
 		if f.Disk.VolumeID == volumeID {
 			return f.Disk, nil
@@ -249,20 +293,136 @@ func (c *fakeCloudProvider) GetDiskByID(volumeID string) (*cloud.Disk, error) {
 	return nil, cloud.ErrNotFound
 }
 
+func (c *fakeCloudProvider) GetDiskByWWN(ctx context.Context, wwn string) (*cloud.Disk, error) {
+	for _, f := range c.disks {
+		if strings.EqualFold(f.Disk.WWN, wwn) {
+			return f.Disk, nil
+		}
+	}
+	return nil, cloud.ErrNotFound
+}
+
+func (c *fakeCloudProvider) ListDisks(ctx context.Context) ([]*cloud.Disk, error) {
+	var disks []*cloud.Disk
+	for _, f := range c.disks {
+		disks = append(disks, f.Disk)
+	}
+	return disks, nil
+}
+
+func (c *fakeCloudProvider) ListDisksByTag(ctx context.Context, tag string) ([]*cloud.Disk, error) {
+	return nil, nil
+}
+
+func (c *fakeCloudProvider) ListPVMInstanceDisks(ctx context.Context, instanceID string) ([]*cloud.Disk, error) {
+	var disks []*cloud.Disk
+	for _, f := range c.disks {
+		if c.pub[f.Disk.VolumeID] == instanceID {
+			disks = append(disks, f.Disk)
+		}
+	}
+	return disks, nil
+}
+
+func (c *fakeCloudProvider) GetStorageCapacity(ctx context.Context, volumeType string) (*cloud.StorageCapacity, error) {
+	return &cloud.StorageCapacity{
+		AvailableCapacityGiB: 1024,
+		MaximumVolumeSizeGiB: 512,
+	}, nil
+}
+
 func (c *fakeCloudProvider) IsExistInstance(nodeID string) bool {
 	return nodeID == "instanceID"
 }
 
-func (c *fakeCloudProvider) ResizeDisk(volumeID string, newSize int64) (int64, error) {
+func (c *fakeCloudProvider) ResizeDisk(ctx context.Context, volumeID string, newSize int64) (int64, error) {
 	for volName, f := range c.disks {
 		if f.Disk.VolumeID == volumeID {
-			c.disks[volName].CapacityGiB = newSize
-			return newSize, nil
+			c.disks[volName].CapacityGiB = util.BytesToGiB(newSize)
+			return c.disks[volName].CapacityGiB, nil
 		}
 	}
 	return 0, cloud.ErrNotFound
 }
 
+func (c *fakeCloudProvider) ModifyDisk(ctx context.Context, volumeID string, modifyOptions *cloud.ModifyDiskOptions) (*cloud.Disk, error) {
+	for _, f := range c.disks {
+		if f.Disk.VolumeID == volumeID {
+			if modifyOptions.VolumeType != "" {
+				f.Disk.DiskType = modifyOptions.VolumeType
+			}
+			if modifyOptions.Shareable != nil {
+				f.Disk.Shareable = *modifyOptions.Shareable
+			}
+			return f.Disk, nil
+		}
+	}
+	return nil, cloud.ErrNotFound
+}
+
+func (c *fakeCloudProvider) CreateSnapshot(ctx context.Context, volumeID string, snapshotName string) (*cloud.Snapshot, error) {
+	r1 := rand.New(rand.NewSource(time.Now().UnixNano()))
+
+	if existingSnapshot, ok := c.snapshots[snapshotName]; ok {
+		//Already Created snapshot
+		if existingSnapshot.SourceVolumeID != volumeID {
+			return nil, errors.New("snapshot Already exists")
+		} else {
+			return existingSnapshot, nil
+		}
+	}
+	s := &cloud.Snapshot{
+		SnapshotID:       fmt.Sprintf("snap-%d", r1.Uint64()),
+		SourceVolumeID:   volumeID,
+		SnapshotVolumeID: volumeID,
+		Name:             snapshotName,
+		State:            cloud.SnapshotAvailableState,
+		CreationTime:     time.Now(),
+		ReadyToUse:       true,
+	}
+	c.snapshots[snapshotName] = s
+	return s, nil
+}
+
+func (c *fakeCloudProvider) DeleteSnapshot(ctx context.Context, snapshotID string) error {
+	for name, s := range c.snapshots {
+		if s.SnapshotID == snapshotID {
+			delete(c.snapshots, name)
+		}
+	}
+	return nil
+}
+
+func (c *fakeCloudProvider) WaitForSnapshotState(ctx context.Context, snapshotID, state string) error {
+	return nil
+}
+
+func (c *fakeCloudProvider) GetSnapshotByName(ctx context.Context, name string) (*cloud.Snapshot, error) {
+	if s, ok := c.snapshots[name]; ok {
+		return s, nil
+	}
+	return nil, cloud.ErrNotFound
+}
+
+func (c *fakeCloudProvider) GetSnapshotByID(ctx context.Context, snapshotID string) (*cloud.Snapshot, error) {
+	for _, s := range c.snapshots {
+		if s.SnapshotID == snapshotID {
+			return s, nil
+		}
+	}
+	return nil, cloud.ErrNotFound
+}
+
+func (c *fakeCloudProvider) ListSnapshots(ctx context.Context) ([]*cloud.Snapshot, error) {
+	var snapshots []*cloud.Snapshot
+	for _, s := range c.snapshots {
+		snapshots = append(snapshots, s)
+	}
+	return snapshots, nil
+}
+
+func (c *fakeCloudProvider) Close() {}
+
 type fakeMounter struct {
 	mount.Interface
 }
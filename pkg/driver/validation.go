@@ -18,12 +18,37 @@ package driver
 
 import (
 	"fmt"
+	"regexp"
+
+	"sigs.k8s.io/ibm-powervs-block-csi-driver/pkg/cloud"
+)
+
+const (
+	// maxTagLength is the maximum length of an IBM Cloud tag, key and value included
+	maxTagLength = 128
+)
+
+var (
+	tagKeyRegexp   = regexp.MustCompile(`^[A-Za-z0-9 _.-]+$`)
+	tagValueRegexp = regexp.MustCompile(`^[A-Za-z0-9 _.:-]*$`)
 )
 
 func ValidateDriverOptions(options *Options) error {
 	if err := validateMode(options.mode); err != nil {
 		return fmt.Errorf("Invalid mode: %v", err)
 	}
+	if err := validateExtraTags(options.extraTags); err != nil {
+		return fmt.Errorf("Invalid extra tags: %v", err)
+	}
+	if err := validateTag(cloud.ClusterIDTagKey, options.kubernetesClusterID); err != nil {
+		return fmt.Errorf("Invalid kubernetes cluster ID: %v", err)
+	}
+	if _, err := generateVolumeName(options.volumeNameTemplate, volumeNameData{}); err != nil {
+		return fmt.Errorf("Invalid volume name template: %v", err)
+	}
+	if err := validateRetryPolicy(options.retryPolicy); err != nil {
+		return fmt.Errorf("Invalid PowerVS API retry policy: %v", err)
+	}
 	return nil
 }
 
@@ -34,3 +59,46 @@ func validateMode(mode Mode) error {
 
 	return nil
 }
+
+func validateRetryPolicy(policy cloud.RetryPolicy) error {
+	if policy.QPS < 0 {
+		return fmt.Errorf("QPS must not be negative (actual: %v)", policy.QPS)
+	}
+	if policy.QPS > 0 && policy.Burst < 1 {
+		return fmt.Errorf("Burst must be at least 1 when QPS is set (actual: %d)", policy.Burst)
+	}
+	if policy.MaxRetries < 0 {
+		return fmt.Errorf("MaxRetries must not be negative (actual: %d)", policy.MaxRetries)
+	}
+	if policy.MaxRetries > 0 && (policy.InitialBackoff <= 0 || policy.MaxBackoff < policy.InitialBackoff) {
+		return fmt.Errorf("InitialBackoff must be positive and not larger than MaxBackoff (actual: %v, %v)", policy.InitialBackoff, policy.MaxBackoff)
+	}
+	return nil
+}
+
+func validateExtraTags(tags map[string]string) error {
+	for key, value := range tags {
+		switch key {
+		case cloud.ClusterIDTagKey, cloud.PVCNameTagKey, cloud.PVCNamespaceTagKey, cloud.PVNameTagKey:
+			return fmt.Errorf("Tag key '%s' is reserved", key)
+		}
+		if err := validateTag(key, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateTag checks that key and value form a valid IBM Cloud "key:value" tag.
+func validateTag(key, value string) error {
+	if !tagKeyRegexp.MatchString(key) {
+		return fmt.Errorf("Tag key '%s' may only contain letters, numbers, spaces, '_', '.' and '-'", key)
+	}
+	if !tagValueRegexp.MatchString(value) {
+		return fmt.Errorf("Tag value '%s' may only contain letters, numbers, spaces, '_', '.', ':' and '-'", value)
+	}
+	if len(key)+len(value)+1 > maxTagLength {
+		return fmt.Errorf("Tag '%s:%s' is longer than %d characters", key, value, maxTagLength)
+	}
+	return nil
+}
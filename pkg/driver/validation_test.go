@@ -16,7 +16,11 @@ package driver
 import (
 	"fmt"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
+
+	"sigs.k8s.io/ibm-powervs-block-csi-driver/pkg/cloud"
 )
 
 func TestValidateMode(t *testing.T) {
@@ -62,6 +66,9 @@ func TestValidateDriverOptions(t *testing.T) {
 		name            string
 		mode            Mode
 		extraVolumeTags map[string]string
+		clusterID       string
+		volumeTemplate  string
+		retryPolicy     cloud.RetryPolicy
 		expErr          error
 	}{
 		{
@@ -69,18 +76,89 @@ func TestValidateDriverOptions(t *testing.T) {
 			mode:   AllMode,
 			expErr: nil,
 		},
+		{
+			name: "success with extra tags and cluster ID",
+			mode: AllMode,
+			extraVolumeTags: map[string]string{
+				"owner": "team-a",
+				"env":   "prod:eu",
+			},
+			clusterID: "cluster-1",
+			expErr:    nil,
+		},
 		{
 			name:   "fail because validateMode fails",
 			mode:   Mode("unknown"),
 			expErr: fmt.Errorf("Invalid mode: Mode is not supported (actual: unknown, supported: %v)", []Mode{AllMode, ControllerMode, NodeMode}),
 		},
+		{
+			name: "fail because extra tag key contains a colon",
+			mode: AllMode,
+			extraVolumeTags: map[string]string{
+				"owner:name": "team-a",
+			},
+			expErr: fmt.Errorf("Invalid extra tags: Tag key 'owner:name' may only contain letters, numbers, spaces, '_', '.' and '-'"),
+		},
+		{
+			name: "fail because extra tag key is reserved",
+			mode: AllMode,
+			extraVolumeTags: map[string]string{
+				cloud.ClusterIDTagKey: "cluster-1",
+			},
+			expErr: fmt.Errorf("Invalid extra tags: Tag key '%s' is reserved", cloud.ClusterIDTagKey),
+		},
+		{
+			name: "fail because extra tag is too long",
+			mode: AllMode,
+			extraVolumeTags: map[string]string{
+				"owner": strings.Repeat("a", maxTagLength),
+			},
+			expErr: fmt.Errorf("Invalid extra tags: Tag 'owner:%s' is longer than %d characters", strings.Repeat("a", maxTagLength), maxTagLength),
+		},
+		{
+			name:           "fail because volume name template uses an unknown field",
+			mode:           AllMode,
+			volumeTemplate: "{{.Cluster}}",
+			expErr:         fmt.Errorf("Invalid volume name template: template: volume-name:1:2: executing \"volume-name\" at <.Cluster>: can't evaluate field Cluster in type driver.volumeNameData"),
+		},
+		{
+			name:      "fail because cluster ID contains invalid characters",
+			mode:      AllMode,
+			clusterID: "cluster/1",
+			expErr:    fmt.Errorf("Invalid kubernetes cluster ID: Tag value 'cluster/1' may only contain letters, numbers, spaces, '_', '.', ':' and '-'"),
+		},
+		{
+			name:        "success with the default retry policy",
+			mode:        AllMode,
+			retryPolicy: cloud.DefaultRetryPolicy,
+			expErr:      nil,
+		},
+		{
+			name:        "fail because QPS is set without burst",
+			mode:        AllMode,
+			retryPolicy: cloud.RetryPolicy{QPS: 5},
+			expErr:      fmt.Errorf("Invalid PowerVS API retry policy: Burst must be at least 1 when QPS is set (actual: 0)"),
+		},
+		{
+			name: "fail because the initial backoff is larger than the maximum backoff",
+			mode: AllMode,
+			retryPolicy: cloud.RetryPolicy{
+				MaxRetries:     3,
+				InitialBackoff: time.Minute,
+				MaxBackoff:     time.Second,
+			},
+			expErr: fmt.Errorf("Invalid PowerVS API retry policy: InitialBackoff must be positive and not larger than MaxBackoff (actual: 1m0s, 1s)"),
+		},
 	}
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
 			err := ValidateDriverOptions(&Options{
-				extraTags: tc.extraVolumeTags,
-				mode:      tc.mode,
+				extraTags:           tc.extraVolumeTags,
+				kubernetesClusterID: tc.clusterID,
+				volumeNameTemplate:  tc.volumeTemplate,
+				retryPolicy:         tc.retryPolicy,
+				mode:                tc.mode,
 			})
 			if !reflect.DeepEqual(err, tc.expErr) {
 				t.Fatalf("error not equal\ngot:\n%s\nexpected:\n%s", err, tc.expErr)
@@ -33,6 +33,13 @@ const (
 	GiB = 1024 * 1024 * 1024
 )
 
+const (
+	// DriverName is the name of the CSI driver
+	DriverName = "powervs.csi.ibm.com"
+	// DiskTypeKey is the topology key, also set as a Node label, carrying the disk type of the PowerVS instance
+	DiskTypeKey = "topology." + DriverName + "/disk-type"
+)
+
 // RoundUpBytes rounds up the volume size in bytes upto multiplications of GiB
 // in the unit of Bytes
 func RoundUpBytes(volumeSizeBytes int64) int64 {
@@ -52,8 +52,7 @@ func (d *powervsCSIDriver) GetDynamicProvisionStorageClass(parameters map[string
 			{
 				MatchLabelExpressions: []v1.TopologySelectorLabelRequirement{
 					{
-						// TODO we should use the new topology key eventually
-						Key:    powervscsidriver.TopologyKey,
+						Key:    powervscsidriver.DiskTypeKey,
 						Values: allowedTopologyValues,
 					},
 				},
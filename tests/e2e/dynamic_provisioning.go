@@ -337,7 +337,7 @@ var _ = Describe("[powervs-csi-e2e]Dynamic Provisioning", func() {
 			Skip(fmt.Sprintf("Could not get cloudInstanceId : %v", err))
 		}
 
-		cloud, err := powervscloud.NewPowerVSCloud(metadata.GetCloudInstanceId(), metadata.GetZone(), debug)
+		cloud, err := powervscloud.NewPowerVSCloud(metadata.GetCloudInstanceId(), metadata.GetZone(), debug, powervscloud.DefaultRetryPolicy)
 		if err != nil {
 			Fail(fmt.Sprintf("could not get NewCloud: %v", err))
 		}
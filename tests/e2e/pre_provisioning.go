@@ -17,6 +17,7 @@ limitations under the License.
 package e2e
 
 import (
+	"context"
 	"fmt"
 	"math/rand"
 	"os"
@@ -79,13 +80,13 @@ var _ = Describe("[powervs-csi-e2e]Pre-Provisioned", func() {
 			Skip(fmt.Sprintf("Could not get cloudInstanceId : %v", err))
 		}
 
-		cloud, err = powervscloud.NewPowerVSCloud(metadata.GetCloudInstanceId(), metadata.GetZone(), debug)
+		cloud, err = powervscloud.NewPowerVSCloud(metadata.GetCloudInstanceId(), metadata.GetZone(), debug, powervscloud.DefaultRetryPolicy)
 		if err != nil {
 			Fail(fmt.Sprintf("could not get NewCloud: %v", err))
 		}
 
 		r1 := rand.New(rand.NewSource(time.Now().UnixNano()))
-		disk, err := cloud.CreateDisk(fmt.Sprintf("pvc-%d", r1.Uint64()), diskOptions)
+		disk, err := cloud.CreateDisk(context.Background(), fmt.Sprintf("pvc-%d", r1.Uint64()), diskOptions)
 		if err != nil {
 			Fail(fmt.Sprintf("Create Disk failed: %v", err))
 		}
@@ -98,11 +99,11 @@ var _ = Describe("[powervs-csi-e2e]Pre-Provisioned", func() {
 	AfterEach(func() {
 		skipManuallyDeletingVolume = true
 		if !skipManuallyDeletingVolume {
-			err := cloud.WaitForVolumeState(volumeID, "detached")
+			err := cloud.WaitForVolumeState(context.Background(), volumeID, "detached")
 			if err != nil {
 				Fail(fmt.Sprintf("could not detach volume %q: %v", volumeID, err))
 			}
-			ok, err := cloud.DeleteDisk(volumeID)
+			ok, err := cloud.DeleteDisk(context.Background(), volumeID)
 			if err != nil || !ok {
 				Fail(fmt.Sprintf("could not delete volume %q: %v", volumeID, err))
 			}